@@ -14,27 +14,58 @@ package main
 import (
 	"context"
 	_ "embed"
+	"os"
+	"time"
 
 	"github.com/afreidah/health-check-service/internal/app"
-	"github.com/afreidah/health-check-service/internal/cache"
+	"github.com/afreidah/health-check-service/internal/handlers"
 )
 
 //go:embed static/dashboard.html
 var dashboardHTML []byte
 
 func main() {
+	os.Exit(run())
+}
+
+// run wires up and runs the service, returning the process exit code.
+// Deferred cleanup runs before main exits.
+func run() int {
+	handlers.ProcessStart = time.Now()
+
 	cfg := app.MustLoadConfig()
+	if cfg.CheckConfig {
+		return app.PrintConfig(cfg)
+	}
 
 	ctx := context.Background()
+	stopTracing := app.MustInitTracing(ctx)
+	defer stopTracing()
+
+	stopStatsD := app.StartStatsD(cfg)
+	defer stopStatsD()
+
 	conn := app.MustConnectDBus(ctx, cfg)
-	defer conn.Close()
+	if conn != nil {
+		defer conn.Close()
+	}
 
-	serviceCache := cache.New()
-	srv := app.SetupHTTPServer(cfg, serviceCache, dashboardHTML)
+	caches := app.NewRegistry(cfg)
+	defer app.SaveState(caches)
+	checkers := app.StartBackgroundChecker(conn, cfg, caches)
 
-	cancelChecker, _ := app.StartBackgroundChecker(conn, cfg, serviceCache)
+	limiters := app.NewLimiters(cfg)
+	srv := app.SetupHTTPServer(cfg, caches, checkers, limiters, dashboardHTML)
 
 	app.StartHTTPServer(srv, cfg)
 
-	app.WaitForShutdown(srv, cancelChecker)
+	reload := func() {
+		checkers.ReopenEventLog()
+		app.ReloadConfig(checkers, limiters)
+	}
+	preShutdownDelay := time.Duration(cfg.PreShutdownDelay) * time.Second
+	if err := app.WaitForShutdown(srv, checkers.Stop, reload, checkers.Failed(), preShutdownDelay); err != nil {
+		return 1
+	}
+	return 0
 }
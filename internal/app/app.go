@@ -13,13 +13,20 @@ package app
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/subtle"
 	"crypto/tls"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -27,23 +34,33 @@ import (
 	"github.com/afreidah/health-check-service/internal/checker"
 	"github.com/afreidah/health-check-service/internal/config"
 	"github.com/afreidah/health-check-service/internal/handlers"
+	"github.com/afreidah/health-check-service/internal/journal"
 	"github.com/afreidah/health-check-service/internal/logging"
 	"github.com/afreidah/health-check-service/internal/metrics"
+	"github.com/afreidah/health-check-service/internal/notify"
 	"github.com/afreidah/health-check-service/internal/ratelimit"
+	"github.com/afreidah/health-check-service/internal/statsd"
+	"github.com/afreidah/health-check-service/internal/tracing"
+	"github.com/afreidah/health-check-service/internal/version"
 	"github.com/coreos/go-systemd/v22/dbus"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"golang.org/x/crypto/acme/autocert"
 )
 
-// Build-time metadata injected via linker flags during compilation.
-var (
-	version = "dev"
-	commit  = "unknown"
-	date    = "unknown"
-)
-
 var loga = slog.Default().With("component", "app")
 
+// draining forces health endpoints to 503 so the node can be pulled from a
+// load balancer ahead of shutdown. Set by /admin/drain and by the
+// pre-shutdown delay.
+var draining atomic.Bool
+
+// Watchdog defaults, used when the corresponding config fields are zero.
+const (
+	defaultWatchdogInterval   = 10 * time.Second
+	defaultWatchdogMultiplier = 2.0
+)
+
 // -----------------------------------------------------------------------
 // Configuration & D-Bus Setup
 // -----------------------------------------------------------------------
@@ -52,17 +69,27 @@ var loga = slog.Default().With("component", "app")
 // and performs validation. If configuration is invalid or incomplete, the
 // service logs an error and exits with status code 1. The logger is
 // initialized twice: first with generic metadata, then re-initialized with
-// the monitored service name as a permanent log context field.
+// the monitored service name as a permanent log context field. The
+// effective configuration is then logged with the source of each setting.
+// With --check_config, logging to stdout is moved to stderr.
 func MustLoadConfig() *config.Config {
+	// --check_config prints the configuration to stdout, so logs that would
+	// go there are sent to stderr to keep the output parseable
+	if config.CheckConfigRequested(os.Args[1:]) {
+		if output := os.Getenv("LOG_OUTPUT"); output == "" || output == "stdout" {
+			os.Setenv("LOG_OUTPUT", "stderr")
+		}
+	}
+
 	// Initialize structured logging first with generic metadata
 	logging.InitFromEnv(map[string]string{
 		"service":    "health-check-service",
-		"version":    version,
-		"commit":     commit,
-		"build_date": date,
+		"version":    version.Version,
+		"commit":     version.Commit,
+		"build_date": version.Date,
 	})
 
-	cfg, err := config.Load()
+	cfg, sources, err := config.LoadWithSources()
 	if err != nil {
 		loga.Error("configuration error", "err", err)
 		os.Exit(1)
@@ -72,62 +99,214 @@ func MustLoadConfig() *config.Config {
 	logging.InitFromEnv(map[string]string{
 		"service":    "health-check-service",
 		"unit":       cfg.Service,
-		"version":    version,
-		"commit":     commit,
-		"build_date": date,
+		"version":    version.Version,
+		"commit":     version.Commit,
+		"build_date": version.Date,
 	})
 
 	loga = slog.Default().With("component", "app")
 
+	if cfg.CheckConfig {
+		return cfg
+	}
+
 	loga.Info("Health Check Service Starting",
 		"service", cfg.Service,
+		"services", cfg.MonitoredServices(),
 		"port", cfg.Port,
+		"listen_addr", cfg.ListenAddr,
 		"interval_sec", cfg.Interval,
 	)
-	loga.Info("TLS/Autocert settings",
-		"tls_enabled", cfg.TLSEnabled,
-		"autocert", cfg.TLSAutocert,
-	)
+	logEffectiveConfig(cfg, sources)
 
 	return cfg
 }
 
-// MustConnectDBus establishes a connection to the systemd D-Bus service and
-// validates that the target service exists in the current systemd
-// configuration. If the connection fails or the service cannot be found, the
-// application exits with status code 1 after logging the error condition.
+// logEffectiveConfig logs every setting once with its value and the source
+// that supplied it (flag, env, file:<path> or default), secrets redacted,
+// to answer why a particular value is in use.
+func logEffectiveConfig(cfg *config.Config, sources config.Sources) {
+	effective := cfg.Effective()
+	keys := make([]string, 0, len(effective))
+	for key := range effective {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]any, 0, len(keys))
+	for _, key := range keys {
+		attrs = append(attrs, slog.Group(key, "value", effective[key], "source", sources.Of(key)))
+	}
+	loga.Info("effective configuration", attrs...)
+}
+
+// PrintConfig writes the validated configuration to stdout as YAML for
+// --check_config and returns the process exit code. Reaching it means
+// loading and validation succeeded; failures already exited with 1.
+func PrintConfig(cfg *config.Config) int {
+	out, err := cfg.EffectiveYAML()
+	if err != nil {
+		loga.Error("cannot render configuration", "err", err)
+		return 1
+	}
+	if _, err := os.Stdout.Write(out); err != nil {
+		return 1
+	}
+	loga.Info("configuration is valid")
+	return 0
+}
+
+// MustConnectDBus establishes a connection to the systemd D-Bus service on
+// the configured scope (system or session bus) and validates that every
+// monitored service exists in the current systemd configuration. If the
+// connection fails or a service cannot be found, the application exits with
+// status code 1 after logging the error condition. When only TCP or HTTP
+// targets are monitored no connection is made and nil is returned.
 func MustConnectDBus(ctx context.Context, cfg *config.Config) *dbus.Conn {
-	conn, err := dbus.NewSystemConnectionContext(ctx)
+	// Unit names are built from the unit type from here on
+	checker.ConfigureUnitType(cfg.UnitType)
+
+	services := cfg.SystemdServices()
+	if len(services) == 0 {
+		loga.Info("no systemd services monitored; not connecting to D-Bus")
+		return nil
+	}
+
+	conn, err := checker.Connect(ctx, cfg.DBusScope)
 	if err != nil {
-		loga.Error("failed to connect to D-Bus", "err", err)
+		loga.Error("failed to connect to D-Bus", "scope", cfg.DBusScope, "err", err)
 		os.Exit(1)
 	}
 
-	// Validate that the target service exists in systemd before proceeding
-	if _, err := conn.GetUnitPropertyContext(ctx, cfg.Service+".service", "ActiveState"); err != nil {
-		loga.Error("service not found in systemd", "service", cfg.Service, "err", err)
-		os.Exit(1)
+	// Validate that each target service exists in systemd before proceeding
+	for _, service := range services {
+		if _, err := conn.GetUnitPropertyContext(ctx, checker.UnitName(service), "ActiveState"); err != nil {
+			loga.Error("service not found in systemd", "service", service, "unit", checker.UnitName(service), "err", err)
+			os.Exit(1)
+		}
+		loga.Info("successfully validated service", "service", service, "unit", checker.UnitName(service))
 	}
-	loga.Info("successfully validated service", "service", cfg.Service)
 
 	return conn
 }
 
+// MustInitTracing enables OpenTelemetry tracing when
+// OTEL_EXPORTER_OTLP_ENDPOINT is set and returns a function that flushes
+// pending spans on shutdown. An invalid exporter configuration is fatal, so
+// a misconfigured deployment fails at startup rather than silently dropping
+// traces.
+func MustInitTracing(ctx context.Context) func() {
+	shutdown, enabled, err := tracing.Init(ctx)
+	if err != nil {
+		loga.Error("failed to initialize tracing", "err", err)
+		os.Exit(1)
+	}
+	if enabled {
+		loga.Info("OpenTelemetry tracing enabled",
+			"endpoint", os.Getenv(tracing.EndpointEnv))
+	}
+
+	return func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdown(flushCtx); err != nil {
+			loga.Error("failed to flush traces", "err", err)
+		}
+	}
+}
+
+// NewRegistry creates the cache registry for the monitored services and,
+// when state_file is configured, restores the status saved by the previous
+// process. A missing or unreadable state file is not fatal: the caches
+// start uninitialized as usual.
+func NewRegistry(cfg *config.Config) *cache.Registry {
+	caches := cache.NewRegistry(cfg.MonitoredServices(), cfg.HistorySize)
+	if cfg.StateFile == "" {
+		return caches
+	}
+
+	if err := caches.PersistTo(cfg.StateFile); err != nil {
+		loga.Warn("failed to load state file; starting uninitialized",
+			"path", cfg.StateFile, "err", err)
+	} else {
+		loga.Info("status persistence enabled", "path", cfg.StateFile)
+	}
+	return caches
+}
+
+// SaveState writes the state file one last time at shutdown, so a status
+// change still waiting on the debounced write is not lost.
+func SaveState(caches *cache.Registry) {
+	if err := caches.SaveState(); err != nil {
+		loga.Error("failed to write state file", "err", err)
+	}
+}
+
+// StartStatsD mirrors key metrics to statsd_addr when it is configured and
+// returns a function that stops the sink after a final flush. A sink that
+// cannot be created is logged and skipped; health checking does not depend
+// on it.
+func StartStatsD(cfg *config.Config) func() {
+	if cfg.StatsDAddr == "" {
+		return func() {}
+	}
+
+	sink, err := statsd.New(statsd.Options{
+		Addr:     cfg.StatsDAddr,
+		Interval: time.Duration(cfg.StatsDFlushInterval) * time.Second,
+		Tags:     cfg.StatsDTags,
+	}, prometheus.DefaultGatherer)
+	if err != nil {
+		loga.Error("failed to start StatsD sink; continuing without it", "err", err)
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sink.Run(ctx)
+	}()
+
+	loga.Info("StatsD metrics enabled",
+		"addr", cfg.StatsDAddr,
+		"flush_interval_sec", cfg.StatsDFlushInterval,
+		"tags", cfg.StatsDTags)
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
 // -----------------------------------------------------------------------
 // Rate Limited Handler
 // -----------------------------------------------------------------------
 
 // RateLimitedHandler wraps an HTTP handler with per-IP rate limiting.
+// Clients in the bypass allowlist skip the limiter entirely. The allowlist
+// is matched against ratelimit.TrustedIP, so an untrusted forwarding header
+// cannot claim an allowlisted address. Endpoints with a strict limit of
+// their own (restart, profiling, fresh checks) leave bypass empty, so
+// allowlisted clients are still held to it.
 type RateLimitedHandler struct {
 	handler  http.Handler
 	limiter  *ratelimit.Manager
 	endpoint string
+	bypass   ratelimit.Allowlist
 }
 
 // ServeHTTP implements the http.Handler interface with rate limiting applied.
 func (h *RateLimitedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r = handlers.WithEndpoint(r, h.endpoint)
+	r = handlers.WithRequestLogger(w, r)
 	ip := ratelimit.GetIP(r)
 
+	if h.bypass.Contains(ratelimit.TrustedIP(r)) {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+
 	if !h.limiter.Allow(ip) {
 		// Include rate limit info in response headers
 		w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%.0f", h.limiter.GetRate()))
@@ -140,6 +319,8 @@ func (h *RateLimitedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			"method", r.Method,
 			"path", r.URL.Path,
 		)
+		metrics.RateLimitRejected.WithLabelValues(h.endpoint).Inc()
+		metrics.RequestsTotal.WithLabelValues(h.endpoint, "429").Inc()
 
 		http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
 		return
@@ -153,87 +334,456 @@ func (h *RateLimitedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.handler.ServeHTTP(w, r)
 }
 
+// -----------------------------------------------------------------------
+// Basic Auth Handler
+// -----------------------------------------------------------------------
+
+// BasicAuthHandler requires HTTP Basic credentials before delegating to the
+// wrapped handler.
+type BasicAuthHandler struct {
+	handler  http.Handler
+	username string
+	password string
+	realm    string
+}
+
+// ServeHTTP implements the http.Handler interface with credential checking.
+func (h *BasicAuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, pass, ok := r.BasicAuth()
+	if !ok || !credentialsMatch(user, pass, h.username, h.password) {
+		slog.Warn("basic auth rejected",
+			"ip", ratelimit.GetIP(r),
+			"realm", h.realm,
+			"path", r.URL.Path,
+		)
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Basic realm=%q, charset="UTF-8"`, h.realm))
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	h.handler.ServeHTTP(w, r)
+}
+
+// requireBasicAuth wraps handler with Basic Auth when a username is
+// configured, and returns it unchanged otherwise.
+func requireBasicAuth(handler http.Handler, username, password, realm string) http.Handler {
+	if username == "" {
+		return handler
+	}
+	return &BasicAuthHandler{
+		handler:  handler,
+		username: username,
+		password: password,
+		realm:    realm,
+	}
+}
+
+// credentialsMatch compares credentials in constant time. Both fields are
+// always compared so timing does not reveal which one was wrong.
+func credentialsMatch(user, pass, wantUser, wantPass string) bool {
+	userOK := secretsEqual(user, wantUser)
+	passOK := secretsEqual(pass, wantPass)
+	return userOK && passOK
+}
+
+// secretsEqual compares two secrets in constant time. Values are hashed
+// first so the comparison does not leak their lengths.
+func secretsEqual(got, want string) bool {
+	gotHash := sha256.Sum256([]byte(got))
+	wantHash := sha256.Sum256([]byte(want))
+	return subtle.ConstantTimeCompare(gotHash[:], wantHash[:]) == 1
+}
+
+// -----------------------------------------------------------------------
+// Bearer Token Handler
+// -----------------------------------------------------------------------
+
+// BearerTokenHandler requires an "Authorization: Bearer <token>" header
+// before delegating to the wrapped handler. The token is never logged.
+type BearerTokenHandler struct {
+	handler http.Handler
+	token   string
+}
+
+// ServeHTTP implements the http.Handler interface with token checking.
+func (h *BearerTokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || !secretsEqual(got, h.token) {
+		slog.Warn("bearer token rejected",
+			"ip", ratelimit.GetIP(r),
+			"path", r.URL.Path,
+		)
+		w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	h.handler.ServeHTTP(w, r)
+}
+
+// requireBearerToken wraps handler with token authentication when a token
+// is configured, and returns it unchanged otherwise.
+func requireBearerToken(handler http.Handler, token string) http.Handler {
+	if token == "" {
+		return handler
+	}
+	return &BearerTokenHandler{handler: handler, token: token}
+}
+
+// -----------------------------------------------------------------------
+// Rate Limiters
+// -----------------------------------------------------------------------
+
+// Limiters holds the per-IP rate limiters for each endpoint category.
+//
+// Defaults reflect expected traffic: health endpoints are permissive
+// (100 req/sec, burst 200) because load balancers and monitoring tools poll
+// them; the dashboard and API are moderate (10 req/sec, burst 20) since the
+// dashboard polls at most every 2s by default; /metrics is tight (2 req/sec,
+// burst 10) since Prometheus scrapes every 15-30s, with burst for multiple
+// instances.
+//
+// The restart and logs limiters are fixed and deliberately strict: each
+// restart bounces the monitored service (one per minute per client),
+// each logs request spawns journalctl, profiles are expensive to take, and
+// each fresh check queries D-Bus for every service.
+type Limiters struct {
+	Health    *ratelimit.Manager
+	Dashboard *ratelimit.Manager
+	Metrics   *ratelimit.Manager
+	Restart   *ratelimit.Manager
+	Logs      *ratelimit.Manager
+	Pprof     *ratelimit.Manager
+	Fresh     *ratelimit.Manager
+}
+
+// Fixed limits for the endpoints that do work per request instead of
+// reading the cache: /admin/restart, /api/logs (spawns journalctl),
+// /debug/pprof/ and ?fresh=1 checks.
+const (
+	restartRate  = 1.0 / 60
+	restartBurst = 1
+	logsRate     = 1
+	logsBurst    = 3
+	pprofRate    = 1
+	pprofBurst   = 2
+	freshRate    = 1.0 / 10
+	freshBurst   = 1
+)
+
+// NewLimiters creates the rate limiters from configuration. When a global
+// rate is configured, each endpoint category also gets its own global
+// bucket shared by all client IPs.
+func NewLimiters(cfg *config.Config) *Limiters {
+	options := func(endpoint string) ratelimit.Options {
+		return ratelimit.Options{
+			Endpoint:         endpoint,
+			GlobalRate:       cfg.GlobalRate,
+			GlobalBurst:      cfg.GlobalBurst,
+			CleanupInterval:  time.Duration(cfg.RateLimitCleanupInterval) * time.Second,
+			CleanupIdleAfter: time.Duration(cfg.RateLimitIdleTimeout) * time.Second,
+			MaxTrackedIPs:    cfg.RateLimitMaxTrackedIPs,
+		}
+	}
+
+	return &Limiters{
+		Health:    ratelimit.NewWithOptions(cfg.HealthRate, cfg.HealthBurst, options("health")),
+		Dashboard: ratelimit.NewWithOptions(cfg.DashboardRate, cfg.DashboardBurst, options("dashboard")),
+		Metrics:   ratelimit.NewWithOptions(cfg.MetricsRate, cfg.MetricsBurst, options("metrics")),
+		Restart:   ratelimit.NewWithOptions(restartRate, restartBurst, options("restart")),
+		Logs:      ratelimit.NewWithOptions(logsRate, logsBurst, options("logs")),
+		Pprof:     ratelimit.NewWithOptions(pprofRate, pprofBurst, options("pprof")),
+		Fresh:     ratelimit.NewWithOptions(freshRate, freshBurst, options("fresh")),
+	}
+}
+
+// ByEndpoint returns the limiters keyed by endpoint category, for
+// reporting stats.
+func (l *Limiters) ByEndpoint() map[string]*ratelimit.Manager {
+	return map[string]*ratelimit.Manager{
+		"health":    l.Health,
+		"dashboard": l.Dashboard,
+		"metrics":   l.Metrics,
+		"restart":   l.Restart,
+		"logs":      l.Logs,
+		"pprof":     l.Pprof,
+		"fresh":     l.Fresh,
+	}
+}
+
+// Apply updates every limiter to the rates in cfg. Used on config reload.
+func (l *Limiters) Apply(cfg *config.Config) {
+	l.Health.SetLimits(cfg.HealthRate, cfg.HealthBurst)
+	l.Dashboard.SetLimits(cfg.DashboardRate, cfg.DashboardBurst)
+	l.Metrics.SetLimits(cfg.MetricsRate, cfg.MetricsBurst)
+}
+
 // -----------------------------------------------------------------------
 // HTTP Server Setup
 // -----------------------------------------------------------------------
 
 // SetupHTTPServer initializes the HTTP server with routes for the dashboard,
-// health check endpoint, status API, and Prometheus metrics. Rate limiting
-// is applied per endpoint with appropriate limits. TLS settings are applied
-// based on configuration. The server is not started; this function only
-// performs configuration and returns the server instance for later startup.
-func SetupHTTPServer(cfg *config.Config, serviceCache *cache.ServiceCache, dashboardHTML []byte) *http.Server {
-	// Create rate limiters for different endpoint categories
-
-	// Health endpoint is critical for monitoring - very permissive
-	// 100 req/sec, burst 200 = thousands per minute
-	// Prometheus, load balancers, multiple monitoring tools won't hit this
-	healthLimiter := ratelimit.New(100, 200)
-
-	// Dashboard and API - moderate for human/UI usage
-	// 10 req/sec, burst 20
-	// Dashboard polls every 2s = 0.5 req/sec, humans max out at 2-5 req/sec
-	dashboardLimiter := ratelimit.New(10, 20)
-
-	// Metrics endpoint - Prometheus-specific
-	// 2 req/sec, burst 10
-	// Prometheus typically scrapes once every 15-30 seconds = 0.033 req/sec
-	// Burst handles multiple Prometheus instances
-	metricsLimiter := ratelimit.New(2, 10)
-
+// aggregate and per-service health endpoints, liveness and readiness probes,
+// status API, and Prometheus metrics. Rate limiting is applied per endpoint
+// category using the provided limiters. TLS settings are applied based on
+// configuration. The server is not started; this function only performs
+// configuration and returns the server instance for later startup.
+func SetupHTTPServer(
+	cfg *config.Config,
+	caches *cache.Registry,
+	checkers *Checkers,
+	limiters *Limiters,
+	dashboardHTML []byte,
+) *http.Server {
 	// Create mux for explicit handler registration
 	mux := http.NewServeMux()
 
-	// Dashboard route serves the embedded React frontend
-	mux.Handle("/", &RateLimitedHandler{
+	// Validated at load time; known scrapers and probes skip rate limiting
+	bypass, err := ratelimit.ParseAllowlist(cfg.RateLimitBypassCIDRs)
+	if err != nil {
+		loga.Error("invalid rate limit bypass CIDRs", "err", err)
+		os.Exit(1)
+	}
+
+	// Forwarding headers are only honored from trusted proxies, if any
+	trustedProxies, err := ratelimit.ParseAllowlist(cfg.TrustedProxies)
+	if err != nil {
+		loga.Error("invalid trusted proxy CIDRs", "err", err)
+		os.Exit(1)
+	}
+	ratelimit.ConfigureTrustedProxies(trustedProxies)
+
+	// Load balancers probing every second would otherwise flood the logs
+	handlers.ConfigureAccessLog(cfg.AccessLog, cfg.AccessLogSample)
+	// Validate has already rejected an unparseable policy
+	policy, _ := cache.ParseAggregatePolicy(cfg.AggregatePolicy)
+	handlers.ConfigureAggregatePolicy(policy)
+	handlers.ConfigurePollInterval(cfg.DashboardPollSeconds())
+	handlers.ConfigureStaleStatusCode(cfg.StaleStatusCode)
+	handlers.ConfigureHealthBodies(cfg.HealthBodyOK, cfg.HealthBodyFail)
+	handlers.ConfigureExemplars(cfg.MetricsExemplars)
+	handlers.ConfigureRequestIDHeaders(cfg.RequestIDHeaders)
+
+	// Dashboard route serves the React frontend, from dashboard_dir when
+	// configured and the embedded copy otherwise
+	// Rate limiting wraps auth so credential guessing is throttled too
+	// Disabled endpoints answer 404 rather than falling through to "/"
+	if cfg.EnableDashboard {
+		mux.Handle("/", &RateLimitedHandler{
+			handler: requireBasicAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handlers.DashboardHandler(w, r, cfg.DashboardDir, dashboardHTML)
+			}), cfg.DashboardAuthUser, cfg.DashboardAuthPass, "dashboard"),
+			limiter:  limiters.Dashboard,
+			endpoint: "dashboard",
+			bypass:   bypass,
+		})
+	}
+
+	// Health endpoint returns aggregate status with appropriate HTTP status code
+	mux.Handle("/health", &RateLimitedHandler{
+		handler: checkFreshOnRequest(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if draining.Load() {
+				handlers.DrainingHealthHandler(w, r)
+				return
+			}
+			handlers.AggregateHealthHandler(w, r, caches.Snapshot())
+		}), checkers, limiters.Fresh, cfg.APIToken, "health_fresh"),
+		limiter:  limiters.Health,
+		endpoint: "health",
+		bypass:   bypass,
+	})
+
+	// Per-service health endpoint for wiring individual load balancer probes
+	mux.Handle("/health/", &RateLimitedHandler{
 		handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Content-Type", "text/html; charset=utf-8")
-			if _, err := w.Write(dashboardHTML); err != nil {
-				slog.Error("error writing dashboard", "err", err)
+			if draining.Load() {
+				handlers.DrainingHealthHandler(w, r)
+				return
 			}
+			handlers.ServiceHealthHandler(w, r, caches.Snapshot())
 		}),
-		limiter:  dashboardLimiter,
-		endpoint: "dashboard",
+		limiter:  limiters.Health,
+		endpoint: "health_service",
+		bypass:   bypass,
 	})
 
-	// Health endpoint returns service status with appropriate HTTP status code
-	mux.Handle("/health", &RateLimitedHandler{
+	// Liveness probe fails only when the checker goroutine is wedged
+	mux.Handle("/livez", &RateLimitedHandler{
 		handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			handlers.HealthHandler(w, r, serviceCache)
+			handlers.LivenessHandler(w, r, checkers, checkers.MaxAge())
 		}),
-		limiter:  healthLimiter,
-		endpoint: "health",
+		limiter:  limiters.Health,
+		endpoint: "livez",
+		bypass:   bypass,
 	})
 
-	// Status API returns detailed health information as JSON
-	mux.Handle("/api/status", &RateLimitedHandler{
+	// Readiness probe fails when a monitored service is down, like /health
+	mux.Handle("/readyz", &RateLimitedHandler{
 		handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			handlers.StatusAPIHandler(w, r, serviceCache, cfg.Service)
+			if draining.Load() {
+				handlers.DrainingHealthHandler(w, r)
+				return
+			}
+			handlers.AggregateHealthHandler(w, r, caches.Snapshot())
 		}),
-		limiter:  dashboardLimiter,
-		endpoint: "api_status",
+		limiter:  limiters.Health,
+		endpoint: "readyz",
+		bypass:   bypass,
 	})
 
-	// Metrics endpoint exports Prometheus-formatted metrics
-	mux.Handle("/metrics", &RateLimitedHandler{
-		handler:  promhttp.Handler(),
-		limiter:  metricsLimiter,
-		endpoint: "metrics",
+	// Status, history and logs APIs
+	if cfg.EnableAPI {
+		// Status API returns detailed health information as JSON
+		mux.Handle("/api/status", &RateLimitedHandler{
+			handler: requireBearerToken(checkFreshOnRequest(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				service, serviceCache := caches.Primary()
+				handlers.StatusAPIHandler(w, r, serviceCache, service, caches.Snapshot())
+			}), checkers, limiters.Fresh, cfg.APIToken, "api_status_fresh"), cfg.APIToken),
+			limiter:  limiters.Dashboard,
+			endpoint: "api_status",
+			bypass:   bypass,
+		})
+
+		// History API returns recent state transitions as JSON
+		mux.Handle("/api/history", &RateLimitedHandler{
+			handler: requireBearerToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				service, serviceCache := caches.Primary()
+				handlers.HistoryAPIHandler(w, r, serviceCache, service)
+			}), cfg.APIToken),
+			limiter:  limiters.Dashboard,
+			endpoint: "api_history",
+			bypass:   bypass,
+		})
+
+		// Logs API returns recent journal entries for a monitored service
+		mux.Handle("/api/logs", &RateLimitedHandler{
+			handler: requireBearerToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handlers.LogsAPIHandler(w, r, checkers.Config().SystemdServices(), cfg.LogLines,
+					func(ctx context.Context, service string, lines int) ([]journal.Entry, error) {
+						return journal.Read(ctx, journal.Query{
+							Unit:  checker.UnitName(service),
+							Lines: lines,
+							User:  cfg.DBusScope == checker.DBusScopeSession,
+						})
+					})
+			}), cfg.APIToken),
+			limiter:  limiters.Logs,
+			endpoint: "api_logs",
+			bypass:   bypass,
+		})
+	} else {
+		for _, pattern := range []string{"/api/status", "/api/history", "/api/logs"} {
+			mux.Handle(pattern, http.NotFoundHandler())
+		}
+	}
+
+	// Admin endpoints toggle drain mode, protected by the API token if set
+	mux.Handle("/admin/drain", &RateLimitedHandler{
+		handler: requireBearerToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlers.DrainHandler(w, r, &draining, true)
+		}), cfg.APIToken),
+		limiter:  limiters.Dashboard,
+		endpoint: "admin_drain",
+		bypass:   bypass,
+	})
+
+	mux.Handle("/admin/undrain", &RateLimitedHandler{
+		handler: requireBearerToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlers.DrainHandler(w, r, &draining, false)
+		}), cfg.APIToken),
+		limiter:  limiters.Dashboard,
+		endpoint: "admin_undrain",
+		bypass:   bypass,
+	})
+
+	// Resetting uptime counters, e.g. after planned maintenance
+	mux.Handle("/admin/reset-stats", &RateLimitedHandler{
+		handler: requireBearerToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			primary, _ := caches.Primary()
+			handlers.ResetStatsHandler(w, r, primary, caches.Snapshot())
+		}), cfg.APIToken),
+		limiter:  limiters.Dashboard,
+		endpoint: "admin_reset_stats",
+		bypass:   bypass,
+	})
+
+	// Rate limiter stats for diagnosing throttled clients
+	mux.Handle("/admin/ratelimit", &RateLimitedHandler{
+		handler: requireBearerToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlers.RateLimitStatsHandler(w, r, limiters.ByEndpoint())
+		}), cfg.APIToken),
+		limiter:  limiters.Dashboard,
+		endpoint: "admin_ratelimit",
+		bypass:   bypass,
+	})
+
+	// Restarting the monitored unit is opt-in; it requires the API token
+	// (enforced by config validation) and has its own strict limiter
+	if cfg.AllowRestart {
+		mux.Handle("/admin/restart", &RateLimitedHandler{
+			handler: requireBearerToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handlers.RestartHandler(w, r, checkers.Config().SystemdServices(), func(ctx context.Context, service string) (string, error) {
+					return checker.RestartService(ctx, cfg.DBusScope, service)
+				})
+			}), cfg.APIToken),
+			limiter:  limiters.Restart,
+			endpoint: "admin_restart",
+		})
+		loga.Warn("service restart endpoint enabled", "endpoint", "/admin/restart")
+	}
+
+	// Runtime profiling is opt-in with the same protections as restart
+	registerPprof(mux, cfg, limiters)
+
+	// Version endpoint reports build metadata of the running binary
+	mux.Handle("/version", &RateLimitedHandler{
+		handler:  http.HandlerFunc(handlers.VersionHandler),
+		limiter:  limiters.Dashboard,
+		endpoint: "version",
+		bypass:   bypass,
 	})
 
+	// Metrics endpoint exports Prometheus-formatted metrics
+	if cfg.EnableMetrics {
+		mux.Handle("/metrics", &RateLimitedHandler{
+			handler:  requireBasicAuth(metricsHandler(cfg), cfg.MetricsAuthUser, cfg.MetricsAuthPass, "metrics"),
+			limiter:  limiters.Metrics,
+			endpoint: "metrics",
+			bypass:   bypass,
+		})
+	} else {
+		mux.Handle("/metrics", http.NotFoundHandler())
+	}
+
 	// Log rate limiting configuration
 	loga.Info("rate limiting configured",
-		"health_limit", "100 req/sec, burst 200",
-		"dashboard_limit", "10 req/sec, burst 20",
-		"metrics_limit", "2 req/sec, burst 10",
+		"health_limit", fmt.Sprintf("%g req/sec, burst %d", cfg.HealthRate, cfg.HealthBurst),
+		"dashboard_limit", fmt.Sprintf("%g req/sec, burst %d", cfg.DashboardRate, cfg.DashboardBurst),
+		"metrics_limit", fmt.Sprintf("%g req/sec, burst %d", cfg.MetricsRate, cfg.MetricsBurst),
+		"global_limit", fmt.Sprintf("%g req/sec, burst %d", cfg.GlobalRate, cfg.GlobalBurst),
+		"bypass_cidrs", cfg.RateLimitBypassCIDRs,
+		"trusted_proxies", cfg.TrustedProxies,
+	)
+	loga.Info("optional endpoints configured",
+		"dashboard", cfg.EnableDashboard,
+		"api", cfg.EnableAPI,
+		"metrics", cfg.EnableMetrics,
+	)
+	loga.Info("authentication configured",
+		"metrics_basic_auth", cfg.MetricsAuthUser != "",
+		"dashboard_basic_auth", cfg.DashboardAuthUser != "",
+		"api_token", cfg.APIToken != "",
 	)
 
+	// Slow handlers are answered with 503 before the server cuts them off
+	requestTimeout := time.Duration(cfg.RequestTimeout) * time.Second
+
 	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%d", cfg.Port),
-		Handler:      mux,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  120 * time.Second,
+		Addr:         cfg.ListenAddress(cfg.Port),
+		Handler:      compressResponses(limitRequestDuration(mux, requestTimeout)),
+		ReadTimeout:  secondsOr(cfg.ReadTimeout, defaultReadTimeout),
+		WriteTimeout: secondsOr(cfg.WriteTimeout, defaultWriteTimeout),
+		IdleTimeout:  secondsOr(cfg.IdleTimeout, defaultIdleTimeout),
 	}
 
 	// Apply TLS configuration if enabled
@@ -245,14 +795,17 @@ func SetupHTTPServer(cfg *config.Config, serviceCache *cache.ServiceCache, dashb
 // configureTLS sets up TLS configuration for the server based on the provided
 // configuration. Three modes are supported: Let's Encrypt ACME with autocert,
 // manual certificate files, and plain HTTP (no TLS). In autocert mode, a
-// background goroutine is started to handle ACME challenges on port 80.
+// background goroutine is started to handle ACME challenges on port 80. In
+// manual mode, the certificate files are watched and reloaded when renewed.
+// Both modes publish the served certificate's expiry as a metric.
 func configureTLS(srv *http.Server, cfg *config.Config) {
 	if cfg.TLSAutocert {
 		// Let's Encrypt ACME mode with automatic certificate renewal
+		certCache := autocert.DirCache(cfg.TLSAutocertCache)
 		certManager := &autocert.Manager{
 			Prompt:     autocert.AcceptTOS,
 			HostPolicy: autocert.HostWhitelist(cfg.TLSAutocertDomain),
-			Cache:      autocert.DirCache(cfg.TLSAutocertCache),
+			Cache:      certCache,
 			Email:      cfg.TLSAutocertEmail,
 		}
 
@@ -262,19 +815,29 @@ func configureTLS(srv *http.Server, cfg *config.Config) {
 		}
 
 		loga.Info("Let's Encrypt autocert enabled", "domain", cfg.TLSAutocertDomain)
+		go watchCertExpiry(autocertLeaf(certCache, cfg.TLSAutocertDomain))
 
 		// Start HTTP server on port 80 to handle ACME challenges (required by Let's Encrypt)
 		go func() {
-			loga.Info("starting HTTP server for ACME challenges", "addr", ":80")
-			if err := http.ListenAndServe(":80", certManager.HTTPHandler(nil)); err != nil {
+			addr := cfg.ListenAddress(80)
+			loga.Info("starting HTTP server for ACME challenges", "addr", addr)
+			if err := http.ListenAndServe(addr, certManager.HTTPHandler(nil)); err != nil {
 				loga.Error("ACME challenge server error", "err", err)
 			}
 		}()
 
 	} else if cfg.TLSEnabled {
-		// Manual TLS mode using provided certificate and key files
+		// Manual TLS mode using provided certificate and key files, served
+		// through a reloader so renewed certificates apply without restart
+		reloader, err := newCertReloader(cfg.TLSCertFile, cfg.TLSKeyFile, cfg.CertExpiryPolicy())
+		if err != nil {
+			loga.Error("failed to load TLS certificate", "err", err)
+			os.Exit(1)
+		}
+
 		srv.TLSConfig = &tls.Config{
-			MinVersion: tls.VersionTLS12,
+			GetCertificate: reloader.GetCertificate,
+			MinVersion:     tls.VersionTLS12,
 			CipherSuites: []uint16{
 				tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
 				tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
@@ -289,89 +852,464 @@ func configureTLS(srv *http.Server, cfg *config.Config) {
 // Background Checker Setup
 // -----------------------------------------------------------------------
 
-// StartBackgroundChecker launches the background service monitoring goroutine
-// and the checker health watchdog. It returns a context cancellation function
-// for clean shutdown and a CheckerHealth handle for health monitoring. The
-// background checker runs at the configured interval and updates the shared
-// service cache with results.
+// Checkers supervises the per-service checker goroutines and the watchdog.
+// Individual checkers can be started and stopped at runtime so a config
+// reload can change the monitored set or check interval without a restart.
+type Checkers struct {
+	mu      sync.Mutex
+	ctx     context.Context
+	cancel  context.CancelFunc
+	cfg     *config.Config
+	caches  *cache.Registry
+	health  map[string]*checker.CheckerHealth
+	running map[string]context.CancelFunc
+	failed  chan error
+
+	// batch serves every systemd service when batch_checks is enabled
+	batch *checker.Batch
+
+	// eventLog receives transitions when event_log is configured
+	eventLog *notify.EventLog
+}
+
+// StartBackgroundChecker launches one background monitoring goroutine per
+// registered service and the checker health watchdog. The returned Checkers
+// handle is used to stop all checkers on shutdown, apply reloaded
+// configuration, and expose checker health to the liveness probe.
+//
+// The initial connection is used by the first systemd service. Other
+// systemd services get their own connection because the reconnection logic
+// closes the connection it owns on failure; TCP and HTTP targets need none.
+// With batch_checks, all systemd services share the initial connection
+// through one checker.Batch.
 func StartBackgroundChecker(
 	conn *dbus.Conn,
 	cfg *config.Config,
-	serviceCache *cache.ServiceCache,
-) (context.CancelFunc, *checker.CheckerHealth) {
+	caches *cache.Registry,
+) *Checkers {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	checkerHealth := checker.NewCheckerHealth()
-	interval := time.Duration(cfg.Interval) * time.Second
+	checker.ConfigureStateCodes(cfg.StateCodes)
+	checker.ConfigureFlapDetection(cfg.FlapThreshold, time.Duration(cfg.FlapWindow)*time.Second)
+	checker.ConfigureDependencyChecks(cfg.CheckDependencies)
+	checker.ConfigureActivatingGrace(time.Duration(cfg.ActivatingGrace) * time.Second)
+	checker.ConfigureMaxConcurrentChecks(cfg.MaxConcurrentChecks)
+	checker.ConfigureErrorLogSummary(time.Duration(cfg.LogSummaryInterval) * time.Second)
+	checker.ConfigureProactiveReconnect(time.Duration(cfg.DBusReconnectInterval) * time.Second)
+	if len(cfg.StateCodes) > 0 {
+		loga.Info("systemd state code overrides applied", "state_codes", cfg.StateCodes)
+	}
+
+	eventLog := configureNotifiers(ctx, cfg)
+
+	c := &Checkers{
+		ctx:      ctx,
+		cancel:   cancel,
+		cfg:      cfg,
+		caches:   caches,
+		health:   make(map[string]*checker.CheckerHealth),
+		running:  make(map[string]context.CancelFunc),
+		failed:   make(chan error, 1),
+		eventLog: eventLog,
+	}
+
+	checker.ConfigureReconnectLimit(cfg.MaxReconnectAttempts, func(service string) {
+		c.fail(fmt.Errorf("checker for %s: %w", service, checker.ErrReconnectLimit))
+	})
+
+	if cfg.BatchChecks {
+		c.batch = checker.NewBatch(cfg.DBusScope, conn, batchMaxAge(cfg))
+		conn = nil
+		loga.Info("batched D-Bus checks enabled")
+	}
 
-	go checker.StartServiceChecker(ctx, conn, cfg.Service, serviceCache, interval, checkerHealth)
+	c.mu.Lock()
+	targets := cfg.MonitoredTargets()
+	for _, service := range caches.Names() {
+		if conn != nil && targets[service].Kind == checker.TargetDBus {
+			c.start(service, conn)
+			conn = nil
+		} else {
+			c.start(service, nil)
+		}
+	}
+	c.mu.Unlock()
 
 	// Start watchdog goroutine to monitor checker responsiveness
-	go startCheckerWatchdog(ctx, cfg, serviceCache, checkerHealth)
+	go startCheckerWatchdog(ctx, c)
 
-	return cancel, checkerHealth
+	return c
 }
 
-// startCheckerWatchdog periodically checks whether the background checker
-// goroutine is responding and updating health information. If the checker
+// configureNotifiers starts the configured state change notifiers and
+// installs them on the checker, returning the event log if one was opened.
+// URLs are not logged since webhook URLs commonly embed secrets. An event
+// log that cannot be opened is logged and skipped.
+func configureNotifiers(ctx context.Context, cfg *config.Config) *notify.EventLog {
+	var notifiers notify.Multi
+
+	if cfg.WebhookURL != "" {
+		webhook := notify.NewWebhook(cfg.WebhookURL)
+		webhook.Start(ctx)
+		notifiers = append(notifiers, webhook)
+		loga.Info("state change webhook enabled")
+	}
+
+	if cfg.SlackWebhookURL != "" {
+		slack := notify.NewSlack(cfg.SlackWebhookURL)
+		slack.Start(ctx)
+		notifiers = append(notifiers, slack)
+		loga.Info("Slack alerting enabled")
+	}
+
+	var eventLog *notify.EventLog
+	if cfg.EventLog != "" {
+		var err error
+		if eventLog, err = notify.NewEventLog(cfg.EventLog); err != nil {
+			loga.Error("failed to open event log; continuing without it", "err", err)
+		} else {
+			notifiers = append(notifiers, eventLog)
+			loga.Info("event log enabled", "path", cfg.EventLog)
+		}
+	}
+
+	if len(notifiers) > 0 {
+		checker.ConfigureNotifier(notifiers)
+	}
+	return eventLog
+}
+
+// Stop cancels every checker goroutine and the watchdog.
+func (c *Checkers) Stop() {
+	c.cancel()
+	if c.batch != nil {
+		c.batch.Close()
+	}
+	if c.eventLog != nil {
+		_ = c.eventLog.Close()
+	}
+}
+
+// ReopenEventLog reopens the event log after rotation. It is a no-op when
+// no event log is configured. Called on SIGHUP.
+func (c *Checkers) ReopenEventLog() {
+	if c.eventLog == nil {
+		return
+	}
+	if err := c.eventLog.Reopen(); err != nil {
+		loga.Error("event log reopen failed; still writing to the old file", "err", err)
+	}
+}
+
+// Failed returns a channel that receives an error when a checker has given
+// up and the process should exit.
+func (c *Checkers) Failed() <-chan error {
+	return c.failed
+}
+
+// fail reports a fatal checker error. Only the first error is kept.
+func (c *Checkers) fail(err error) {
+	select {
+	case c.failed <- err:
+	default:
+	}
+}
+
+// ServiceHealth reports, for each running checker, whether it has
+// completed a check within maxAge.
+func (c *Checkers) ServiceHealth(maxAge time.Duration) map[string]bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	healthy := make(map[string]bool, len(c.health))
+	for service, health := range c.health {
+		healthy[service] = health.IsHealthy(maxAge)
+	}
+	return healthy
+}
+
+// IsHealthy reports whether every running checker has completed a check
+// within maxAge. This is the process-level rollup served by /livez.
+func (c *Checkers) IsHealthy(maxAge time.Duration) bool {
+	for _, healthy := range c.ServiceHealth(maxAge) {
+		if !healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckNow checks every monitored service once, outside the checkers'
+// schedules, and records the results in their caches.
+func (c *Checkers) CheckNow(ctx context.Context) error {
+	cfg := c.Config()
+	return checker.CheckNow(ctx, cfg.MonitoredTargets(), c.caches.Snapshot(),
+		cfg.DBusScope, time.Duration(cfg.DBusTimeout)*time.Second)
+}
+
+// Config returns the configuration the checkers are currently running with.
+func (c *Checkers) Config() *config.Config {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cfg
+}
+
+// MaxAge returns the liveness threshold for the current check interval.
+func (c *Checkers) MaxAge() time.Duration {
+	return checkerMaxAge(c.Config())
+}
+
+// Apply reconciles running checkers with a reloaded configuration. Checkers
+// for removed services are stopped, new services get a checker, a service
+// whose target changed is restarted, and an schedule change (interval,
+// jitter, adaptive backoff, D-Bus timeout) restarts every checker so the new
+// schedule takes effect.
+// The caller is responsible for only passing live-reloadable changes.
+func (c *Checkers) Apply(next *config.Config) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	scheduleChanged := checkSchedule(next) != checkSchedule(c.cfg)
+	prevTargets := c.cfg.MonitoredTargets()
+	c.cfg = next
+
+	added, removed := c.caches.Sync(next.MonitoredServices())
+
+	for _, service := range removed {
+		c.stop(service)
+		metrics.ServiceStatus.DeletePartialMatch(prometheus.Labels{"service": service})
+		metrics.CheckEffectiveInterval.DeleteLabelValues(service)
+		metrics.ServiceRestarts.DeleteLabelValues(service)
+		metrics.ServiceActiveSince.DeleteLabelValues(service)
+		metrics.ServiceDowntime.DeleteLabelValues(service)
+		metrics.ServiceRecoveries.DeleteLabelValues(service)
+		metrics.ServiceFlapping.DeleteLabelValues(service)
+		metrics.CacheStaleness.DeleteLabelValues(service)
+		metrics.CheckerServiceHealthy.DeleteLabelValues(service)
+		loga.Info("stopped checker for removed service", "service", service)
+	}
+
+	if scheduleChanged {
+		if c.batch != nil {
+			c.batch.SetMaxAge(batchMaxAge(next))
+		}
+		for _, service := range c.caches.Names() {
+			if _, ok := c.running[service]; ok {
+				c.stop(service)
+				c.start(service, nil)
+			}
+		}
+		loga.Info("restarted checkers with new schedule", "interval_sec", next.Interval)
+	} else {
+		for service, target := range next.MonitoredTargets() {
+			prev, ok := prevTargets[service]
+			if _, running := c.running[service]; ok && running && prev != target {
+				c.stop(service)
+				c.start(service, nil)
+				loga.Info("restarted checker for changed target", "service", service, "kind", target.Kind)
+			}
+		}
+	}
+
+	for _, service := range added {
+		c.start(service, nil)
+		loga.Info("started checker for added service", "service", service)
+	}
+}
+
+// start launches the checker goroutine for service. For a systemd service
+// with a nil conn a new connection is opened; on failure the checker starts
+// disconnected and retries with backoff. Callers must hold c.mu.
+func (c *Checkers) start(service string, conn *dbus.Conn) {
+	serviceCache, ok := c.caches.Get(service)
+	if !ok {
+		return
+	}
+
+	target, ok := c.cfg.MonitoredTargets()[service]
+	if !ok {
+		return
+	}
+
+	// A fresh tracker gives a restarted checker a full window to report
+	health := checker.NewCheckerHealth()
+	c.health[service] = health
+
+	if c.batch != nil && target.Kind == checker.TargetDBus {
+		ctx, cancel := context.WithCancel(c.ctx)
+		c.running[service] = cancel
+
+		go checker.StartServiceChecker(ctx, checker.NewBatchProber(c.batch, service), service, serviceCache,
+			checkSchedule(c.cfg), health)
+		return
+	}
+
+	if conn == nil && target.Kind == checker.TargetDBus {
+		var err error
+		conn, err = checker.Connect(c.ctx, c.cfg.DBusScope)
+		if err != nil {
+			// The checker reconnects with backoff when given a nil connection
+			loga.Warn("failed to open D-Bus connection for service; checker will retry",
+				"service", service, "err", err)
+			conn = nil
+		}
+	}
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	c.running[service] = cancel
+
+	schedule := checkSchedule(c.cfg)
+	prober := checker.NewProber(target, c.cfg.DBusScope, conn, schedule.Timeout)
+	go checker.StartServiceChecker(ctx, prober, service, serviceCache, schedule, health)
+}
+
+// checkSchedule builds the checker schedule from configuration.
+func checkSchedule(cfg *config.Config) checker.Schedule {
+	schedule := checker.Schedule{
+		Interval:      time.Duration(cfg.Interval) * time.Second,
+		JitterPercent: cfg.IntervalJitterPercent,
+		Timeout:       time.Duration(cfg.DBusTimeout) * time.Second,
+	}
+	if cfg.AdaptiveInterval {
+		schedule.AdaptiveMax = time.Duration(cfg.AdaptiveIntervalMax) * time.Second
+		schedule.AdaptiveThreshold = cfg.AdaptiveIntervalThreshold
+	}
+	return schedule
+}
+
+// batchMaxAge is how long a batched D-Bus result is shared: half the
+// check interval, so checkers spread by jitter still mostly share a call.
+func batchMaxAge(cfg *config.Config) time.Duration {
+	return time.Duration(cfg.Interval) * time.Second / 2
+}
+
+// stop cancels the checker goroutine for service. Callers must hold c.mu.
+func (c *Checkers) stop(service string) {
+	if cancel, ok := c.running[service]; ok {
+		cancel()
+		delete(c.running, service)
+	}
+	delete(c.health, service)
+}
+
+// startCheckerWatchdog periodically checks whether each service's checker
+// goroutine is responding and updating health information. If a checker
 // fails to update within the expected time window, the watchdog logs an
-// alert, sets the checker health metric to 0, and continues monitoring for
-// recovery. The watchdog checks every 10 seconds and considers the checker
-// unhealthy if its last update exceeds 2x the configured check interval.
+// alert, sets its health metric to 0, and continues monitoring for
+// recovery. The watchdog runs every watchdog_interval_seconds (default 10)
+// and considers a checker unhealthy if its last update exceeds
+// watchdog_multiplier (default 2) times the configured check interval.
 //
 // Metrics Updated:
-//   - health_checker_healthy: Set to 1 when checker is responsive, 0 when stuck
-//   - health_checker_last_check_timestamp_seconds: Updated with current cache timestamp
-func startCheckerWatchdog(
-	ctx context.Context,
-	cfg *config.Config,
-	serviceCache *cache.ServiceCache,
-	checkerHealth *checker.CheckerHealth,
-) {
-	// Watchdog check interval and health threshold
-	maxCheckerAge := time.Duration(cfg.Interval*2) * time.Second
+//   - health_checker_service_healthy: Per service, 1 when its checker is responsive, 0 when stuck
+//   - health_checker_healthy: 1 when every checker is responsive, 0 when any is stuck
+//   - health_checker_last_check_timestamp_seconds: Updated with the oldest cache timestamp
+//   - health_check_cache_staleness_seconds: Set per service from its cache age
+func startCheckerWatchdog(ctx context.Context, checkers *Checkers) {
+	tick := defaultWatchdogInterval
+	if cfg := checkers.Config(); cfg.WatchdogInterval > 0 {
+		tick = time.Duration(cfg.WatchdogInterval) * time.Second
+	}
 
-	ticker := time.NewTicker(10 * time.Second)
+	ticker := time.NewTicker(tick)
 	defer ticker.Stop()
 
-	var isHealthy bool
+	// Last observed health per service, for logging transitions
+	var wasHealthy map[string]bool
 
 	for {
 		select {
 		case <-ticker.C:
 			// Evaluate checker health by comparing last update timestamp
-			wasHealthy := isHealthy
-			isHealthy = checkerHealth.IsHealthy(maxCheckerAge)
-
-			// Update Prometheus metrics to reflect checker health
-			if isHealthy {
-				metrics.CheckerHealthy.Set(1)
-			} else {
-				metrics.CheckerHealthy.Set(0)
-			}
-
-			// Log state transitions for operational visibility
-			if isHealthy != wasHealthy {
-				if isHealthy {
-					loga.Info("checker watchdog: checker recovered")
-				} else {
+			// Threshold is re-read each tick so a reloaded interval applies
+			maxCheckerAge := checkers.MaxAge()
+			serviceHealth := checkers.ServiceHealth(maxCheckerAge)
+
+			allHealthy := true
+			for service, healthy := range serviceHealth {
+				metrics.CheckerServiceHealthy.WithLabelValues(service).Set(gaugeValue(healthy))
+				allHealthy = allHealthy && healthy
+
+				// Log state transitions for operational visibility; a new
+				// checker is only logged if it starts out stuck
+				was, seen := wasHealthy[service]
+				switch {
+				case healthy && seen && !was:
+					loga.Info("checker watchdog: checker recovered", "service", service)
+				case !healthy && (!seen || was):
 					loga.Error("checker watchdog: checker is not responding",
-						"max_age", maxCheckerAge.String(),
-						"service", cfg.Service)
+						"service", service,
+						"max_age", maxCheckerAge.String())
 				}
 			}
-
-			// Update gauge with timestamp of the most recent health check
-			metrics.CheckerLastCheckTimestamp.Set(float64(serviceCache.GetLastChecked().Unix()))
+			wasHealthy = serviceHealth
+			metrics.CheckerHealthy.Set(gaugeValue(allHealthy))
+
+			// Report the oldest per-service check so one lagging checker is
+			// visible, and each service's staleness whether or not anything
+			// is polling /health
+			var oldest time.Time
+			first := true
+			for service, serviceCache := range checkers.caches.Snapshot() {
+				lastChecked := serviceCache.GetLastChecked()
+				if first || lastChecked.Before(oldest) {
+					oldest = lastChecked
+					first = false
+				}
+				// A service not checked yet has no meaningful age
+				if !lastChecked.IsZero() {
+					metrics.CacheStaleness.WithLabelValues(service).Set(serviceCache.GetStaleness().Seconds())
+				}
+			}
+			metrics.CheckerLastCheckTimestamp.Set(float64(oldest.Unix()))
 
 		case <-ctx.Done():
 			loga.Info("stopping checker watchdog")
 			metrics.CheckerHealthy.Set(0)
+			for service := range wasHealthy {
+				metrics.CheckerServiceHealthy.WithLabelValues(service).Set(0)
+			}
 			return
 		}
 	}
 }
 
+// metricsHandler returns the Prometheus handler for /metrics. With
+// exemplars enabled it also offers the OpenMetrics format, the only one
+// that carries them, to scrapers that ask for it.
+func metricsHandler(cfg *config.Config) http.Handler {
+	if !cfg.MetricsExemplars {
+		return promhttp.Handler()
+	}
+	return promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}))
+}
+
+// gaugeValue converts a boolean to a 1/0 gauge value.
+func gaugeValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// checkerMaxAge returns how long the checker may go without updating before
+// it is considered unresponsive. Shared by the watchdog and /livez so both
+// agree on what "wedged" means. With adaptive backoff a persistently down
+// service is checked only every adaptive_interval_max, so that is the
+// interval the multiplier applies to; otherwise /livez would fail, and get
+// the checker restarted, exactly while the service is down.
+func checkerMaxAge(cfg *config.Config) time.Duration {
+	multiplier := cfg.WatchdogMultiplier
+	if multiplier <= 0 {
+		multiplier = defaultWatchdogMultiplier
+	}
+	interval := cfg.Interval
+	if cfg.AdaptiveInterval && cfg.AdaptiveIntervalMax > interval {
+		interval = cfg.AdaptiveIntervalMax
+	}
+	return time.Duration(float64(interval) * multiplier * float64(time.Second))
+}
+
 // -----------------------------------------------------------------------
 // HTTP Server Start
 // -----------------------------------------------------------------------
@@ -399,19 +1337,20 @@ func StartHTTPServer(srv *http.Server, cfg *config.Config) {
 			loga.Info("monitoring (HTTPS with manual certs)",
 				"service", cfg.Service, "port", cfg.Port)
 			loga.Info("endpoints",
-				"dashboard", fmt.Sprintf("https://localhost:%d/", cfg.Port),
-				"health", fmt.Sprintf("https://localhost:%d/health", cfg.Port),
-				"api", fmt.Sprintf("https://localhost:%d/api/status", cfg.Port),
-				"metrics", fmt.Sprintf("https://localhost:%d/metrics", cfg.Port),
+				"dashboard", "https://"+endpointHost(cfg)+"/",
+				"health", "https://"+endpointHost(cfg)+"/health",
+				"api", "https://"+endpointHost(cfg)+"/api/status",
+				"metrics", "https://"+endpointHost(cfg)+"/metrics",
 			)
-			err = srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+			// Certificates come from TLSConfig.GetCertificate (hot-reloaded)
+			err = srv.ListenAndServeTLS("", "")
 		} else {
 			loga.Info("monitoring (HTTP)", "service", cfg.Service, "port", cfg.Port)
 			loga.Info("endpoints",
-				"dashboard", fmt.Sprintf("http://localhost:%d/", cfg.Port),
-				"health", fmt.Sprintf("http://localhost:%d/health", cfg.Port),
-				"api", fmt.Sprintf("http://localhost:%d/api/status", cfg.Port),
-				"metrics", fmt.Sprintf("http://localhost:%d/metrics", cfg.Port),
+				"dashboard", "http://"+endpointHost(cfg)+"/",
+				"health", "http://"+endpointHost(cfg)+"/health",
+				"api", "http://"+endpointHost(cfg)+"/api/status",
+				"metrics", "http://"+endpointHost(cfg)+"/metrics",
 			)
 			err = srv.ListenAndServe()
 		}
@@ -423,23 +1362,91 @@ func StartHTTPServer(srv *http.Server, cfg *config.Config) {
 	}()
 }
 
+// endpointHost returns the host:port to show in endpoint URLs: the listen
+// address, or localhost when listening on all interfaces.
+func endpointHost(cfg *config.Config) string {
+	host := cfg.ListenAddr
+	if host == "" || net.ParseIP(host).IsUnspecified() {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(cfg.Port))
+}
+
 // -----------------------------------------------------------------------
 // Graceful Shutdown
 // -----------------------------------------------------------------------
 
 // WaitForShutdown blocks until receiving a termination signal (SIGTERM or
-// SIGINT), then initiates graceful shutdown of the checker and HTTP server.
-// Shutdown follows a phased approach: the background checker is stopped first
-// (5s timeout), followed by the HTTP server (remaining time from 30s overall
-// budget). If shutdown exceeds the overall 30-second deadline, the server is
-// forcefully closed. This function logs all shutdown phases for operational
-// observability.
-func WaitForShutdown(srv *http.Server, cancelChecker context.CancelFunc) {
+// SIGINT) or an error on failed, then initiates graceful shutdown of the
+// checker and HTTP server. SIGHUP invokes reload and keeps waiting, so
+// configuration can be re-read without dropping cached status and history.
+// The error from failed is returned after shutdown so the caller can exit
+// non-zero; a signal-initiated shutdown returns nil.
+// Shutdown follows a phased approach: with a non-zero preShutdownDelay the
+// node first enters drain mode and keeps serving for the delay, so load
+// balancers see /health fail and stop routing to it (a second SIGTERM or
+// SIGINT skips the rest of the delay; SIGHUP only reopens the log file).
+// The background checker is then stopped (5s timeout), followed by the HTTP
+// server (remaining time from 30s overall budget). If shutdown exceeds the
+// overall 30-second deadline, the server is forcefully closed. This
+// function logs all shutdown phases for operational observability.
+func WaitForShutdown(
+	srv *http.Server,
+	cancelChecker context.CancelFunc,
+	reload func(),
+	failed <-chan error,
+	preShutdownDelay time.Duration,
+) error {
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+
+	var fatal error
+wait:
+	for {
+		select {
+		case sig := <-sigChan:
+			if sig != syscall.SIGHUP {
+				loga.Info("shutdown signal received; starting graceful shutdown")
+				break wait
+			}
+			loga.Info("SIGHUP received; reopening log file and reloading configuration")
+			if err := logging.Reopen(); err != nil {
+				loga.Error("log file reopen failed; still writing to the old file", "err", err)
+			}
+			reload()
+		case fatal = <-failed:
+			loga.Error("fatal checker error; starting graceful shutdown", "err", fatal)
+			break wait
+		}
+	}
 
-	<-sigChan
-	loga.Info("shutdown signal received; starting graceful shutdown")
+	// Phase 0: Drain so load balancers remove the node before it stops
+	// accepting connections
+	if preShutdownDelay > 0 {
+		draining.Store(true)
+		loga.Info("draining before shutdown", "delay", preShutdownDelay.String())
+		drained := time.After(preShutdownDelay)
+	drain:
+		for {
+			select {
+			case <-drained:
+				loga.Info("pre-shutdown delay elapsed")
+				break drain
+			case sig := <-sigChan:
+				// A SIGHUP such as logrotate's still gets its log file
+				// reopened; reloading is pointless while shutting down
+				if sig == syscall.SIGHUP {
+					loga.Info("SIGHUP received while draining; reopening log file")
+					if err := logging.Reopen(); err != nil {
+						loga.Error("log file reopen failed; still writing to the old file", "err", err)
+					}
+					continue
+				}
+				loga.Warn("second signal received; skipping the rest of the pre-shutdown delay")
+				break drain
+			}
+		}
+	}
 
 	// Overall shutdown context with timeout
 	shutdownTimeout := 30 * time.Second
@@ -507,4 +1514,6 @@ func WaitForShutdown(srv *http.Server, cancelChecker context.CancelFunc) {
 
 	elapsed := time.Since(shutdownStart)
 	loga.Info("graceful shutdown complete", "elapsed", elapsed.String())
+
+	return fatal
 }
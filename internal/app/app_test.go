@@ -0,0 +1,271 @@
+// -----------------------------------------------------------------------
+// Application Orchestration and Lifecycle - Tests
+// -----------------------------------------------------------------------
+//
+// Validates --check_config, whose output on stdout must parse as YAML with
+// no log lines interleaved, the checker liveness threshold, that disabled
+// endpoints answer 404, and Basic and bearer token authentication.
+//
+// -----------------------------------------------------------------------
+
+package app
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/afreidah/health-check-service/internal/cache"
+	"github.com/afreidah/health-check-service/internal/config"
+	"github.com/knadh/koanf/parsers/yaml"
+)
+
+// TestCheckConfigOutputParses verifies that with logging left at its
+// stdout default, --check_config still writes only YAML to stdout.
+func TestCheckConfigOutputParses(t *testing.T) {
+	t.Setenv("LOG_OUTPUT", "")
+
+	args := os.Args
+	os.Args = []string{"health-checker", "--service", "nginx", "--check_config"}
+	t.Cleanup(func() { os.Args = args })
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	t.Cleanup(func() { os.Stdout = stdout })
+
+	code := PrintConfig(MustLoadConfig())
+	os.Stdout = stdout
+	w.Close()
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("reading stdout: %v", err)
+	}
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+
+	got, err := yaml.Parser().Unmarshal(out)
+	if err != nil {
+		t.Fatalf("stdout is not valid YAML: %v\n%s", err, out)
+	}
+	if got["service"] != "nginx" {
+		t.Errorf("Expected service nginx, got %v\n%s", got["service"], out)
+	}
+}
+
+// TestCheckerMaxAge verifies the liveness threshold covers the longest
+// wait between checks, including adaptive backoff.
+func TestCheckerMaxAge(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Config
+		want time.Duration
+	}{
+		{"default multiplier", config.Config{Interval: 10}, 20 * time.Second},
+		{"configured multiplier", config.Config{Interval: 10, WatchdogMultiplier: 3}, 30 * time.Second},
+		{"adaptive backoff", config.Config{Interval: 10, AdaptiveInterval: true, AdaptiveIntervalMax: 300}, 600 * time.Second},
+		{"adaptive max unset", config.Config{Interval: 10, AdaptiveInterval: true}, 20 * time.Second},
+		{"adaptive disabled", config.Config{Interval: 10, AdaptiveIntervalMax: 300}, 20 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkerMaxAge(&tt.cfg); got != tt.want {
+				t.Errorf("checkerMaxAge = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// serve sends a GET for path to the server SetupHTTPServer builds from cfg.
+func serve(cfg *config.Config, path string) *httptest.ResponseRecorder {
+	srv := SetupHTTPServer(cfg, cache.NewRegistry([]string{cfg.Service}, cfg.HistorySize),
+		nil, NewLimiters(cfg), []byte("<html></html>"))
+	w := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+// TestSetupHTTPServerDisabledEndpoints verifies each enable_* setting
+// turned off makes its endpoints answer 404, including when the dashboard's
+// "/" route would otherwise catch them, while the other endpoints still
+// answer.
+func TestSetupHTTPServerDisabledEndpoints(t *testing.T) {
+	tests := []struct {
+		name    string
+		disable func(*config.Config)
+		paths   []string
+	}{
+		{"dashboard", func(c *config.Config) { c.EnableDashboard = false }, []string{"/", "/index.html"}},
+		{"api", func(c *config.Config) { c.EnableAPI = false }, []string{"/api/status", "/api/history", "/api/logs"}},
+		{"metrics", func(c *config.Config) { c.EnableMetrics = false }, []string{"/metrics"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := reloadBaseConfig()
+			cfg.EnableDashboard, cfg.EnableAPI, cfg.EnableMetrics = true, true, true
+			tt.disable(cfg)
+
+			for _, path := range tt.paths {
+				if w := serve(cfg, path); w.Code != http.StatusNotFound {
+					t.Errorf("GET %s with %s disabled: expected 404, got %d", path, tt.name, w.Code)
+				}
+			}
+			for _, path := range []string{"/", "/api/status", "/metrics"} {
+				if slices.Contains(tt.paths, path) {
+					continue
+				}
+				if w := serve(cfg, path); w.Code == http.StatusNotFound {
+					t.Errorf("GET %s with only %s disabled: expected it served, got 404", path, tt.name)
+				}
+			}
+		})
+	}
+}
+
+// okHandler is the handler auth wrappers delegate to in tests.
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+// TestBasicAuthHandler verifies requests without the configured
+// credentials are rejected with a challenge naming the realm, and requests
+// with them are delegated.
+func TestBasicAuthHandler(t *testing.T) {
+	handler := requireBasicAuth(okHandler, "admin", "s3cret", "dashboard")
+
+	tests := []struct {
+		name     string
+		user     string
+		pass     string
+		withAuth bool
+		want     int
+	}{
+		{"missing credentials", "", "", false, http.StatusUnauthorized},
+		{"wrong user", "root", "s3cret", true, http.StatusUnauthorized},
+		{"wrong password", "admin", "wrong", true, http.StatusUnauthorized},
+		{"both wrong", "root", "wrong", true, http.StatusUnauthorized},
+		{"correct credentials", "admin", "s3cret", true, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.withAuth {
+				req.SetBasicAuth(tt.user, tt.pass)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("Expected status %d, got %d", tt.want, w.Code)
+			}
+			challenge := w.Header().Get("WWW-Authenticate")
+			if tt.want == http.StatusUnauthorized && challenge != `Basic realm="dashboard", charset="UTF-8"` {
+				t.Errorf("Expected Basic challenge for realm dashboard, got %q", challenge)
+			}
+			if tt.want == http.StatusOK && challenge != "" {
+				t.Errorf("Expected no challenge on success, got %q", challenge)
+			}
+		})
+	}
+}
+
+// TestRequireBasicAuthEmpty verifies an empty username leaves the handler
+// unwrapped, so authentication is off.
+func TestRequireBasicAuthEmpty(t *testing.T) {
+	handler := requireBasicAuth(okHandler, "", "", "dashboard")
+	if _, wrapped := handler.(*BasicAuthHandler); wrapped {
+		t.Fatal("Expected the handler to be returned unwrapped")
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 without credentials configured, got %d", w.Code)
+	}
+}
+
+// TestCredentialsMatch verifies both fields must match.
+func TestCredentialsMatch(t *testing.T) {
+	tests := []struct {
+		user, pass string
+		want       bool
+	}{
+		{"admin", "s3cret", true},
+		{"admin", "wrong", false},
+		{"root", "s3cret", false},
+		{"", "", false},
+		{"admin", "", false},
+	}
+	for _, tt := range tests {
+		if got := credentialsMatch(tt.user, tt.pass, "admin", "s3cret"); got != tt.want {
+			t.Errorf("credentialsMatch(%q, %q) = %v, want %v", tt.user, tt.pass, got, tt.want)
+		}
+	}
+}
+
+// TestBearerTokenHandler verifies requests without the configured token
+// are rejected with a challenge, and requests with it are delegated.
+func TestBearerTokenHandler(t *testing.T) {
+	handler := requireBearerToken(okHandler, "s3cret")
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"missing header", "", http.StatusUnauthorized},
+		{"wrong scheme", "Basic czNjcmV0", http.StatusUnauthorized},
+		{"wrong token", "Bearer wrong", http.StatusUnauthorized},
+		{"token prefix", "Bearer s3cre", http.StatusUnauthorized},
+		{"correct token", "Bearer s3cret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("Expected status %d, got %d", tt.want, w.Code)
+			}
+			challenge := w.Header().Get("WWW-Authenticate")
+			if tt.want == http.StatusUnauthorized && challenge != `Bearer realm="api"` {
+				t.Errorf("Expected Bearer challenge, got %q", challenge)
+			}
+			if tt.want == http.StatusOK && challenge != "" {
+				t.Errorf("Expected no challenge on success, got %q", challenge)
+			}
+		})
+	}
+}
+
+// TestRequireBearerTokenEmpty verifies an empty token leaves the handler
+// unwrapped, so authentication is off.
+func TestRequireBearerTokenEmpty(t *testing.T) {
+	handler := requireBearerToken(okHandler, "")
+	if _, wrapped := handler.(*BearerTokenHandler); wrapped {
+		t.Fatal("Expected the handler to be returned unwrapped")
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 without a token, got %d", w.Code)
+	}
+}
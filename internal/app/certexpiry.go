@@ -0,0 +1,99 @@
+// -----------------------------------------------------------------------
+// TLS Certificate Expiry Metric
+// -----------------------------------------------------------------------
+//
+// Publishes the served certificate's NotAfter as
+// health_check_tls_cert_expiry_timestamp_seconds so expiry can be alerted
+// on fleet-wide. Startup validation only checks once; this refreshes daily
+// by re-reading the certificate being served, from the reloader in manual
+// mode or from the autocert cache.
+//
+// -----------------------------------------------------------------------
+
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/afreidah/health-check-service/internal/metrics"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// certExpiryInterval is how often the expiry metric is refreshed.
+const certExpiryInterval = 24 * time.Hour
+
+// leafCertificate returns the served leaf certificate source.
+type leafCertificate func() (*x509.Certificate, error)
+
+// watchCertExpiry sets the expiry metric from leaf now and then daily.
+// Until the first read succeeds, such as before autocert has issued a
+// certificate, it retries every certPollInterval.
+func watchCertExpiry(leaf leafCertificate) {
+	recorded := false
+	for {
+		cert, err := leaf()
+		switch {
+		case err == nil:
+			setCertExpiry(cert)
+			recorded = true
+		case recorded:
+			loga.Warn("cannot read TLS certificate for expiry metric; keeping last value", "err", err)
+		default:
+			loga.Debug("TLS certificate expiry not available yet", "err", err)
+		}
+
+		wait := certExpiryInterval
+		if !recorded {
+			wait = certPollInterval
+		}
+		time.Sleep(wait)
+	}
+}
+
+// setCertExpiry publishes cert's NotAfter as the expiry metric.
+func setCertExpiry(cert *x509.Certificate) {
+	metrics.TLSCertExpiry.Set(float64(cert.NotAfter.Unix()))
+}
+
+// tlsLeaf returns the leaf of cert, parsing it when Leaf is not populated.
+func tlsLeaf(cert *tls.Certificate) (*x509.Certificate, error) {
+	if cert == nil || len(cert.Certificate) == 0 {
+		return nil, errors.New("no certificate loaded")
+	}
+	if cert.Leaf != nil {
+		return cert.Leaf, nil
+	}
+	return x509.ParseCertificate(cert.Certificate[0])
+}
+
+// autocertLeaf reads domain's certificate from the autocert cache rather
+// than GetCertificate, which would start an ACME order if none is cached.
+// autocert stores ECDSA certificates under the domain and RSA ones under
+// domain+"+rsa", each as the private key followed by the chain.
+func autocertLeaf(cache autocert.Cache, domain string) leafCertificate {
+	return func() (*x509.Certificate, error) {
+		for _, key := range []string{domain, domain + "+rsa"} {
+			data, err := cache.Get(context.Background(), key)
+			if errors.Is(err, autocert.ErrCacheMiss) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("reading autocert cache: %w", err)
+			}
+
+			for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+				if block.Type == "CERTIFICATE" {
+					return x509.ParseCertificate(block.Bytes)
+				}
+			}
+			return nil, fmt.Errorf("no certificate in autocert cache entry %s", key)
+		}
+		return nil, fmt.Errorf("no certificate cached for %s", domain)
+	}
+}
@@ -0,0 +1,119 @@
+// -----------------------------------------------------------------------
+// TLS Certificate Expiry Metric - Tests
+// -----------------------------------------------------------------------
+//
+// Validates reading the served leaf certificate, from a loaded
+// tls.Certificate in manual mode and from autocert's cache, where entries
+// hold the private key followed by the chain under the domain (ECDSA) or
+// domain+"+rsa" (RSA).
+//
+// -----------------------------------------------------------------------
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// putAutocertEntry stores key followed by certs under name in cache, the
+// layout autocert writes.
+func putAutocertEntry(t *testing.T, cache autocert.Cache, name string, key []byte, certs ...[]byte) {
+	t.Helper()
+	data := append([]byte{}, key...)
+	data = append(data, bytes.Join(certs, nil)...)
+	if err := cache.Put(context.Background(), name, data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestAutocertLeaf verifies the leaf is read from the first certificate of
+// the domain's entry, falling back to the RSA entry, and that a missing or
+// certificate-less entry is an error.
+func TestAutocertLeaf(t *testing.T) {
+	leafPEM, keyPEM := testCertPEM(t, 1)
+	issuerPEM, _ := testCertPEM(t, 2)
+
+	tests := []struct {
+		name    string
+		setup   func(autocert.Cache)
+		serial  int64
+		wantErr bool
+	}{
+		{"ecdsa entry", func(c autocert.Cache) {
+			putAutocertEntry(t, c, "example.com", keyPEM, leafPEM, issuerPEM)
+		}, 1, false},
+		{"rsa entry", func(c autocert.Cache) {
+			putAutocertEntry(t, c, "example.com+rsa", keyPEM, leafPEM, issuerPEM)
+		}, 1, false},
+		{"ecdsa preferred", func(c autocert.Cache) {
+			putAutocertEntry(t, c, "example.com", keyPEM, issuerPEM)
+			putAutocertEntry(t, c, "example.com+rsa", keyPEM, leafPEM)
+		}, 2, false},
+		{"other domain only", func(c autocert.Cache) {
+			putAutocertEntry(t, c, "other.example.com", keyPEM, leafPEM)
+		}, 0, true},
+		{"key without chain", func(c autocert.Cache) {
+			putAutocertEntry(t, c, "example.com", keyPEM)
+		}, 0, true},
+		{"empty cache", func(autocert.Cache) {}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := autocert.DirCache(t.TempDir())
+			tt.setup(cache)
+
+			leaf, err := autocertLeaf(cache, "example.com")()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got certificate with serial %v", leaf.SerialNumber)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("autocertLeaf() error = %v", err)
+			}
+			if got := leaf.SerialNumber.Int64(); got != tt.serial {
+				t.Errorf("Expected serial %d, got %d", tt.serial, got)
+			}
+		})
+	}
+}
+
+// TestTLSLeaf verifies the leaf is taken from Leaf when populated, parsed
+// from the first certificate otherwise, and that an empty certificate is an
+// error.
+func TestTLSLeaf(t *testing.T) {
+	certPEM, keyPEM := testCertPEM(t, 1)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert.Leaf = nil
+	leaf, err := tlsLeaf(&cert)
+	if err != nil {
+		t.Fatalf("tlsLeaf() error = %v", err)
+	}
+	if leaf.SerialNumber.Int64() != 1 {
+		t.Errorf("Expected serial 1 parsed from the chain, got %v", leaf.SerialNumber)
+	}
+
+	populated := &x509.Certificate{}
+	cert.Leaf = populated
+	if leaf, err := tlsLeaf(&cert); err != nil || leaf != populated {
+		t.Errorf("Expected the populated Leaf, got %v, %v", leaf, err)
+	}
+
+	for _, empty := range []*tls.Certificate{nil, {}} {
+		if _, err := tlsLeaf(empty); err == nil {
+			t.Errorf("Expected an error for %+v", empty)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// -----------------------------------------------------------------------
+// TLS Certificate Reloading
+// -----------------------------------------------------------------------
+//
+// In manual TLS mode the certificate is served through a GetCertificate
+// callback backed by an atomically swapped *tls.Certificate. A background
+// loop watches the certificate and key files and, when either changes,
+// validates the new pair before swapping it in. A bad renewal is logged and
+// the previous certificate keeps serving.
+//
+// -----------------------------------------------------------------------
+
+package app
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/afreidah/health-check-service/internal/config"
+)
+
+// certPollInterval is how often certificate files are checked for changes.
+const certPollInterval = time.Minute
+
+// certReloader serves the most recently loaded valid certificate.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	expiry   config.CertExpiryPolicy
+	cert     atomic.Pointer[tls.Certificate]
+
+	// Modification times of the loaded files, only touched by the poll loop
+	certMod time.Time
+	keyMod  time.Time
+}
+
+// newCertReloader loads the initial certificate pair and starts watching
+// the files for changes. A strict expiry policy also rejects renewals that
+// are expired or close to expiry.
+func newCertReloader(certFile, keyFile string, expiry config.CertExpiryPolicy) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile, expiry: expiry}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	go r.watchLoop(certPollInterval, nil)
+	go watchCertExpiry(r.leaf)
+
+	return r, nil
+}
+
+// leaf returns the currently served leaf certificate.
+func (r *certReloader) leaf() (*x509.Certificate, error) {
+	return tlsLeaf(r.cert.Load())
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// reload validates and loads the certificate pair, swapping it in only if
+// both steps succeed.
+func (r *certReloader) reload() error {
+	certMod, keyMod, err := r.modTimes()
+	if err != nil {
+		return err
+	}
+
+	if err := config.ValidateTLSCertificatePair(r.certFile, r.keyFile, r.expiry); err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading certificate pair: %w", err)
+	}
+
+	r.cert.Store(&cert)
+	r.certMod, r.keyMod = certMod, keyMod
+	if leaf, err := tlsLeaf(&cert); err == nil {
+		setCertExpiry(leaf)
+	}
+	return nil
+}
+
+// watchLoop reloads the certificate whenever either file's modification
+// time changes, checking every interval until stop is closed. A nil stop
+// watches for the life of the process.
+func (r *certReloader) watchLoop(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		certMod, keyMod, err := r.modTimes()
+		if err != nil {
+			loga.Error("cannot stat TLS certificate files; keeping current certificate", "err", err)
+			continue
+		}
+		if certMod.Equal(r.certMod) && keyMod.Equal(r.keyMod) {
+			continue
+		}
+
+		if err := r.reload(); err != nil {
+			loga.Error("TLS certificate reload failed; keeping current certificate",
+				"cert", r.certFile, "err", err)
+			// Remember the bad files so the error is logged once per change
+			r.certMod, r.keyMod = certMod, keyMod
+			continue
+		}
+		loga.Info("TLS certificate reloaded", "cert", r.certFile)
+	}
+}
+
+// modTimes returns the modification times of the certificate and key files.
+func (r *certReloader) modTimes() (time.Time, time.Time, error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
@@ -0,0 +1,179 @@
+// -----------------------------------------------------------------------
+// TLS Certificate Reloading - Tests
+// -----------------------------------------------------------------------
+//
+// Validates that a renewed certificate pair is picked up once its files
+// change, and that a bad renewal leaves the previous certificate serving.
+//
+// -----------------------------------------------------------------------
+
+package app
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/afreidah/health-check-service/internal/config"
+)
+
+// testCertPEM returns a self-signed ECDSA certificate with the given
+// serial number and its PKCS#8 key, both PEM encoded.
+func testCertPEM(t *testing.T, serial int64) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeCertFiles writes certPEM and keyPEM to their files and moves both
+// modification times forward, so a change is seen even on filesystems with
+// coarse timestamps.
+func writeCertFiles(t *testing.T, certFile, keyFile string, certPEM, keyPEM []byte, mod time.Time) {
+	t.Helper()
+	for path, data := range map[string][]byte{certFile: certPEM, keyFile: keyPEM} {
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// loadTestCertReloader returns a certReloader serving a certificate with
+// serial 1, without watching its files.
+func loadTestCertReloader(t *testing.T) *certReloader {
+	t.Helper()
+	dir := t.TempDir()
+	r := &certReloader{
+		certFile: filepath.Join(dir, "cert.pem"),
+		keyFile:  filepath.Join(dir, "key.pem"),
+		expiry:   config.CertExpiryPolicy{},
+	}
+	certPEM, keyPEM := testCertPEM(t, 1)
+	writeCertFiles(t, r.certFile, r.keyFile, certPEM, keyPEM, time.Now().Add(-time.Hour))
+	if err := r.reload(); err != nil {
+		t.Fatalf("reload() error = %v", err)
+	}
+	return r
+}
+
+// newTestCertReloader returns a certReloader serving a certificate with
+// serial 1, watching its files every few milliseconds until the test ends.
+func newTestCertReloader(t *testing.T) *certReloader {
+	t.Helper()
+	r := loadTestCertReloader(t)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		r.watchLoop(5*time.Millisecond, stop)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		close(stop)
+		<-done
+	})
+	return r
+}
+
+// servedSerial returns the serial number of the certificate r serves.
+func servedSerial(t *testing.T, r *certReloader) int64 {
+	t.Helper()
+	leaf, err := r.leaf()
+	if err != nil {
+		t.Fatalf("leaf() error = %v", err)
+	}
+	return leaf.SerialNumber.Int64()
+}
+
+// TestCertReloaderPicksUpRenewal verifies a renewed pair is served once
+// the watch loop sees the files change.
+func TestCertReloaderPicksUpRenewal(t *testing.T) {
+	r := newTestCertReloader(t)
+
+	certPEM, keyPEM := testCertPEM(t, 2)
+	writeCertFiles(t, r.certFile, r.keyFile, certPEM, keyPEM, time.Now())
+
+	deadline := time.Now().Add(2 * time.Second)
+	for servedSerial(t, r) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("renewed certificate was not picked up")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestCertReloaderKeepsCertOnBadRenewal verifies a renewal that fails
+// validation leaves the previous certificate serving, and a later good
+// renewal is still picked up.
+func TestCertReloaderKeepsCertOnBadRenewal(t *testing.T) {
+	r := newTestCertReloader(t)
+
+	// A key that does not match the certificate
+	certPEM, _ := testCertPEM(t, 2)
+	_, otherKeyPEM := testCertPEM(t, 3)
+	writeCertFiles(t, r.certFile, r.keyFile, certPEM, otherKeyPEM, time.Now().Add(-time.Minute))
+
+	time.Sleep(50 * time.Millisecond)
+	if got := servedSerial(t, r); got != 1 {
+		t.Fatalf("Expected the previous certificate after a bad renewal, got serial %d", got)
+	}
+	if cert, err := r.GetCertificate(nil); err != nil || cert == nil {
+		t.Fatalf("GetCertificate() = %v, %v; want the previous certificate", cert, err)
+	}
+
+	certPEM, keyPEM := testCertPEM(t, 4)
+	writeCertFiles(t, r.certFile, r.keyFile, certPEM, keyPEM, time.Now())
+
+	deadline := time.Now().Add(2 * time.Second)
+	for servedSerial(t, r) != 4 {
+		if time.Now().After(deadline) {
+			t.Fatal("good renewal after a bad one was not picked up")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestCertReloaderReloadFails verifies reload returns the validation error
+// without replacing the served certificate.
+func TestCertReloaderReloadFails(t *testing.T) {
+	r := loadTestCertReloader(t)
+
+	if err := os.WriteFile(r.certFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.reload(); err == nil {
+		t.Fatal("Expected reload() to fail for an invalid certificate")
+	}
+	if got := servedSerial(t, r); got != 1 {
+		t.Errorf("Expected serial 1 to keep serving, got %d", got)
+	}
+}
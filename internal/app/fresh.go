@@ -0,0 +1,58 @@
+// -----------------------------------------------------------------------
+// Fresh Checks
+// -----------------------------------------------------------------------
+//
+// /health?fresh=1 and /api/status?fresh=1 check every monitored service
+// before responding instead of serving the cache. Each such request costs
+// D-Bus queries for every service, so fresh requests pass a strict
+// limiter of their own and need the API token when one is configured.
+// Requests without the parameter are served from cache as always.
+//
+// -----------------------------------------------------------------------
+
+package app
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/afreidah/health-check-service/internal/ratelimit"
+)
+
+// checkFreshOnRequest wraps handler so requests with ?fresh=1 first check
+// every service through checkers. endpoint labels the fresh requests'
+// metrics and limiter rejections.
+func checkFreshOnRequest(
+	handler http.Handler,
+	checkers *Checkers,
+	limiter *ratelimit.Manager,
+	token string,
+	endpoint string,
+) http.Handler {
+	fresh := &RateLimitedHandler{
+		handler: requireBearerToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			loga.Info("fresh check requested", "endpoint", endpoint, "ip", ratelimit.GetIP(r))
+			if err := checkers.CheckNow(r.Context()); err != nil {
+				loga.Warn("fresh check incomplete; serving cached status", "endpoint", endpoint, "err", err)
+			}
+			handler.ServeHTTP(w, r)
+		}), token),
+		limiter:  limiter,
+		endpoint: endpoint,
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if wantsFresh(r) {
+			fresh.ServeHTTP(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// wantsFresh reports whether the request asked for a fresh check with
+// ?fresh=1 (or any other true value accepted by strconv.ParseBool).
+func wantsFresh(r *http.Request) bool {
+	fresh, err := strconv.ParseBool(r.URL.Query().Get("fresh"))
+	return err == nil && fresh
+}
@@ -0,0 +1,145 @@
+// -----------------------------------------------------------------------
+// Gzip Response Compression
+// -----------------------------------------------------------------------
+//
+// The dashboard HTML and JSON API responses are compressed when the client
+// advertises gzip support. Health probes are skipped because their bodies
+// are tiny and latency matters more than size, and /metrics is skipped
+// because promhttp negotiates its own compression.
+//
+// -----------------------------------------------------------------------
+
+package app
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// GzipHandler compresses responses from the wrapped handler for clients
+// that accept gzip.
+type GzipHandler struct {
+	handler http.Handler
+}
+
+// ServeHTTP implements the http.Handler interface with response compression.
+func (h *GzipHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if skipCompression(r.URL.Path) {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+
+	w.Header().Add("Vary", "Accept-Encoding")
+	if !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+
+	gw := &gzipResponseWriter{ResponseWriter: w, head: r.Method == http.MethodHead}
+	defer gw.close()
+
+	h.handler.ServeHTTP(gw, r)
+}
+
+// compressResponses wraps handler with gzip compression.
+func compressResponses(handler http.Handler) http.Handler {
+	return &GzipHandler{handler: handler}
+}
+
+// skipCompression reports whether path is served uncompressed: health
+// probes and the Prometheus endpoint.
+func skipCompression(path string) bool {
+	switch path {
+	case "/health", "/livez", "/readyz", "/metrics":
+		return true
+	}
+	return strings.HasPrefix(path, "/health/")
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip. A
+// zero quality value ("gzip;q=0") explicitly refuses the encoding.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		qValue, ok := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if !ok {
+			return true
+		}
+		q, err := strconv.ParseFloat(qValue, 64)
+		return err == nil && q > 0
+	}
+	return false
+}
+
+// -----------------------------------------------------------------------
+// Response Writer
+// -----------------------------------------------------------------------
+
+// gzipResponseWriter decides whether to compress on the first WriteHeader
+// or Write, so the wrapped handler's status code is forwarded unchanged.
+// Bodiless responses (HEAD, 204, 304) and responses that already carry a
+// Content-Encoding pass through untouched.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer
+	head        bool
+	wroteHeader bool
+}
+
+// WriteHeader sets the compression headers when the response will have a
+// compressed body, then forwards the status code.
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	if code < http.StatusOK {
+		// Informational responses precede the real header
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.wroteHeader = true
+
+	h := w.Header()
+	bodiless := w.head || code == http.StatusNoContent || code == http.StatusNotModified
+	if !bodiless && h.Get("Content-Encoding") == "" {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write compresses b when compression was selected in WriteHeader.
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		// Sniff before the body is compressed, as net/http would
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.gz == nil {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.gz.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close flushes the gzip footer. If the handler wrote nothing, net/http
+// sends an empty uncompressed 200 as usual.
+func (w *gzipResponseWriter) close() {
+	if w.gz != nil {
+		_ = w.gz.Close()
+	}
+}
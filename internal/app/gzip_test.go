@@ -0,0 +1,132 @@
+// -----------------------------------------------------------------------
+// Gzip Response Compression - Tests
+// -----------------------------------------------------------------------
+//
+// Validates that compression is negotiated from Accept-Encoding, skipped
+// for health probes, /metrics and bodiless responses, and never changes
+// the status code the wrapped handler wrote.
+//
+// -----------------------------------------------------------------------
+
+package app
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestAcceptsGzip verifies Accept-Encoding parsing, including quality
+// values that refuse the encoding.
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"GZIP", true},
+		{"deflate, gzip", true},
+		{"gzip;q=0.5", true},
+		{"gzip; q=1", true},
+		{"*", true},
+		{"gzip;q=0", false},
+		{"gzip;q=0.0", false},
+		{"*;q=0", false},
+		{"deflate, br", false},
+	}
+
+	for _, tt := range tests {
+		if got := acceptsGzip(tt.header); got != tt.want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+// TestGzipHandler verifies which responses are compressed and that the
+// wrapped handler's status code always passes through.
+func TestGzipHandler(t *testing.T) {
+	const body = `{"status":"ok"}`
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		acceptEncoding string
+		status         int
+		encoding       string // Content-Encoding set by the wrapped handler
+		wantStatus     int
+		wantGzip       bool
+	}{
+		{"compressed 200", "GET", "/api/status", "gzip", http.StatusOK, "", http.StatusOK, true},
+		{"compressed 404", "GET", "/api/status", "gzip", http.StatusNotFound, "", http.StatusNotFound, true},
+		{"compressed 500", "GET", "/api/status", "gzip", http.StatusInternalServerError, "", http.StatusInternalServerError, true},
+		{"implicit 200", "GET", "/api/status", "gzip", 0, "", http.StatusOK, true},
+		{"not accepted", "GET", "/api/status", "", http.StatusOK, "", http.StatusOK, false},
+		{"refused with q=0", "GET", "/api/status", "gzip;q=0", http.StatusOK, "", http.StatusOK, false},
+		{"HEAD", "HEAD", "/api/status", "gzip", http.StatusOK, "", http.StatusOK, false},
+		{"204", "GET", "/api/status", "gzip", http.StatusNoContent, "", http.StatusNoContent, false},
+		{"304", "GET", "/api/status", "gzip", http.StatusNotModified, "", http.StatusNotModified, false},
+		{"already encoded", "GET", "/api/status", "gzip", http.StatusOK, "br", http.StatusOK, false},
+		{"health skipped", "GET", "/health", "gzip", http.StatusServiceUnavailable, "", http.StatusServiceUnavailable, false},
+		{"service health skipped", "GET", "/health/nginx", "gzip", http.StatusOK, "", http.StatusOK, false},
+		{"readyz skipped", "GET", "/readyz", "gzip", http.StatusOK, "", http.StatusOK, false},
+		{"metrics skipped", "GET", "/metrics", "gzip", http.StatusOK, "", http.StatusOK, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := compressResponses(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if tt.encoding != "" {
+					w.Header().Set("Content-Encoding", tt.encoding)
+				}
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				if tt.status != http.StatusNoContent && tt.status != http.StatusNotModified {
+					io.WriteString(w, body)
+				}
+			}))
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+
+			encoding := w.Header().Get("Content-Encoding")
+			if !tt.wantGzip {
+				if encoding == "gzip" {
+					t.Errorf("Expected an uncompressed response, got Content-Encoding gzip")
+				}
+				if tt.encoding != "" && encoding != tt.encoding {
+					t.Errorf("Expected Content-Encoding %q left alone, got %q", tt.encoding, encoding)
+				}
+				return
+			}
+
+			if encoding != "gzip" {
+				t.Fatalf("Expected Content-Encoding gzip, got %q", encoding)
+			}
+			zr, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Fatalf("gzip.NewReader: %v", err)
+			}
+			got, err := io.ReadAll(zr)
+			if err != nil {
+				t.Fatalf("reading gzip body: %v", err)
+			}
+			if string(got) != body {
+				t.Errorf("Expected body %q, got %q", body, got)
+			}
+		})
+	}
+}
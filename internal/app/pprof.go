@@ -0,0 +1,49 @@
+// -----------------------------------------------------------------------
+// Runtime Profiling Endpoints
+// -----------------------------------------------------------------------
+//
+// With enable_pprof the net/http/pprof handlers are served under
+// /debug/pprof/ on the main mux, behind the API token and a strict rate
+// limit. Profiles expose memory contents and CPU profiling stalls the
+// process briefly, so the routes are off by default.
+//
+// CPU profiles and traces run for ?seconds= (30 by default), which must
+// stay below request_timeout_seconds and write_timeout_seconds, e.g.
+// /debug/pprof/profile?seconds=5.
+//
+// -----------------------------------------------------------------------
+
+package app
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/afreidah/health-check-service/internal/config"
+)
+
+// registerPprof adds the profiling routes to mux when cfg enables them.
+// Otherwise /debug/pprof/ is answered with 404, as it would fall through
+// to the dashboard.
+func registerPprof(mux *http.ServeMux, cfg *config.Config, limiters *Limiters) {
+	if !cfg.EnablePprof {
+		mux.Handle("/debug/pprof/", http.NotFoundHandler())
+		return
+	}
+
+	routes := map[string]http.HandlerFunc{
+		"/debug/pprof/":        pprof.Index, // also serves named profiles (heap, goroutine, ...)
+		"/debug/pprof/cmdline": pprof.Cmdline,
+		"/debug/pprof/profile": pprof.Profile,
+		"/debug/pprof/symbol":  pprof.Symbol,
+		"/debug/pprof/trace":   pprof.Trace,
+	}
+	for pattern, handler := range routes {
+		mux.Handle(pattern, &RateLimitedHandler{
+			handler:  requireBearerToken(handler, cfg.APIToken),
+			limiter:  limiters.Pprof,
+			endpoint: "pprof",
+		})
+	}
+	loga.Warn("profiling endpoints enabled", "endpoint", "/debug/pprof/")
+}
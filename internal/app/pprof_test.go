@@ -0,0 +1,87 @@
+// -----------------------------------------------------------------------
+// Runtime Profiling Endpoints - Tests
+// -----------------------------------------------------------------------
+//
+// Validates that /debug/pprof/ answers 404 when profiling is disabled,
+// rather than the dashboard page, and requires the API token when it is
+// enabled.
+//
+// -----------------------------------------------------------------------
+
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/afreidah/health-check-service/internal/cache"
+)
+
+// pprofServer returns the handler of a server with the dashboard enabled
+// and profiling set to enabled.
+func pprofServer(enabled bool) http.Handler {
+	cfg := reloadBaseConfig()
+	cfg.EnableDashboard = true
+	cfg.EnablePprof = enabled
+	cfg.APIToken = "s3cret"
+	return SetupHTTPServer(cfg, cache.NewRegistry([]string{cfg.Service}, cfg.HistorySize),
+		nil, NewLimiters(cfg), []byte("<html></html>")).Handler
+}
+
+// pprofRequest sends a GET for path with the given Authorization header,
+// from a distinct client address each time so the strict profiling rate
+// limit does not interfere.
+func pprofRequest(handler http.Handler, path, auth string) *httptest.ResponseRecorder {
+	pprofClients++
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = fmt.Sprintf("192.0.2.%d:1234", pprofClients)
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+// pprofClients numbers the client addresses used by pprofRequest.
+var pprofClients int
+
+// TestPprofDisabled verifies the profiling routes answer 404 when
+// disabled, even with a valid token and the dashboard's "/" route present.
+func TestPprofDisabled(t *testing.T) {
+	handler := pprofServer(false)
+
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/heap", "/debug/pprof/cmdline"} {
+		if w := pprofRequest(handler, path, "Bearer s3cret"); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s: expected 404, got %d", path, w.Code)
+		}
+	}
+}
+
+// TestPprofRequiresToken verifies enabled profiling routes reject requests
+// without the API token and serve those with it.
+func TestPprofRequiresToken(t *testing.T) {
+	handler := pprofServer(true)
+
+	tests := []struct {
+		name string
+		auth string
+		want int
+	}{
+		{"missing token", "", http.StatusUnauthorized},
+		{"wrong token", "Bearer wrong", http.StatusUnauthorized},
+		{"correct token", "Bearer s3cret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, path := range []string{"/debug/pprof/", "/debug/pprof/cmdline"} {
+				if w := pprofRequest(handler, path, tt.auth); w.Code != tt.want {
+					t.Errorf("GET %s: expected %d, got %d", path, tt.want, w.Code)
+				}
+			}
+		})
+	}
+}
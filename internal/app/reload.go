@@ -0,0 +1,128 @@
+// -----------------------------------------------------------------------
+// Configuration Reload
+// -----------------------------------------------------------------------
+//
+// SIGHUP re-runs config.Load and applies the subset of fields that can
+// change without restarting: the check schedule (interval, jitter, adaptive
+// backoff), the monitored service set, the watchdog multiplier, the
+// dashboard poll interval, and the rate limits. Everything else (port,
+// TLS, D-Bus scope, history size, state codes) is bound at startup;
+// changes to those fields are logged as ignored.
+//
+// -----------------------------------------------------------------------
+
+package app
+
+import (
+	"reflect"
+
+	"github.com/afreidah/health-check-service/internal/config"
+	"github.com/afreidah/health-check-service/internal/handlers"
+	"github.com/afreidah/health-check-service/internal/metrics"
+)
+
+// liveReloadFields lists the config keys applied on reload.
+var liveReloadFields = map[string]bool{
+	"interval":                true,
+	"interval_jitter_percent": true,
+	"dbus_timeout_seconds":    true,
+
+	"adaptive_interval":           true,
+	"adaptive_interval_max":       true,
+	"adaptive_interval_threshold": true,
+	"service":                     true,
+	"services":                    true,
+	"target":                      true,
+
+	"watchdog_multiplier": true,
+
+	"dashboard_poll_interval_seconds": true,
+
+	"health_rate":     true,
+	"health_burst":    true,
+	"dashboard_rate":  true,
+	"dashboard_burst": true,
+	"metrics_rate":    true,
+	"metrics_burst":   true,
+}
+
+// ReloadConfig re-reads configuration from flags, environment, and the
+// config file, then applies live-reloadable changes to the running
+// checkers and rate limiters. An invalid configuration is logged and the
+// current one kept. Each outcome is counted in
+// health_check_config_reloads_total.
+func ReloadConfig(checkers *Checkers, limiters *Limiters) {
+	next, err := config.Load()
+	if err != nil {
+		metrics.ConfigReloads.WithLabelValues("failure").Inc()
+		loga.Error("config reload failed; keeping current configuration", "err", err)
+		return
+	}
+	metrics.ConfigReloads.WithLabelValues("success").Inc()
+	metrics.ConfigLastReload.SetToCurrentTime()
+
+	current := checkers.Config()
+	changed := changedFields(current, next)
+	if len(changed) == 0 {
+		loga.Info("config reload: no changes detected")
+		return
+	}
+
+	var applied, ignored []string
+	for _, field := range changed {
+		if liveReloadFields[field] {
+			applied = append(applied, field)
+		} else {
+			ignored = append(ignored, field)
+		}
+	}
+
+	if len(applied) > 0 {
+		merged := mergeLiveFields(current, next)
+		checkers.Apply(merged)
+		limiters.Apply(merged)
+		handlers.ConfigurePollInterval(merged.DashboardPollSeconds())
+	}
+
+	loga.Info("config reloaded", "applied", applied, "ignored", ignored)
+	if len(ignored) > 0 {
+		loga.Warn("changed fields require a restart to take effect", "fields", ignored)
+	}
+}
+
+// changedFields returns the koanf keys of fields that differ between two
+// configurations, in struct declaration order.
+func changedFields(current, next *config.Config) []string {
+	cv := reflect.ValueOf(current).Elem()
+	nv := reflect.ValueOf(next).Elem()
+	t := cv.Type()
+
+	var changed []string
+	for i := 0; i < t.NumField(); i++ {
+		if reflect.DeepEqual(cv.Field(i).Interface(), nv.Field(i).Interface()) {
+			continue
+		}
+		name := t.Field(i).Tag.Get("koanf")
+		if name == "" {
+			name = t.Field(i).Name
+		}
+		changed = append(changed, name)
+	}
+	return changed
+}
+
+// mergeLiveFields returns a copy of current with the live-reloadable fields
+// taken from next, so ignored fields keep their startup values.
+func mergeLiveFields(current, next *config.Config) *config.Config {
+	merged := *current
+	mv := reflect.ValueOf(&merged).Elem()
+	nv := reflect.ValueOf(next).Elem()
+	t := mv.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		if liveReloadFields[t.Field(i).Tag.Get("koanf")] {
+			mv.Field(i).Set(nv.Field(i))
+		}
+	}
+	return &merged
+}
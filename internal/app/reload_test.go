@@ -0,0 +1,173 @@
+// -----------------------------------------------------------------------
+// Configuration Reload - Tests
+// -----------------------------------------------------------------------
+//
+// Validates which fields a reload applies: live fields are taken from the
+// new configuration, and every other field keeps its startup value. A key
+// missing from liveReloadFields would silently make a live field
+// restart-only, so each live field is listed here explicitly. An invalid
+// reload is counted as a failure and changes nothing.
+//
+// -----------------------------------------------------------------------
+
+package app
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/afreidah/health-check-service/internal/config"
+	"github.com/afreidah/health-check-service/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// reloadBaseConfig returns the configuration reloads are compared against.
+func reloadBaseConfig() *config.Config {
+	return &config.Config{
+		Port:               8080,
+		Service:            "nginx",
+		Interval:           10,
+		WatchdogMultiplier: 2,
+		HealthRate:         100,
+		HealthBurst:        200,
+		DashboardRate:      10,
+		DashboardBurst:     20,
+		MetricsRate:        2,
+		MetricsBurst:       10,
+		HistorySize:        50,
+		DBusScope:          "system",
+	}
+}
+
+// TestReloadFields verifies changedFields reports the koanf key of each
+// changed field, and mergeLiveFields takes live fields from the new
+// configuration while keeping the startup value of all others.
+func TestReloadFields(t *testing.T) {
+	tests := []struct {
+		key    string
+		modify func(*config.Config)
+		live   bool
+	}{
+		{"interval", func(c *config.Config) { c.Interval = 30 }, true},
+		{"interval_jitter_percent", func(c *config.Config) { c.IntervalJitterPercent = 20 }, true},
+		{"dbus_timeout_seconds", func(c *config.Config) { c.DBusTimeout = 5 }, true},
+		{"adaptive_interval", func(c *config.Config) { c.AdaptiveInterval = true }, true},
+		{"adaptive_interval_max", func(c *config.Config) { c.AdaptiveIntervalMax = 300 }, true},
+		{"adaptive_interval_threshold", func(c *config.Config) { c.AdaptiveIntervalThreshold = 5 }, true},
+		{"service", func(c *config.Config) { c.Service = "redis" }, true},
+		{"services", func(c *config.Config) { c.Services = []string{"redis"} }, true},
+		{"target", func(c *config.Config) { c.Targets = []string{"tcp:localhost:6379"} }, true},
+		{"watchdog_multiplier", func(c *config.Config) { c.WatchdogMultiplier = 3 }, true},
+		{"dashboard_poll_interval_seconds", func(c *config.Config) { c.DashboardPollInterval = 5 }, true},
+		{"health_rate", func(c *config.Config) { c.HealthRate = 50 }, true},
+		{"health_burst", func(c *config.Config) { c.HealthBurst = 100 }, true},
+		{"dashboard_rate", func(c *config.Config) { c.DashboardRate = 5 }, true},
+		{"dashboard_burst", func(c *config.Config) { c.DashboardBurst = 10 }, true},
+		{"metrics_rate", func(c *config.Config) { c.MetricsRate = 1 }, true},
+		{"metrics_burst", func(c *config.Config) { c.MetricsBurst = 5 }, true},
+
+		{"port", func(c *config.Config) { c.Port = 9090 }, false},
+		{"tls_enabled", func(c *config.Config) { c.TLSEnabled = true }, false},
+		{"dbus_scope", func(c *config.Config) { c.DBusScope = "user" }, false},
+		{"history_size", func(c *config.Config) { c.HistorySize = 100 }, false},
+		{"state_codes", func(c *config.Config) { c.StateCodes = map[string]int{"activating": 200} }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			current := reloadBaseConfig()
+			next := reloadBaseConfig()
+			tt.modify(next)
+
+			if got := changedFields(current, next); !reflect.DeepEqual(got, []string{tt.key}) {
+				t.Fatalf("changedFields = %v, want [%s]", got, tt.key)
+			}
+			if liveReloadFields[tt.key] != tt.live {
+				t.Errorf("liveReloadFields[%q] = %v, want %v", tt.key, liveReloadFields[tt.key], tt.live)
+			}
+
+			merged := mergeLiveFields(current, next)
+			want := current
+			if tt.live {
+				want = next
+			}
+			if !reflect.DeepEqual(merged, want) {
+				t.Errorf("mergeLiveFields with %s changed: live %v, got %+v", tt.key, tt.live, merged)
+			}
+		})
+	}
+}
+
+// TestReloadFieldsMixed verifies a reload changing live and restart-only
+// fields together applies only the live ones.
+func TestReloadFieldsMixed(t *testing.T) {
+	current := reloadBaseConfig()
+	next := reloadBaseConfig()
+	next.Interval = 30
+	next.Port = 9090
+	next.HealthRate = 50
+
+	if got, want := changedFields(current, next), []string{"port", "interval", "health_rate"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("changedFields = %v, want %v", got, want)
+	}
+
+	merged := mergeLiveFields(current, next)
+	if merged.Interval != 30 || merged.HealthRate != 50 {
+		t.Errorf("Expected live fields applied, got interval %d health_rate %v", merged.Interval, merged.HealthRate)
+	}
+	if merged.Port != 8080 {
+		t.Errorf("Expected port to keep its startup value 8080, got %d", merged.Port)
+	}
+	if current.Interval != 10 {
+		t.Errorf("mergeLiveFields must not modify current, interval is now %d", current.Interval)
+	}
+}
+
+// TestLiveReloadFieldsExist verifies every key in liveReloadFields names a
+// configuration field, so a typo cannot leave a field restart-only.
+func TestLiveReloadFieldsExist(t *testing.T) {
+	keys := make(map[string]bool)
+	typ := reflect.TypeOf(config.Config{})
+	for i := 0; i < typ.NumField(); i++ {
+		keys[typ.Field(i).Tag.Get("koanf")] = true
+	}
+
+	for key := range liveReloadFields {
+		if !keys[key] {
+			t.Errorf("liveReloadFields key %q is not a configuration field", key)
+		}
+	}
+}
+
+// TestReloadConfigInvalid verifies a reload that fails validation counts a
+// failure and leaves the running configuration and rate limits unchanged.
+func TestReloadConfigInvalid(t *testing.T) {
+	args := os.Args
+	os.Args = []string{"health-checker", "--service", "redis", "--interval", "0", "--health_rate", "1"}
+	t.Cleanup(func() { os.Args = args })
+
+	current := reloadBaseConfig()
+	checkers := &Checkers{cfg: current}
+	limiters := NewLimiters(current)
+	want := *current
+
+	failures := metrics.ConfigReloads.WithLabelValues("failure")
+	successes := metrics.ConfigReloads.WithLabelValues("success")
+	failuresBefore, successesBefore := testutil.ToFloat64(failures), testutil.ToFloat64(successes)
+
+	ReloadConfig(checkers, limiters)
+
+	if got := testutil.ToFloat64(failures) - failuresBefore; got != 1 {
+		t.Errorf("Expected 1 failed reload counted, got %v", got)
+	}
+	if got := testutil.ToFloat64(successes) - successesBefore; got != 0 {
+		t.Errorf("Expected no successful reload counted, got %v", got)
+	}
+	if checkers.Config() != current || !reflect.DeepEqual(*current, want) {
+		t.Errorf("Expected the configuration unchanged, got %+v", checkers.Config())
+	}
+	if got := limiters.Health.GetRate(); got != want.HealthRate {
+		t.Errorf("Expected health rate %v unchanged, got %v", want.HealthRate, got)
+	}
+}
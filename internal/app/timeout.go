@@ -0,0 +1,48 @@
+// -----------------------------------------------------------------------
+// Request and Server Timeouts
+// -----------------------------------------------------------------------
+//
+// Every request runs under a deadline. A handler that overruns it (e.g. a
+// hung journalctl or D-Bus call) is answered with a clear 503 instead of
+// having the connection cut by the server's WriteTimeout, which should be
+// longer. Handlers see the deadline through r.Context() and should return
+// once it is done. Health endpoints are served from cache and never come
+// close.
+//
+// -----------------------------------------------------------------------
+
+package app
+
+import (
+	"net/http"
+	"time"
+)
+
+// Server timeouts used when the configuration leaves them at zero.
+const (
+	defaultReadTimeout  = 5 * time.Second
+	defaultWriteTimeout = 10 * time.Second
+	defaultIdleTimeout  = 120 * time.Second
+)
+
+// requestTimeoutMessage is the body of the 503 sent for a timed-out request.
+const requestTimeoutMessage = "Service Unavailable: request timed out"
+
+// limitRequestDuration wraps handler so each request is cancelled and
+// answered with 503 after timeout. A zero timeout returns handler
+// unchanged.
+func limitRequestDuration(handler http.Handler, timeout time.Duration) http.Handler {
+	if timeout <= 0 {
+		return handler
+	}
+	return http.TimeoutHandler(handler, timeout, requestTimeoutMessage)
+}
+
+// secondsOr converts a timeout in seconds from the configuration, with
+// zero selecting def.
+func secondsOr(seconds int, def time.Duration) time.Duration {
+	if seconds <= 0 {
+		return def
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -72,6 +72,38 @@ func (s StateType) String() string {
 	}
 }
 
+// -----------------------------------------------------------------------
+// Transition History
+// -----------------------------------------------------------------------
+
+// DefaultHistorySize is the number of state transitions retained when no
+// explicit size is configured.
+const DefaultHistorySize = 50
+
+// UninitializedSystemdState is the systemd state reported before the first
+// check completes.
+const UninitializedSystemdState = "uninitialized"
+
+// ErrorSystemdState is the state recorded when the checker could not read
+// the unit's state, e.g. because D-Bus failed. It says nothing about the
+// service itself.
+const ErrorSystemdState = "error"
+
+// Transition records a change in the monitored service's systemd state.
+type Transition struct {
+	Timestamp time.Time `json:"timestamp"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+}
+
+// Dependency is the last observed state of a unit the monitored unit
+// depends on. Healthy reflects the configured state mapping.
+type Dependency struct {
+	Unit    string `json:"unit"`
+	State   string `json:"state"`
+	Healthy bool   `json:"healthy"`
+}
+
 // -----------------------------------------------------------------------
 // Service Cache Type
 // -----------------------------------------------------------------------
@@ -93,8 +125,60 @@ type ServiceCache struct {
 	// lastChecked is the timestamp of the most recent cache update.
 	lastChecked time.Time
 
+	// stateSince is when systemdState was first observed, i.e. the time of
+	// the last transition.
+	stateSince time.Time
+
 	// cacheState represents the lifecycle state of the cache.
 	cacheState StateType
+
+	// activeTime and observedTime accumulate time between updates,
+	// attributed to the state that was in effect during the interval.
+	// Used to compute the rolling uptime percentage; their difference is
+	// the cumulative downtime. Intervals in ErrorSystemdState are left out,
+	// since the unit's real state was unknown.
+	activeTime   time.Duration
+	observedTime time.Duration
+
+	// statsSince is when activeTime and observedTime started accumulating:
+	// the first check, or the last ResetStats. resetAt is the time of the
+	// last ResetStats, so the interval before it is not counted.
+	statsSince time.Time
+	resetAt    time.Time
+
+	// subState is systemd's SubState (e.g. "dead", "exited", "running"),
+	// and lastActive is when the unit last entered the active state
+	// (ActiveEnterTimestamp); zero if it has never run since boot.
+	subState   string
+	lastActive time.Time
+
+	// restarts is systemd's NRestarts for the unit: how many times it has
+	// been automatically restarted since it was last started manually.
+	restarts uint32
+
+	// dependencies holds the states of declared dependencies when
+	// dependency checks are enabled; nil otherwise.
+	dependencies []Dependency
+
+	// flapping is set by the checker when the service keeps crossing the
+	// active/non-active boundary; see ActiveTransitionsSince.
+	flapping bool
+
+	// history is a fixed-size ring buffer of recent state transitions.
+	// historyNext is the slot for the next write; historyLen is the number
+	// of valid entries (at most len(history)).
+	history     []Transition
+	historyNext int
+	historyLen  int
+
+	// restored is set while the status is one loaded from a state file by
+	// Restore, before the first live check. The gap since the persisted
+	// timestamp was not observed, so it is not counted toward uptime.
+	restored bool
+
+	// onUpdate is called after each UpdateStatus, outside the lock. The
+	// registry uses it to schedule state file writes.
+	onUpdate func()
 }
 
 // -----------------------------------------------------------------------
@@ -103,12 +187,23 @@ type ServiceCache struct {
 
 // New creates a new ServiceCache initialized in the uninitialized state
 // with 503 Service Unavailable until the first successful health check.
+// Retains DefaultHistorySize transitions.
 func New() *ServiceCache {
+	return NewWithHistorySize(DefaultHistorySize)
+}
+
+// NewWithHistorySize creates a new ServiceCache that retains up to size
+// state transitions. A size below 1 selects DefaultHistorySize.
+func NewWithHistorySize(size int) *ServiceCache {
+	if size < 1 {
+		size = DefaultHistorySize
+	}
 	return &ServiceCache{
 		cacheState:   StateUninitialized,
-		systemdState: "uninitialized",
+		systemdState: UninitializedSystemdState,
 		statusCode:   http.StatusServiceUnavailable,
 		lastChecked:  time.Time{},
+		history:      make([]Transition, size),
 	}
 }
 
@@ -132,6 +227,14 @@ func (c *ServiceCache) GetLastChecked() time.Time {
 	return c.lastChecked
 }
 
+// GetStateSince returns when the current systemd state was first observed.
+// Returns the zero time before the first check.
+func (c *ServiceCache) GetStateSince() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.stateSince
+}
+
 // GetCacheState returns the current cache lifecycle state.
 func (c *ServiceCache) GetCacheState() StateType {
 	c.mu.RLock()
@@ -168,31 +271,313 @@ func (c *ServiceCache) GetStaleness() time.Duration {
 	return time.Since(c.lastChecked)
 }
 
+// GetUptimePercent returns the percentage of observed time the service has
+// spent in the active state since the first check. The interval since the
+// most recent update is counted toward the current state so the value moves
+// smoothly between checks. Time in the error state is not observed time.
+// Returns 0 before the first check.
+func (c *ServiceCache) GetUptimePercent() float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.lastChecked.IsZero() || c.restored {
+		return 0
+	}
+
+	sinceUpdate := time.Since(c.accumulatedUntil())
+	if c.systemdState == ErrorSystemdState {
+		sinceUpdate = 0
+	}
+	active := c.activeTime
+	total := c.observedTime + sinceUpdate
+	if c.systemdState == "active" {
+		active += sinceUpdate
+	}
+
+	if total <= 0 {
+		return 0
+	}
+	return float64(active) / float64(total) * 100
+}
+
+// GetDowntime returns the cumulative time the service has spent in
+// non-active states since the first check or the last ResetStats, not
+// counting time in the error state. Unlike
+// GetUptimePercent, only intervals closed by an update are counted, so the
+// value never decreases and can feed a Prometheus counter.
+func (c *ServiceCache) GetDowntime() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.observedTime - c.activeTime
+}
+
+// GetStatsSince returns when uptime and downtime started accumulating:
+// the first check, or the last ResetStats. Returns the zero time before
+// the first check.
+func (c *ServiceCache) GetStatsSince() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.statsSince
+}
+
+// accumulatedUntil returns the end of the last interval counted toward
+// uptime: the last update, or the last reset if that came later. Caller
+// must hold the lock.
+func (c *ServiceCache) accumulatedUntil() time.Time {
+	if c.resetAt.After(c.lastChecked) {
+		return c.resetAt
+	}
+	return c.lastChecked
+}
+
+// GetActivity returns the unit's SubState and when it last entered the
+// active state. A zero time means the unit has not run since boot, which
+// tells a unit that was never started from one that ran and stopped.
+func (c *ServiceCache) GetActivity() (string, time.Time) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.subState, c.lastActive
+}
+
+// GetRestarts returns the most recently observed NRestarts value.
+func (c *ServiceCache) GetRestarts() uint32 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.restarts
+}
+
+// GetDependencies returns a copy of the last observed dependency states.
+func (c *ServiceCache) GetDependencies() []Dependency {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.dependencies == nil {
+		return nil
+	}
+	return append([]Dependency(nil), c.dependencies...)
+}
+
+// FailedDependency returns the first dependency that is not healthy.
+func (c *ServiceCache) FailedDependency() (Dependency, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, d := range c.dependencies {
+		if !d.Healthy {
+			return d, true
+		}
+	}
+	return Dependency{}, false
+}
+
+// GetHistory returns recorded state transitions ordered oldest to newest.
+// The returned slice is a copy and safe to retain.
+func (c *ServiceCache) GetHistory() []Transition {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	out := make([]Transition, 0, c.historyLen)
+	start := (c.historyNext - c.historyLen + len(c.history)) % len(c.history)
+	for i := 0; i < c.historyLen; i++ {
+		out = append(out, c.history[(start+i)%len(c.history)])
+	}
+	return out
+}
+
+// IsFlapping returns whether the service was last classified as flapping.
+func (c *ServiceCache) IsFlapping() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.flapping
+}
+
+// ActiveTransitionsSince counts recorded transitions after since that
+// entered or left the active state. Transitions between two non-active
+// states (e.g. activating -> failed) and the first result after startup
+// are not counted. Checker errors are looked through: active -> error ->
+// active counts nothing, and active -> error -> failed counts once. Only
+// transitions still in the history ring buffer are seen.
+func (c *ServiceCache) ActiveTransitionsSince(since time.Time) int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	at := func(i int) Transition {
+		return c.history[(c.historyNext-1-i+len(c.history))%len(c.history)]
+	}
+
+	count := 0
+	for i := 0; i < c.historyLen; i++ {
+		t := at(i)
+		if !t.Timestamp.After(since) {
+			break
+		}
+		if t.To == ErrorSystemdState {
+			continue
+		}
+		from := t.From
+		if from == ErrorSystemdState {
+			// Compare with the state before the error, if still recorded
+			if i+1 == c.historyLen {
+				continue
+			}
+			from = at(i + 1).From
+		}
+		if from != UninitializedSystemdState && (from == "active") != (t.To == "active") {
+			count++
+		}
+	}
+	return count
+}
+
 // -----------------------------------------------------------------------
 // Update Methods
 // -----------------------------------------------------------------------
 
 // UpdateStatus atomically updates the cached status and transitions the
 // cache state. Called by the background checker when it successfully queries
-// systemd.
+// systemd. If the systemd state changed, the recorded transition is returned
+// with true so the caller can act on it (e.g. send notifications).
 //
 // Parameters:
 //   - code: HTTP status code (200, 503, 500)
 //   - state: systemd ActiveState (active, inactive, failed, etc.)
-func (c *ServiceCache) UpdateStatus(code int, state string) {
+func (c *ServiceCache) UpdateStatus(code int, state string) (Transition, bool) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	transition, changed := c.updateStatus(code, state)
+	onUpdate := c.onUpdate
+	c.mu.Unlock()
+
+	if onUpdate != nil {
+		onUpdate()
+	}
+	return transition, changed
+}
+
+// updateStatus implements UpdateStatus. Caller must hold the write lock.
+func (c *ServiceCache) updateStatus(code int, state string) (Transition, bool) {
+	now := time.Now()
+
+	// Attribute the elapsed interval to the state that was in effect during
+	// it. A restored status was not observed, and a checker error says
+	// nothing about the unit, so their intervals are skipped.
+	if !c.lastChecked.IsZero() && !c.restored && c.systemdState != ErrorSystemdState {
+		elapsed := now.Sub(c.accumulatedUntil())
+		c.observedTime += elapsed
+		if c.systemdState == "active" {
+			c.activeTime += elapsed
+		}
+	}
+
+	var transition Transition
+	changed := state != c.systemdState
+	if changed {
+		transition = Transition{Timestamp: now, From: c.systemdState, To: state}
+		c.recordTransition(transition)
+		c.stateSince = now
+	}
 
 	c.statusCode = code
 	c.systemdState = state
-	c.lastChecked = time.Now()
+	c.lastChecked = now
+	c.restored = false
+	if c.statsSince.IsZero() {
+		c.statsSince = now
+	}
 
 	// Transition state machine based on state
-	if state == "error" {
+	if state == ErrorSystemdState {
 		c.cacheState = StateError
 	} else {
 		c.cacheState = StateRunning
 	}
+
+	return transition, changed
+}
+
+// Restore seeds a cache that has not been checked yet with a status saved
+// by a previous process, so a restarted health checker can answer with the
+// last known status instead of 503 until its first check. The cache is
+// marked stale and checkedAt is kept as the last update time, so staleness
+// warnings report the real age of the data. Has no effect once the cache
+// has been updated.
+func (c *ServiceCache) Restore(code int, state string, checkedAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.lastChecked.IsZero() {
+		return
+	}
+
+	c.statusCode = code
+	c.systemdState = state
+	c.lastChecked = checkedAt
+	c.stateSince = checkedAt
+	c.cacheState = StateStale
+	c.restored = true
+}
+
+// ResetStats zeroes the uptime and downtime accumulators and clears the
+// transition history, e.g. after a planned maintenance window, so they
+// cover only the time since the reset. The current status is kept. Before
+// the first check there is nothing to reset.
+func (c *ServiceCache) ResetStats() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.activeTime = 0
+	c.observedTime = 0
+	c.historyNext = 0
+	c.historyLen = 0
+	clear(c.history)
+	if !c.statsSince.IsZero() {
+		c.resetAt = time.Now()
+		c.statsSince = c.resetAt
+	}
+}
+
+// setOnUpdate installs the hook called after each UpdateStatus.
+func (c *ServiceCache) setOnUpdate(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onUpdate = fn
+}
+
+// SetActivity stores the latest SubState and ActiveEnterTimestamp read
+// from systemd.
+func (c *ServiceCache) SetActivity(subState string, lastActive time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subState = subState
+	c.lastActive = lastActive
+}
+
+// SetRestarts stores the latest NRestarts value read from systemd.
+func (c *ServiceCache) SetRestarts(n uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.restarts = n
+}
+
+// SetDependencies stores the latest dependency states read from systemd.
+func (c *ServiceCache) SetDependencies(deps []Dependency) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dependencies = deps
+}
+
+// SetFlapping records the checker's flap classification.
+func (c *ServiceCache) SetFlapping(flapping bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flapping = flapping
+}
+
+// recordTransition appends a transition to the ring buffer, overwriting the
+// oldest entry when full. Caller must hold the write lock.
+func (c *ServiceCache) recordTransition(t Transition) {
+	c.history[c.historyNext] = t
+	c.historyNext = (c.historyNext + 1) % len(c.history)
+	if c.historyLen < len(c.history) {
+		c.historyLen++
+	}
 }
 
 // SetLastChecked sets the lastChecked timestamp manually. This method is
@@ -277,6 +277,372 @@ func TestGetStaleness(t *testing.T) {
 	}
 }
 
+// -----------------------------------------------------------------------
+// Uptime Tests
+// -----------------------------------------------------------------------
+
+// TestGetUptimePercentBeforeFirstCheck verifies uptime is 0 (not NaN) before
+// any check has run. NaN cannot be encoded as JSON and would break the API.
+func TestGetUptimePercentBeforeFirstCheck(t *testing.T) {
+	c := New()
+
+	if got := c.GetUptimePercent(); got != 0 {
+		t.Errorf("Expected uptime 0 before first check, got %f", got)
+	}
+}
+
+// TestGetUptimePercent verifies elapsed time is attributed to the state in
+// effect during each interval. Attributing it to the new state would report
+// a recovered service as having been up during its outage.
+func TestGetUptimePercent(t *testing.T) {
+	c := New()
+	c.UpdateStatus(http.StatusOK, "active")
+
+	// 30s active, then 10s failed
+	c.SetLastChecked(time.Now().Add(-30 * time.Second))
+	c.UpdateStatus(http.StatusServiceUnavailable, "failed")
+	c.SetLastChecked(time.Now().Add(-10 * time.Second))
+
+	got := c.GetUptimePercent()
+	if got < 74 || got > 76 {
+		t.Errorf("Expected uptime ~75%%, got %f", got)
+	}
+}
+
+// TestGetDowntime verifies only intervals spent in a non-active state are
+// counted, attributed to the state in effect before each update.
+func TestGetDowntime(t *testing.T) {
+	c := New()
+	c.UpdateStatus(http.StatusOK, "active")
+
+	// 30s active, then 10s failed, then recovery
+	c.SetLastChecked(time.Now().Add(-30 * time.Second))
+	c.UpdateStatus(http.StatusServiceUnavailable, "failed")
+	c.SetLastChecked(time.Now().Add(-10 * time.Second))
+	c.UpdateStatus(http.StatusOK, "active")
+
+	got := c.GetDowntime()
+	if got < 9*time.Second || got > 11*time.Second {
+		t.Errorf("Expected downtime ~10s, got %v", got)
+	}
+}
+
+// TestUptimeIgnoresCheckerErrors verifies intervals in the error state,
+// when the unit's state was unknown, count toward neither uptime nor
+// downtime.
+func TestUptimeIgnoresCheckerErrors(t *testing.T) {
+	c := New()
+	c.UpdateStatus(http.StatusOK, "active")
+
+	// 30s active, then 60s of checker errors, then 10s failed
+	c.SetLastChecked(time.Now().Add(-30 * time.Second))
+	c.UpdateStatus(http.StatusInternalServerError, ErrorSystemdState)
+	c.SetLastChecked(time.Now().Add(-60 * time.Second))
+	c.UpdateStatus(http.StatusServiceUnavailable, "failed")
+	c.SetLastChecked(time.Now().Add(-10 * time.Second))
+	c.UpdateStatus(http.StatusOK, "active")
+
+	if got := c.GetDowntime(); got < 9*time.Second || got > 11*time.Second {
+		t.Errorf("Expected downtime ~10s, got %v", got)
+	}
+	if got := c.GetUptimePercent(); got < 74 || got > 76 {
+		t.Errorf("Expected uptime ~75%%, got %f", got)
+	}
+
+	// The interval since an update in the error state is not counted either
+	c.UpdateStatus(http.StatusInternalServerError, ErrorSystemdState)
+	c.SetLastChecked(time.Now().Add(-60 * time.Second))
+	if got := c.GetUptimePercent(); got < 74 || got > 76 {
+		t.Errorf("Expected uptime ~75%% while in the error state, got %f", got)
+	}
+}
+
+// TestRestore verifies a restored status is served but marked stale, and
+// that the unobserved gap before the first live check is not counted
+// toward uptime or downtime.
+func TestRestore(t *testing.T) {
+	c := New()
+	checkedAt := time.Now().Add(-time.Hour)
+	c.Restore(http.StatusOK, "active", checkedAt)
+
+	if code, state := c.GetStatus(); code != http.StatusOK || state != "active" {
+		t.Errorf("Expected restored 200/active, got %d/%s", code, state)
+	}
+	if c.GetCacheState() != StateStale {
+		t.Errorf("Expected stale cache state, got %s", c.GetCacheState())
+	}
+	if !c.IsStale(30 * time.Second) {
+		t.Error("Expected restored data to be stale")
+	}
+	if got := c.GetUptimePercent(); got != 0 {
+		t.Errorf("Expected uptime 0 before the first live check, got %f", got)
+	}
+
+	if _, changed := c.UpdateStatus(http.StatusServiceUnavailable, "failed"); !changed {
+		t.Error("Expected a transition from the restored state")
+	}
+	if got := c.GetDowntime(); got != 0 {
+		t.Errorf("Expected the restored interval to be skipped, got downtime %v", got)
+	}
+	if c.GetCacheState() != StateRunning {
+		t.Errorf("Expected running after a live check, got %s", c.GetCacheState())
+	}
+}
+
+// TestRestoreAfterUpdate verifies a live status is never overwritten by a
+// saved one.
+func TestRestoreAfterUpdate(t *testing.T) {
+	c := New()
+	c.UpdateStatus(http.StatusServiceUnavailable, "failed")
+	c.Restore(http.StatusOK, "active", time.Now().Add(-time.Hour))
+
+	if _, state := c.GetStatus(); state != "failed" {
+		t.Errorf("Expected live state failed to be kept, got %q", state)
+	}
+}
+
+// TestResetStats verifies the accumulators and history are cleared while
+// the status is kept, and that only time after the reset is counted.
+func TestResetStats(t *testing.T) {
+	c := New()
+	c.UpdateStatus(http.StatusOK, "active")
+	c.SetLastChecked(time.Now().Add(-30 * time.Second))
+	c.UpdateStatus(http.StatusServiceUnavailable, "failed")
+
+	// 10s of the current failure happened before the reset
+	c.SetLastChecked(time.Now().Add(-10 * time.Second))
+	c.ResetStats()
+
+	if len(c.GetHistory()) != 0 {
+		t.Errorf("Expected empty history after reset, got %v", c.GetHistory())
+	}
+	if code, state := c.GetStatus(); code != http.StatusServiceUnavailable || state != "failed" {
+		t.Errorf("Expected status to be kept, got %d/%s", code, state)
+	}
+	if since := c.GetStatsSince(); time.Since(since) > time.Second {
+		t.Errorf("Expected stats since to be now, got %v", since)
+	}
+
+	c.UpdateStatus(http.StatusOK, "active")
+	if got := c.GetDowntime(); got > time.Second {
+		t.Errorf("Expected time before the reset to be excluded, got downtime %v", got)
+	}
+}
+
+// TestResetStatsBeforeFirstCheck verifies the stats period still starts at
+// the first check when reset before it.
+func TestResetStatsBeforeFirstCheck(t *testing.T) {
+	c := New()
+	c.ResetStats()
+
+	if !c.GetStatsSince().IsZero() {
+		t.Errorf("Expected zero stats since before the first check, got %v", c.GetStatsSince())
+	}
+}
+
+// -----------------------------------------------------------------------
+// History Tests
+// -----------------------------------------------------------------------
+
+// TestHistoryRecordsOnlyTransitions verifies repeated updates with the same
+// state do not fill the history. Recording every poll would evict real
+// transitions within seconds.
+func TestHistoryRecordsOnlyTransitions(t *testing.T) {
+	c := New()
+	c.UpdateStatus(http.StatusOK, "active")
+	c.UpdateStatus(http.StatusOK, "active")
+	c.UpdateStatus(http.StatusServiceUnavailable, "failed")
+	c.UpdateStatus(http.StatusServiceUnavailable, "failed")
+
+	history := c.GetHistory()
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 transitions, got %d: %+v", len(history), history)
+	}
+
+	if history[0].From != "uninitialized" || history[0].To != "active" {
+		t.Errorf("Unexpected first transition: %+v", history[0])
+	}
+	if history[1].From != "active" || history[1].To != "failed" {
+		t.Errorf("Unexpected second transition: %+v", history[1])
+	}
+}
+
+// TestHistoryRingBufferOverwritesOldest verifies the buffer keeps only the
+// most recent transitions in chronological order once full.
+func TestHistoryRingBufferOverwritesOldest(t *testing.T) {
+	c := NewWithHistorySize(3)
+
+	states := []string{"active", "failed", "activating", "active", "deactivating"}
+	for _, state := range states {
+		c.UpdateStatus(http.StatusOK, state)
+	}
+
+	history := c.GetHistory()
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 transitions, got %d", len(history))
+	}
+
+	want := []string{"activating", "active", "deactivating"}
+	for i, tr := range history {
+		if tr.To != want[i] {
+			t.Errorf("Index %d: expected transition to %q, got %q", i, want[i], tr.To)
+		}
+	}
+}
+
+// TestUpdateStatusReportsTransition verifies UpdateStatus reports a
+// transition only when the state changes, which the checker relies on to
+// send notifications once per change rather than once per poll.
+func TestUpdateStatusReportsTransition(t *testing.T) {
+	c := New()
+
+	if tr, changed := c.UpdateStatus(http.StatusOK, "active"); !changed || tr.From != UninitializedSystemdState {
+		t.Errorf("First update: expected transition from %q, got %+v (changed=%v)", UninitializedSystemdState, tr, changed)
+	}
+	if _, changed := c.UpdateStatus(http.StatusOK, "active"); changed {
+		t.Error("Repeated state should not report a transition")
+	}
+	if tr, changed := c.UpdateStatus(http.StatusServiceUnavailable, "failed"); !changed || tr.From != "active" || tr.To != "failed" {
+		t.Errorf("Expected active -> failed, got %+v (changed=%v)", tr, changed)
+	}
+}
+
+// TestRestarts verifies the NRestarts value is stored independently of the
+// health status.
+func TestRestarts(t *testing.T) {
+	c := New()
+	if got := c.GetRestarts(); got != 0 {
+		t.Errorf("Expected 0 restarts initially, got %d", got)
+	}
+
+	c.UpdateStatus(http.StatusOK, "active")
+	c.SetRestarts(40)
+
+	if got := c.GetRestarts(); got != 40 {
+		t.Errorf("Expected 40 restarts, got %d", got)
+	}
+	if code, state := c.GetStatus(); code != http.StatusOK || state != "active" {
+		t.Errorf("Restart count should not affect status, got %d/%s", code, state)
+	}
+}
+
+// TestActivity verifies SubState and the last active time are stored
+// without affecting the status.
+func TestActivity(t *testing.T) {
+	c := New()
+	if sub, last := c.GetActivity(); sub != "" || !last.IsZero() {
+		t.Errorf("Expected no activity initially, got %q/%v", sub, last)
+	}
+
+	c.UpdateStatus(http.StatusServiceUnavailable, "inactive")
+	lastActive := time.Now().Add(-time.Hour)
+	c.SetActivity("dead", lastActive)
+
+	if sub, last := c.GetActivity(); sub != "dead" || !last.Equal(lastActive) {
+		t.Errorf("Expected dead/%v, got %q/%v", lastActive, sub, last)
+	}
+	if code, state := c.GetStatus(); code != http.StatusServiceUnavailable || state != "inactive" {
+		t.Errorf("Activity should not affect status, got %d/%s", code, state)
+	}
+}
+
+// TestActiveTransitionsSince verifies only transitions into or out of
+// active are counted, and the startup transition is ignored.
+func TestActiveTransitionsSince(t *testing.T) {
+	c := New()
+	start := time.Now().Add(-time.Second)
+
+	c.UpdateStatus(200, "active")     // startup, ignored
+	c.UpdateStatus(503, "failed")     // counted
+	c.UpdateStatus(503, "activating") // failed -> activating, not counted
+	c.UpdateStatus(200, "active")     // counted
+
+	if got := c.ActiveTransitionsSince(start); got != 2 {
+		t.Errorf("Expected 2 transitions, got %d", got)
+	}
+	if got := c.ActiveTransitionsSince(time.Now().Add(time.Second)); got != 0 {
+		t.Errorf("Expected 0 transitions after the window, got %d", got)
+	}
+}
+
+// TestActiveTransitionsSinceIgnoresCheckerErrors verifies transitions into
+// and out of the error state are looked through, so a D-Bus hiccup on an
+// active unit does not count toward flapping.
+func TestActiveTransitionsSinceIgnoresCheckerErrors(t *testing.T) {
+	start := time.Now().Add(-time.Second)
+
+	c := New()
+	c.UpdateStatus(200, "active")          // startup, ignored
+	c.UpdateStatus(500, ErrorSystemdState) // ignored
+	c.UpdateStatus(200, "active")          // active -> error -> active, ignored
+	c.UpdateStatus(500, ErrorSystemdState) // ignored
+	c.UpdateStatus(503, "failed")          // active -> error -> failed, counted
+	c.UpdateStatus(500, ErrorSystemdState) // ignored
+	c.UpdateStatus(503, "activating")      // failed -> error -> activating, not counted
+	if got := c.ActiveTransitionsSince(start); got != 1 {
+		t.Errorf("Expected 1 transition, got %d", got)
+	}
+
+	// An error at startup has no known state before it
+	c = New()
+	c.UpdateStatus(500, ErrorSystemdState)
+	c.UpdateStatus(200, "active")
+	if got := c.ActiveTransitionsSince(start); got != 0 {
+		t.Errorf("Expected 0 transitions after a startup error, got %d", got)
+	}
+}
+
+// TestGetStateSince verifies the timestamp only moves on a transition, so
+// it measures how long the current state has persisted.
+func TestGetStateSince(t *testing.T) {
+	c := New()
+	if !c.GetStateSince().IsZero() {
+		t.Error("Expected zero time before first check")
+	}
+
+	c.UpdateStatus(503, "activating")
+	since := c.GetStateSince()
+	if since.IsZero() {
+		t.Fatal("Expected state time after first check")
+	}
+
+	time.Sleep(time.Millisecond)
+	c.UpdateStatus(503, "activating")
+	if !c.GetStateSince().Equal(since) {
+		t.Error("Expected state time to be unchanged without a transition")
+	}
+
+	c.UpdateStatus(200, "active")
+	if !c.GetStateSince().After(since) {
+		t.Error("Expected state time to advance on transition")
+	}
+}
+
+// TestFailedDependency verifies the first unhealthy dependency is reported
+// and that callers cannot mutate the stored dependencies.
+func TestFailedDependency(t *testing.T) {
+	c := New()
+	if _, failed := c.FailedDependency(); failed {
+		t.Error("Expected no failed dependency before any were recorded")
+	}
+
+	c.SetDependencies([]Dependency{
+		{Unit: "data.mount", State: "active", Healthy: true},
+		{Unit: "db.service", State: "failed", Healthy: false},
+	})
+
+	dep, failed := c.FailedDependency()
+	if !failed || dep.Unit != "db.service" {
+		t.Errorf("Expected db.service to be reported, got %+v (failed=%v)", dep, failed)
+	}
+
+	deps := c.GetDependencies()
+	deps[0].Healthy = false
+	if !c.GetDependencies()[0].Healthy {
+		t.Error("GetDependencies must return a copy")
+	}
+}
+
 // -----------------------------------------------------------------------
 // Concurrency Tests
 // -----------------------------------------------------------------------
@@ -0,0 +1,181 @@
+// -----------------------------------------------------------------------
+// Status Persistence
+// -----------------------------------------------------------------------
+//
+// A registry can save the last known status of each service to a small
+// JSON file and load it back at startup. Without it, a restarted health
+// checker answers 503 until its first check, which makes load balancers
+// flap during a fast restart loop. Loaded status is marked stale, so
+// consumers still see how old it is.
+//
+// Writes are debounced: a burst of updates (one per service per check
+// interval) results in a single write shortly after.
+//
+// -----------------------------------------------------------------------
+
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var logc = slog.Default().With("component", "cache")
+
+// persistDelay is how long writes are held back to coalesce updates.
+const persistDelay = time.Second
+
+// persistedStatus is the saved status of one service.
+type persistedStatus struct {
+	StatusCode  int       `json:"status_code"`
+	State       string    `json:"state"`
+	LastChecked time.Time `json:"last_checked"`
+}
+
+// stateFile writes registry snapshots to path, at most once per delay.
+type stateFile struct {
+	path     string
+	delay    time.Duration
+	snapshot func() map[string]persistedStatus
+
+	mu      sync.Mutex
+	pending bool
+}
+
+// schedule arranges for the state to be written after the debounce delay,
+// unless a write is already pending.
+func (s *stateFile) schedule() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pending {
+		return
+	}
+	s.pending = true
+	time.AfterFunc(s.delay, func() {
+		s.mu.Lock()
+		s.pending = false
+		s.mu.Unlock()
+
+		if err := s.write(); err != nil {
+			logc.Warn("failed to write state file", "path", s.path, "err", err)
+		}
+	})
+}
+
+// write saves the current snapshot. The file is written to a temporary
+// name and renamed, so a crash mid-write never leaves a truncated file.
+func (s *stateFile) write() error {
+	data, err := json.Marshal(s.snapshot())
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// readStateFile loads saved statuses from path. A missing file yields an
+// empty map and no error.
+func readStateFile(path string) (map[string]persistedStatus, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var saved map[string]persistedStatus
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("corrupt state file: %w", err)
+	}
+	return saved, nil
+}
+
+// -----------------------------------------------------------------------
+// Registry Integration
+// -----------------------------------------------------------------------
+
+// PersistTo enables status persistence to path. Statuses saved there by a
+// previous process are restored into caches that have not been checked
+// yet, and every later update is written back (debounced). Call before the
+// checkers start.
+//
+// Persistence is enabled even when loading fails; the returned error
+// describes why nothing was restored (e.g. a corrupt file), and the caches
+// stay uninitialized.
+func (r *Registry) PersistTo(path string) error {
+	state := &stateFile{path: path, delay: persistDelay, snapshot: r.persistedStatuses}
+
+	r.mu.Lock()
+	r.state = state
+	caches := make(map[string]*ServiceCache, len(r.caches))
+	for name, c := range r.caches {
+		caches[name] = c
+	}
+	r.mu.Unlock()
+
+	for _, c := range caches {
+		c.setOnUpdate(state.schedule)
+	}
+
+	saved, err := readStateFile(path)
+	if err != nil {
+		return err
+	}
+	for name, s := range saved {
+		c, ok := caches[name]
+		if !ok || s.State == "" || s.LastChecked.IsZero() {
+			continue
+		}
+		c.Restore(s.StatusCode, s.State, s.LastChecked)
+	}
+	return nil
+}
+
+// SaveState writes the state file immediately, e.g. at shutdown so a
+// pending debounced write is not lost. Does nothing when persistence is
+// not enabled.
+func (r *Registry) SaveState() error {
+	r.mu.RLock()
+	state := r.state
+	r.mu.RUnlock()
+
+	if state == nil {
+		return nil
+	}
+	return state.write()
+}
+
+// persistedStatuses snapshots the status of every service that has one.
+func (r *Registry) persistedStatuses() map[string]persistedStatus {
+	out := make(map[string]persistedStatus)
+	for name, c := range r.Snapshot() {
+		lastChecked := c.GetLastChecked()
+		if lastChecked.IsZero() {
+			continue
+		}
+		code, state := c.GetStatus()
+		out[name] = persistedStatus{StatusCode: code, State: state, LastChecked: lastChecked}
+	}
+	return out
+}
@@ -0,0 +1,116 @@
+// -----------------------------------------------------------------------
+// Status Persistence - Tests
+// -----------------------------------------------------------------------
+//
+// Validates that a registry restores the status saved by a previous
+// process, writes updates back, and starts uninitialized when the state
+// file is missing or corrupt.
+//
+// -----------------------------------------------------------------------
+
+package cache
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestPersistTo_RoundTrip verifies a status saved by one registry is
+// restored, marked stale, by the next.
+func TestPersistTo_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	first := NewRegistry([]string{"nginx", "postgres"}, 0)
+	if err := first.PersistTo(path); err != nil {
+		t.Fatalf("PersistTo: %v", err)
+	}
+	nginx, _ := first.Get("nginx")
+	nginx.UpdateStatus(http.StatusOK, "active")
+	if err := first.SaveState(); err != nil {
+		t.Fatalf("SaveState: %v", err)
+	}
+
+	second := NewRegistry([]string{"nginx", "postgres"}, 0)
+	if err := second.PersistTo(path); err != nil {
+		t.Fatalf("PersistTo: %v", err)
+	}
+
+	restored, _ := second.Get("nginx")
+	if code, state := restored.GetStatus(); code != http.StatusOK || state != "active" {
+		t.Errorf("Expected restored 200/active, got %d/%s", code, state)
+	}
+	if restored.GetCacheState() != StateStale {
+		t.Errorf("Expected restored cache to be stale, got %s", restored.GetCacheState())
+	}
+
+	// postgres was never checked, so nothing was saved for it
+	postgres, _ := second.Get("postgres")
+	if postgres.GetCacheState() != StateUninitialized {
+		t.Errorf("Expected unchecked service to stay uninitialized, got %s", postgres.GetCacheState())
+	}
+}
+
+// TestPersistTo_WritesOnUpdate verifies updates are written to the state
+// file after the debounce delay, including for services added by a reload.
+func TestPersistTo_WritesOnUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	r := NewRegistry([]string{"nginx"}, 0)
+	if err := r.PersistTo(path); err != nil {
+		t.Fatalf("PersistTo: %v", err)
+	}
+	r.state.delay = 10 * time.Millisecond
+
+	r.Sync([]string{"nginx", "redis"})
+	redis, _ := r.Get("redis")
+	redis.UpdateStatus(http.StatusServiceUnavailable, "failed")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		saved, err := readStateFile(path)
+		if err == nil && saved["redis"].State == "failed" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected redis to be saved as failed, got %v (err=%v)", saved, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestPersistTo_BadFile verifies a missing file is not an error and a
+// corrupt one is reported, with the caches left uninitialized either way.
+func TestPersistTo_BadFile(t *testing.T) {
+	dir := t.TempDir()
+	corrupt := filepath.Join(dir, "corrupt.json")
+	if err := os.WriteFile(corrupt, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{"missing", filepath.Join(dir, "missing.json"), false},
+		{"corrupt", corrupt, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry([]string{"nginx"}, 0)
+			err := r.PersistTo(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PersistTo error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			c, _ := r.Get("nginx")
+			if c.GetCacheState() != StateUninitialized {
+				t.Errorf("Expected uninitialized cache, got %s", c.GetCacheState())
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// -----------------------------------------------------------------------
+// Aggregate Health Policy
+// -----------------------------------------------------------------------
+//
+// AggregatePolicy decides whether a set of monitored services counts as
+// healthy as a whole, for the aggregate /health endpoint. The default
+// requires every service to be healthy; "any" and "quorum:N" suit
+// replicated services where losing one member is tolerable.
+//
+// -----------------------------------------------------------------------
+
+package cache
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PolicyMode selects how an AggregatePolicy counts healthy services.
+type PolicyMode int
+
+const (
+	// PolicyAll requires every service to be healthy.
+	PolicyAll PolicyMode = iota
+
+	// PolicyAny requires at least one healthy service.
+	PolicyAny
+
+	// PolicyQuorum requires at least Quorum healthy services.
+	PolicyQuorum
+)
+
+// AggregatePolicy is a parsed aggregation policy. The zero value is
+// PolicyAll.
+type AggregatePolicy struct {
+	Mode   PolicyMode
+	Quorum int
+}
+
+// ParseAggregatePolicy parses "all", "any" or "quorum:N" with N >= 1. An
+// empty string selects "all".
+func ParseAggregatePolicy(s string) (AggregatePolicy, error) {
+	switch s {
+	case "", "all":
+		return AggregatePolicy{Mode: PolicyAll}, nil
+	case "any":
+		return AggregatePolicy{Mode: PolicyAny}, nil
+	}
+
+	n, ok := strings.CutPrefix(s, "quorum:")
+	if !ok {
+		return AggregatePolicy{}, fmt.Errorf("unknown aggregate policy %q (want all, any or quorum:N)", s)
+	}
+	quorum, err := strconv.Atoi(n)
+	if err != nil || quorum < 1 {
+		return AggregatePolicy{}, fmt.Errorf("invalid quorum %q in aggregate policy (want a positive integer)", n)
+	}
+	return AggregatePolicy{Mode: PolicyQuorum, Quorum: quorum}, nil
+}
+
+// String returns the policy in the form accepted by ParseAggregatePolicy.
+func (p AggregatePolicy) String() string {
+	switch p.Mode {
+	case PolicyAny:
+		return "any"
+	case PolicyQuorum:
+		return "quorum:" + strconv.Itoa(p.Quorum)
+	default:
+		return "all"
+	}
+}
+
+// Satisfied reports whether healthy out of total services meets the
+// policy. No services never satisfies it, since nothing can be vouched for.
+func (p AggregatePolicy) Satisfied(healthy, total int) bool {
+	if total == 0 {
+		return false
+	}
+	switch p.Mode {
+	case PolicyAny:
+		return healthy >= 1
+	case PolicyQuorum:
+		return healthy >= p.Quorum
+	default:
+		return healthy == total
+	}
+}
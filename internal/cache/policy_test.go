@@ -0,0 +1,70 @@
+// -----------------------------------------------------------------------
+// Aggregate Health Policy - Tests
+// -----------------------------------------------------------------------
+//
+// Validates policy parsing and how many healthy services each policy
+// requires.
+//
+// -----------------------------------------------------------------------
+
+package cache
+
+import "testing"
+
+// TestParseAggregatePolicy verifies accepted spellings round-trip through
+// String and malformed policies are rejected.
+func TestParseAggregatePolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"", "all", false},
+		{"all", "all", false},
+		{"any", "any", false},
+		{"quorum:2", "quorum:2", false},
+		{"quorum:0", "", true},
+		{"quorum:two", "", true},
+		{"quorum", "", true},
+		{"majority", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			p, err := ParseAggregatePolicy(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAggregatePolicy(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if err == nil && p.String() != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, p.String())
+			}
+		})
+	}
+}
+
+// TestAggregatePolicySatisfied verifies each policy against healthy counts,
+// and that no services never satisfies a policy.
+func TestAggregatePolicySatisfied(t *testing.T) {
+	tests := []struct {
+		name           string
+		policy         AggregatePolicy
+		healthy, total int
+		want           bool
+	}{
+		{"all healthy", AggregatePolicy{}, 3, 3, true},
+		{"all with one down", AggregatePolicy{}, 2, 3, false},
+		{"any with one up", AggregatePolicy{Mode: PolicyAny}, 1, 3, true},
+		{"any with none up", AggregatePolicy{Mode: PolicyAny}, 0, 3, false},
+		{"quorum met", AggregatePolicy{Mode: PolicyQuorum, Quorum: 2}, 2, 3, true},
+		{"quorum lost", AggregatePolicy{Mode: PolicyQuorum, Quorum: 2}, 1, 3, false},
+		{"no services", AggregatePolicy{Mode: PolicyAny}, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Satisfied(tt.healthy, tt.total); got != tt.want {
+				t.Errorf("Satisfied(%d, %d) = %v, want %v", tt.healthy, tt.total, got, tt.want)
+			}
+		})
+	}
+}
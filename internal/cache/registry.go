@@ -0,0 +1,111 @@
+// -----------------------------------------------------------------------
+// Service Cache Registry
+// -----------------------------------------------------------------------
+//
+// Registry tracks one ServiceCache per monitored service. The set of
+// services can change at runtime (config reload), so lookups are guarded
+// by an RWMutex and handlers work from point-in-time snapshots.
+// The first configured service is the primary, used by single-service
+// endpoints such as /api/status.
+//
+// -----------------------------------------------------------------------
+
+package cache
+
+import "sync"
+
+// Registry maps monitored service names to their caches, preserving
+// configuration order.
+type Registry struct {
+	mu          sync.RWMutex
+	names       []string
+	caches      map[string]*ServiceCache
+	historySize int
+
+	// state is set by PersistTo when status persistence is enabled.
+	state *stateFile
+}
+
+// NewRegistry creates a registry with a cache for each named service. Each
+// cache retains historySize transitions.
+func NewRegistry(names []string, historySize int) *Registry {
+	r := &Registry{
+		caches:      make(map[string]*ServiceCache),
+		historySize: historySize,
+	}
+	r.Sync(names)
+	return r
+}
+
+// Get returns the cache for the named service.
+func (r *Registry) Get(name string) (StatusStore, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.caches[name]
+	if !ok {
+		return nil, false
+	}
+	return c, true
+}
+
+// Primary returns the first configured service and its cache. Returns an
+// empty name and nil cache if no services are registered.
+func (r *Registry) Primary() (string, StatusStore) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.names) == 0 {
+		return "", nil
+	}
+	return r.names[0], r.caches[r.names[0]]
+}
+
+// Names returns the registered service names in configuration order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string(nil), r.names...)
+}
+
+// Snapshot returns a copy of the name-to-cache map. The caches themselves
+// are shared, but the map is safe to iterate while the registry changes.
+func (r *Registry) Snapshot() map[string]StatusStore {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make(map[string]StatusStore, len(r.caches))
+	for name, c := range r.caches {
+		out[name] = c
+	}
+	return out
+}
+
+// Sync replaces the registered service set with names. Caches for services
+// that remain are kept (preserving status and history), new services get a
+// fresh cache, and services no longer listed are dropped. Returns the names
+// that were added and removed.
+func (r *Registry) Sync(names []string) (added, removed []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	want := make(map[string]bool, len(names))
+	for _, name := range names {
+		want[name] = true
+		if _, ok := r.caches[name]; !ok {
+			c := NewWithHistorySize(r.historySize)
+			if r.state != nil {
+				c.onUpdate = r.state.schedule
+			}
+			r.caches[name] = c
+			added = append(added, name)
+		}
+	}
+
+	for _, name := range r.names {
+		if !want[name] {
+			delete(r.caches, name)
+			removed = append(removed, name)
+		}
+	}
+
+	r.names = append([]string(nil), names...)
+	return added, removed
+}
@@ -0,0 +1,64 @@
+// -----------------------------------------------------------------------
+// Service Cache Registry - Tests
+// -----------------------------------------------------------------------
+//
+// Validates that the registry keeps caches for services that survive a
+// config reload and reports which services were added and removed, since
+// the checker supervisor starts and stops goroutines from that result.
+//
+// -----------------------------------------------------------------------
+
+package cache
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// TestRegistry_Primary verifies the first configured service is treated as
+// the primary, which backs the single-service status endpoints.
+func TestRegistry_Primary(t *testing.T) {
+	r := NewRegistry([]string{"nginx", "postgres"}, 0)
+
+	name, c := r.Primary()
+	if name != "nginx" || c == nil {
+		t.Errorf("Expected primary nginx with cache, got %q (nil=%v)", name, c == nil)
+	}
+
+	if got := r.Names(); !reflect.DeepEqual(got, []string{"nginx", "postgres"}) {
+		t.Errorf("Expected names in config order, got %v", got)
+	}
+}
+
+// TestRegistry_SyncPreservesExisting verifies that a reload keeps the cache
+// (and its status) for services still being monitored, creates caches for
+// new services, and drops removed ones.
+func TestRegistry_SyncPreservesExisting(t *testing.T) {
+	r := NewRegistry([]string{"nginx", "postgres"}, 0)
+
+	nginx, _ := r.Get("nginx")
+	nginx.UpdateStatus(http.StatusOK, "active")
+
+	added, removed := r.Sync([]string{"nginx", "redis"})
+
+	if !reflect.DeepEqual(added, []string{"redis"}) {
+		t.Errorf("Expected added [redis], got %v", added)
+	}
+	if !reflect.DeepEqual(removed, []string{"postgres"}) {
+		t.Errorf("Expected removed [postgres], got %v", removed)
+	}
+
+	if c, ok := r.Get("nginx"); !ok || c != nginx {
+		t.Error("Existing cache should be preserved across sync")
+	}
+	if _, state := nginx.GetStatus(); state != "active" {
+		t.Errorf("Expected preserved state active, got %q", state)
+	}
+	if _, ok := r.Get("postgres"); ok {
+		t.Error("Removed service should no longer be registered")
+	}
+	if len(r.Snapshot()) != 2 {
+		t.Errorf("Expected 2 caches in snapshot, got %d", len(r.Snapshot()))
+	}
+}
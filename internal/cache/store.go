@@ -0,0 +1,81 @@
+// -----------------------------------------------------------------------
+// Status Store Interface
+// -----------------------------------------------------------------------
+//
+// StatusStore is the contract between the checker, which writes observed
+// state, and the HTTP handlers, which read it. ServiceCache is the
+// in-memory implementation; the interface leaves room for a shared
+// backend (such as Redis) and lets tests substitute simple fakes.
+//
+// -----------------------------------------------------------------------
+
+package cache
+
+import "time"
+
+// StatusStore holds the observed health of one monitored unit.
+// Implementations must be safe for concurrent use by the checker and any
+// number of request handlers.
+type StatusStore interface {
+	// GetStatus returns the HTTP status code and systemd state.
+	GetStatus() (int, string)
+
+	// GetLastChecked returns when the status was last updated.
+	GetLastChecked() time.Time
+
+	// GetStateSince returns when the current state was first observed.
+	GetStateSince() time.Time
+
+	// GetCacheState returns the lifecycle state of the stored data.
+	// IsError reports the StateError state, and IsUninitialized whether
+	// the status has never been updated.
+	GetCacheState() StateType
+	IsError() bool
+	IsUninitialized() bool
+
+	// IsStale reports whether the last update is older than maxAge, and
+	// GetStaleness returns its age.
+	IsStale(maxAge time.Duration) bool
+	GetStaleness() time.Duration
+
+	// GetUptimePercent and GetDowntime summarize observed availability
+	// since GetStatsSince; ResetStats restarts them from now.
+	GetUptimePercent() float64
+	GetDowntime() time.Duration
+	GetStatsSince() time.Time
+	ResetStats()
+
+	// GetRestarts returns systemd's NRestarts for the unit.
+	GetRestarts() uint32
+
+	// GetActivity returns the unit's SubState and when it last became
+	// active; the zero time means it has not run since boot.
+	GetActivity() (string, time.Time)
+
+	// GetDependencies returns dependency states and FailedDependency the
+	// first unhealthy one, when dependency checks are enabled.
+	GetDependencies() []Dependency
+	FailedDependency() (Dependency, bool)
+
+	// GetHistory returns recent state transitions, oldest first, and
+	// ActiveTransitionsSince counts those crossing the active boundary.
+	GetHistory() []Transition
+	ActiveTransitionsSince(since time.Time) int
+
+	// IsFlapping reports whether the checker marked the unit as flapping.
+	IsFlapping() bool
+
+	// UpdateStatus records a check result, returning the transition when
+	// the state changed.
+	UpdateStatus(code int, state string) (Transition, bool)
+
+	// SetRestarts, SetActivity, SetDependencies and SetFlapping record the
+	// checker's secondary observations.
+	SetRestarts(n uint32)
+	SetActivity(subState string, lastActive time.Time)
+	SetDependencies(deps []Dependency)
+	SetFlapping(flapping bool)
+}
+
+// ServiceCache is the in-memory StatusStore.
+var _ StatusStore = (*ServiceCache)(nil)
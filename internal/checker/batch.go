@@ -0,0 +1,412 @@
+// -----------------------------------------------------------------------
+// Batched D-Bus Checks
+// -----------------------------------------------------------------------
+//
+// Checking a unit on its own costs several D-Bus round-trips, so
+// monitoring hundreds of units costs hundreds of calls per interval. A
+// Batch instead reads LoadState, ActiveState and SubState of every
+// registered unit with one ListUnitsByNames call and shares the result
+// with every checker that asks within maxAge of it. Checkers keep their own
+// schedules; the first one to ask after the result expires refreshes it
+// for all of them.
+//
+// Properties that ListUnitsByNames does not return (NRestarts,
+// ActiveEnterTimestamp, dependencies) are still read per unit. systemd
+// versions without ListUnitsByNames (before v230) fall back to per-unit
+// checks.
+//
+// D-Bus calls run outside the Batch's lock, one fetch at a time, so a slow
+// call never blocks Close or checkers that can use the current result. A
+// replaced connection is closed only once no checker is reading per-unit
+// properties over it.
+//
+// -----------------------------------------------------------------------
+
+package checker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/afreidah/health-check-service/internal/cache"
+	"github.com/afreidah/health-check-service/internal/metrics"
+	"github.com/afreidah/health-check-service/internal/tracing"
+	"github.com/coreos/go-systemd/v22/dbus"
+	godbus "github.com/godbus/dbus/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// errBatchUnsupported reports that systemd has no ListUnitsByNames method.
+var errBatchUnsupported = errors.New("systemd does not support ListUnitsByNames")
+
+// Batch fetches the status of all registered units in one D-Bus call and
+// shares it between their checkers. It is safe for concurrent use.
+type Batch struct {
+	scope string
+
+	mu          sync.Mutex
+	maxAge      time.Duration
+	conn        *batchConn
+	connectedAt time.Time
+	fetching    chan struct{}
+	closing     bool
+	services    map[string]int
+	statuses    map[string]dbus.UnitStatus
+	err         error
+	fetched     time.Time
+	attempts    int
+	unsupported bool
+
+	// list replaces ListUnitsByNames on conn; used by tests
+	list func(ctx context.Context, units []string) ([]dbus.UnitStatus, error)
+}
+
+// batchConn is a D-Bus connection shared by a Batch and the checkers using
+// it. Fields are guarded by the Batch's mutex.
+type batchConn struct {
+	conn    *dbus.Conn
+	users   int
+	retired bool
+}
+
+// NewBatch returns a Batch on the given D-Bus scope. conn is the initial
+// connection (nil connects on the first fetch); the Batch owns it. A
+// result is reused for maxAge, typically half the check interval so each
+// interval costs about two calls.
+func NewBatch(scope string, conn *dbus.Conn, maxAge time.Duration) *Batch {
+	b := &Batch{
+		scope:       scope,
+		maxAge:      maxAge,
+		connectedAt: time.Now(),
+		services:    make(map[string]int),
+	}
+	if conn != nil {
+		b.conn = &batchConn{conn: conn}
+	}
+	return b
+}
+
+// SetMaxAge changes how long a result is reused, e.g. after the check
+// interval is reloaded.
+func (b *Batch) SetMaxAge(maxAge time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.maxAge = maxAge
+}
+
+// Close closes the Batch's D-Bus connection once checkers still using it
+// are done. A later fetch reconnects.
+func (b *Batch) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.retireConn()
+	// A connection opened by a fetch in flight is retired when it ends
+	b.closing = b.fetching != nil
+}
+
+// acquireConn returns the current connection, held open until release.
+// Returns nil when there is none. Callers must hold b.mu.
+func (b *Batch) acquireConn() *batchConn {
+	if b.conn != nil {
+		b.conn.users++
+	}
+	return b.conn
+}
+
+// release ends a use of conn from status, closing it if it has been
+// replaced and this was its last user. conn may be nil.
+func (b *Batch) release(conn *batchConn) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.releaseLocked(conn)
+}
+
+// releaseLocked is release for callers holding b.mu.
+func (b *Batch) releaseLocked(conn *batchConn) {
+	if conn == nil {
+		return
+	}
+	conn.users--
+	if conn.retired && conn.users == 0 {
+		conn.conn.Close()
+	}
+}
+
+// retireConn stops handing out the current connection and closes it once
+// it has no users. Callers must hold b.mu.
+func (b *Batch) retireConn() {
+	if b.conn == nil {
+		return
+	}
+	b.conn.retired = true
+	if b.conn.users == 0 {
+		b.conn.conn.Close()
+	}
+	b.conn = nil
+}
+
+// register adds service to the units fetched by each call.
+func (b *Batch) register(service string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.services[service]++
+}
+
+// unregister removes service once its last checker has stopped.
+func (b *Batch) unregister(service string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.services[service]--; b.services[service] <= 0 {
+		delete(b.services, service)
+	}
+}
+
+// status returns the status of service from a result at most maxAge old,
+// fetching a new one first if needed, together with the connection to use
+// for per-unit properties. The connection stays open until passed to
+// release, even if a later fetch replaces it. A failed fetch is also
+// reused for maxAge, which paces reconnection attempts. Checkers that find
+// a fetch in flight wait for its result rather than starting another.
+// Returns errBatchUnsupported once systemd is known to lack
+// ListUnitsByNames.
+func (b *Batch) status(ctx context.Context, service string) (dbus.UnitStatus, *batchConn, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for !b.unsupported {
+		_, included := b.statuses[service]
+		if time.Since(b.fetched) < b.maxAge && (b.err != nil || included) {
+			break
+		}
+		if b.fetching == nil {
+			b.fetch(ctx, service)
+			break
+		}
+
+		inFlight := b.fetching
+		b.mu.Unlock()
+		select {
+		case <-inFlight:
+		case <-ctx.Done():
+			b.mu.Lock()
+			return dbus.UnitStatus{}, nil, ctx.Err()
+		}
+		b.mu.Lock()
+	}
+
+	if b.unsupported {
+		return dbus.UnitStatus{}, nil, errBatchUnsupported
+	}
+	if b.err != nil {
+		return dbus.UnitStatus{}, nil, b.err
+	}
+	status, ok := b.statuses[service]
+	if !ok {
+		return dbus.UnitStatus{}, nil, fmt.Errorf("ListUnitsByNames returned no status for %s", UnitName(service))
+	}
+	return status, b.acquireConn(), nil
+}
+
+// fetch replaces the shared result with a new ListUnitsByNames call,
+// connecting first if needed or if the connection is due for a proactive
+// reconnect. service is the checker that triggered the fetch, used to
+// label metrics. Callers must hold b.mu; it is released during D-Bus
+// calls, with b.fetching set so no other fetch starts meanwhile.
+func (b *Batch) fetch(ctx context.Context, service string) {
+	done := make(chan struct{})
+	b.fetching = done
+	defer func() {
+		if b.closing {
+			b.retireConn()
+			b.closing = false
+		}
+		b.fetching = nil
+		close(done)
+	}()
+
+	if b.conn != nil && reconnectDue(b.connectedAt) {
+		logc.Info("proactively reconnecting to D-Bus",
+			"connection_age", time.Since(b.connectedAt).Round(time.Second).String())
+		b.retireConn()
+	}
+
+	reconnected := false
+	if b.conn == nil && b.list == nil {
+		if maxReconnectAttempts > 0 && b.attempts >= maxReconnectAttempts {
+			logc.Error("giving up on D-Bus reconnection",
+				"attempts", b.attempts,
+				"max_reconnect_attempts", maxReconnectAttempts)
+			b.fetched = time.Now()
+			b.err = ErrReconnectLimit
+			return
+		}
+		b.attempts++
+		metrics.DBusReconnects.WithLabelValues(service).Inc()
+
+		b.mu.Unlock()
+		conn, err := Connect(ctx, b.scope)
+		b.mu.Lock()
+		if err != nil {
+			b.fetched = time.Now()
+			b.err = fmt.Errorf("failed to connect to D-Bus: %w", err)
+			return
+		}
+		b.conn = &batchConn{conn: conn}
+		b.connectedAt = time.Now()
+		reconnected = true
+	}
+
+	byUnit := make(map[string]string, len(b.services))
+	units := make([]string, 0, len(b.services))
+	for name := range b.services {
+		byUnit[UnitName(name)] = name
+		units = append(units, UnitName(name))
+	}
+
+	conn := b.acquireConn()
+	b.mu.Unlock()
+	statuses, err := b.listUnits(ctx, service, conn, units)
+	b.mu.Lock()
+	b.releaseLocked(conn)
+	b.fetched = time.Now()
+
+	if isUnknownMethod(err) {
+		logc.Warn("ListUnitsByNames is not supported by systemd; falling back to per-unit checks",
+			"error", err.Error())
+		b.unsupported = true
+		b.err = errBatchUnsupported
+		return
+	}
+	if err != nil {
+		if conn != nil && b.conn == conn {
+			b.retireConn()
+		}
+		b.err = err
+		return
+	}
+
+	if reconnected {
+		metrics.DBusReconnectSuccess.WithLabelValues(service).Inc()
+	}
+	b.attempts = 0
+	b.err = nil
+	b.statuses = make(map[string]dbus.UnitStatus, len(statuses))
+	for _, status := range statuses {
+		if name, ok := byUnit[status.Name]; ok {
+			b.statuses[name] = status
+		}
+	}
+}
+
+// listUnits calls ListUnitsByNames inside a span and records its latency
+// under the service that triggered the fetch.
+func (b *Batch) listUnits(ctx context.Context, service string, conn *batchConn, units []string) ([]dbus.UnitStatus, error) {
+	ctx, span := tracing.Start(ctx, "ListUnitsByNamesContext",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int("systemd.units", len(units))))
+	defer span.End()
+
+	list := b.list
+	if list == nil {
+		list = conn.conn.ListUnitsByNamesContext
+	}
+
+	start := time.Now()
+	statuses, err := list(ctx, units)
+	metrics.DBusQueryDuration.WithLabelValues(service).Observe(time.Since(start).Seconds())
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "D-Bus query failed")
+	}
+	return statuses, err
+}
+
+// isUnknownMethod reports whether err is D-Bus's reply to a method the
+// peer does not implement.
+func isUnknownMethod(err error) bool {
+	var dbusErr godbus.Error
+	return errors.As(err, &dbusErr) && dbusErr.Name == "org.freedesktop.DBus.Error.UnknownMethod"
+}
+
+// -----------------------------------------------------------------------
+// Batch Prober
+// -----------------------------------------------------------------------
+
+// batchProber checks a systemd unit through a shared Batch, falling back
+// to a per-unit dbusProber when batching is unsupported.
+type batchProber struct {
+	batch    *Batch
+	service  string
+	fallback *dbusProber
+}
+
+// NewBatchProber returns a prober that checks service through batch. The
+// service is fetched by every batch call until the prober is closed.
+func NewBatchProber(batch *Batch, service string) Prober {
+	batch.register(service)
+	return &batchProber{
+		batch:    batch,
+		service:  service,
+		fallback: &dbusProber{scope: batch.scope},
+	}
+}
+
+// Check records the unit's status from the batch.
+func (p *batchProber) Check(ctx context.Context, service string, c cache.StatusStore) error {
+	err := p.checkBatched(ctx, service, c)
+	if errors.Is(err, errBatchUnsupported) {
+		return p.fallback.Check(ctx, service, c)
+	}
+	return err
+}
+
+// checkBatched holds a check slot while reading the batch and the unit's
+// remaining properties.
+func (p *batchProber) checkBatched(ctx context.Context, service string, c cache.StatusStore) error {
+	if err := acquireCheckSlot(ctx); err != nil {
+		repeatedErrors.Log(ctx, logc, slog.LevelWarn, service,
+			"no free check slot; skipping check", "service", service, "error", err.Error())
+		return fmt.Errorf("waiting for a check slot: %w", err)
+	}
+	defer releaseCheckSlot()
+
+	status, shared, err := p.batch.status(ctx, service)
+	if errors.Is(err, errBatchUnsupported) || errors.Is(err, ErrReconnectLimit) {
+		return err
+	}
+	if err != nil {
+		repeatedErrors.Log(ctx, logc, slog.LevelError, service,
+			"error checking service via D-Bus",
+			"service", service,
+			"error", err.Error(),
+			"context_err", ctx.Err())
+
+		updateCache(service, c, http.StatusInternalServerError, StateError)
+		metrics.CheckFailures.WithLabelValues(service, "dbus_error").Inc()
+		return err
+	}
+
+	// Hold the connection until the per-unit properties have been read, so
+	// a reconnect by another checker cannot close it underneath us
+	defer p.batch.release(shared)
+	var conn *dbus.Conn
+	if shared != nil {
+		conn = shared.conn
+	}
+	recordUnitStatus(ctx, conn, service, status, c)
+	return nil
+}
+
+// Close removes the service from the batch and closes the fallback
+// connection, if any.
+func (p *batchProber) Close() {
+	p.batch.unregister(p.service)
+	p.fallback.Close()
+}
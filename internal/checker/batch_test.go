@@ -0,0 +1,246 @@
+// -----------------------------------------------------------------------
+// Batched D-Bus Checks - Tests
+// -----------------------------------------------------------------------
+//
+// Validates that a Batch shares one ListUnitsByNames result between
+// checkers, refreshes it when it expires or misses a unit, reports
+// systemd versions without the method so checkers fall back, handles
+// templated unit names, and makes one call at a time without holding its
+// lock during the call.
+//
+// -----------------------------------------------------------------------
+
+package checker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/afreidah/health-check-service/internal/cache"
+	"github.com/afreidah/health-check-service/internal/metrics"
+	"github.com/coreos/go-systemd/v22/dbus"
+	godbus "github.com/godbus/dbus/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// fakeBatch returns a Batch whose ListUnitsByNames reports every unit
+// active and counts the calls.
+func fakeBatch(maxAge time.Duration, calls *int) *Batch {
+	b := NewBatch(DBusScopeSystem, nil, maxAge)
+	b.list = func(_ context.Context, units []string) ([]dbus.UnitStatus, error) {
+		*calls++
+		statuses := make([]dbus.UnitStatus, 0, len(units))
+		for _, unit := range units {
+			statuses = append(statuses, dbus.UnitStatus{
+				Name: unit, LoadState: "loaded", ActiveState: StateActive, SubState: "running",
+			})
+		}
+		return statuses, nil
+	}
+	return b
+}
+
+// TestBatchSharesResult verifies checkers asking within maxAge share one
+// call, and that an expired result or a newly registered unit is fetched
+// again.
+func TestBatchSharesResult(t *testing.T) {
+	calls := 0
+	b := fakeBatch(time.Hour, &calls)
+	b.register("nginx")
+	b.register("redis")
+
+	for _, service := range []string{"nginx", "redis", "nginx"} {
+		status, _, err := b.status(context.Background(), service)
+		if err != nil {
+			t.Fatalf("status(%s) error = %v", service, err)
+		}
+		if status.Name != UnitName(service) || status.SubState != "running" {
+			t.Errorf("status(%s) = %+v", service, status)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected 1 call for 3 checks, got %d", calls)
+	}
+
+	// A unit missing from the current result triggers a fetch
+	b.register("postgresql")
+	if _, _, err := b.status(context.Background(), "postgresql"); err != nil {
+		t.Fatalf("status(postgresql) error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected a fetch for the new unit, got %d calls", calls)
+	}
+
+	// An expired result is fetched again
+	b.SetMaxAge(0)
+	if _, _, err := b.status(context.Background(), "nginx"); err != nil {
+		t.Fatalf("status(nginx) error = %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected a fetch after expiry, got %d calls", calls)
+	}
+}
+
+// TestBatchUnregister verifies a unit is dropped from the batch only when
+// its last prober is closed.
+func TestBatchUnregister(t *testing.T) {
+	calls := 0
+	b := fakeBatch(0, &calls)
+	b.register("nginx")
+	b.register("nginx")
+
+	b.unregister("nginx")
+	if _, _, err := b.status(context.Background(), "nginx"); err != nil {
+		t.Errorf("Expected nginx to stay registered, got %v", err)
+	}
+
+	b.unregister("nginx")
+	if _, _, err := b.status(context.Background(), "nginx"); err == nil {
+		t.Error("Expected an error for an unregistered unit")
+	}
+}
+
+// TestBatchErrors verifies a failed call is reported to every checker
+// until it expires, and that a missing method is reported permanently.
+func TestBatchErrors(t *testing.T) {
+	b := NewBatch(DBusScopeSystem, nil, time.Hour)
+	b.register("nginx")
+
+	calls := 0
+	failure := errors.New("connection reset")
+	b.list = func(context.Context, []string) ([]dbus.UnitStatus, error) {
+		calls++
+		return nil, failure
+	}
+
+	for range 2 {
+		if _, _, err := b.status(context.Background(), "nginx"); !errors.Is(err, failure) {
+			t.Errorf("Expected %v, got %v", failure, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected the failure to be reused, got %d calls", calls)
+	}
+
+	b.SetMaxAge(0)
+	b.list = func(context.Context, []string) ([]dbus.UnitStatus, error) {
+		return nil, godbus.Error{Name: "org.freedesktop.DBus.Error.UnknownMethod"}
+	}
+	for range 2 {
+		if _, _, err := b.status(context.Background(), "nginx"); !errors.Is(err, errBatchUnsupported) {
+			t.Errorf("Expected errBatchUnsupported, got %v", err)
+		}
+	}
+}
+
+// TestBatchSingleFlight verifies checkers arriving during a slow call wait
+// for its result instead of making their own, and that Close is not held
+// up by the call.
+func TestBatchSingleFlight(t *testing.T) {
+	b := NewBatch(DBusScopeSystem, nil, time.Hour)
+	var calls atomic.Int32
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	b.list = func(_ context.Context, units []string) ([]dbus.UnitStatus, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+		}
+		<-unblock
+		return []dbus.UnitStatus{{Name: "nginx.service", ActiveState: StateActive}}, nil
+	}
+	b.register("nginx")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, conn, err := b.status(context.Background(), "nginx")
+			b.release(conn)
+			errs <- err
+		}()
+	}
+	<-started
+
+	closed := make(chan struct{})
+	go func() {
+		b.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close blocked on the in-flight call")
+	}
+
+	close(unblock)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("status() error = %v", err)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("Expected 1 call for 5 concurrent checks, got %d", got)
+	}
+}
+
+// TestBatchWaitHonoursContext verifies a checker waiting on another's call
+// gives up when its context ends.
+func TestBatchWaitHonoursContext(t *testing.T) {
+	b := NewBatch(DBusScopeSystem, nil, time.Hour)
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	defer close(unblock)
+	b.list = func(context.Context, []string) ([]dbus.UnitStatus, error) {
+		close(started)
+		<-unblock
+		return nil, nil
+	}
+	b.register("nginx")
+
+	go b.status(context.Background(), "nginx")
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := b.status(ctx, "nginx"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+// TestBatchProberTemplatedUnit verifies an instance of a templated unit is
+// queried by its full unit name and reported under its monitored name.
+func TestBatchProberTemplatedUnit(t *testing.T) {
+	b := NewBatch(DBusScopeSystem, nil, time.Hour)
+	var queried []string
+	b.list = func(_ context.Context, units []string) ([]dbus.UnitStatus, error) {
+		queried = units
+		return []dbus.UnitStatus{{Name: "getty@tty1.service", LoadState: LoadStateMasked}}, nil
+	}
+
+	p := NewBatchProber(b, "getty@tty1")
+	defer p.Close()
+
+	c := cache.New()
+	if err := p.Check(context.Background(), "getty@tty1", c); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if len(queried) != 1 || queried[0] != "getty@tty1.service" {
+		t.Errorf("Expected getty@tty1.service to be queried, got %v", queried)
+	}
+	if _, state := c.GetStatus(); state != LoadStateMasked {
+		t.Errorf("Expected state %q, got %q", LoadStateMasked, state)
+	}
+	gauge := metrics.ServiceStatus.WithLabelValues("getty@tty1", LoadStateMasked)
+	if got := testutil.ToFloat64(gauge); got != 0 {
+		t.Errorf("Expected service_status{service=\"getty@tty1\"} 0, got %v", got)
+	}
+	metrics.ServiceStatus.DeleteLabelValues("getty@tty1", LoadStateMasked)
+}
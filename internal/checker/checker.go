@@ -3,25 +3,42 @@
 // -----------------------------------------------------------------------
 //
 // Package checker provides periodic healthchecking of systemd services via
-// D-Bus with automatic reconnection and exponential backoff. It updates the
-// shared cache and exports Prometheus metrics. Checker health is tracked
+// D-Bus with automatic reconnection and exponential backoff, and of other
+// processes via TCP or HTTP probes (see Prober). It updates the shared
+// cache and exports Prometheus metrics. Checker health is tracked
 // separately to detect stuck or unresponsive goroutines.
 //
+// The Configure* functions install package-wide settings that checkers
+// read without locking, so they must be called before any checker is
+// started or unit is queried.
+//
 // -----------------------------------------------------------------------
 
 package checker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
+	"math/rand/v2"
 	"net/http"
+	"regexp"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/afreidah/health-check-service/internal/cache"
+	"github.com/afreidah/health-check-service/internal/logging"
 	"github.com/afreidah/health-check-service/internal/metrics"
+	"github.com/afreidah/health-check-service/internal/notify"
+	"github.com/afreidah/health-check-service/internal/tracing"
 	"github.com/coreos/go-systemd/v22/dbus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // -----------------------------------------------------------------------
@@ -37,6 +54,32 @@ const (
 	StateReloading    = "reloading"
 )
 
+// StateError is recorded when the checker cannot read a unit's state, such
+// as on a D-Bus error. It is not a systemd state and says nothing about the
+// unit, so it neither starts nor ends an outage.
+const StateError = cache.ErrorSystemdState
+
+// -----------------------------------------------------------------------
+// Systemd Load State Constants
+// -----------------------------------------------------------------------
+
+const (
+	LoadStateNotFound = "not-found"
+	LoadStateMasked   = "masked"
+)
+
+// -----------------------------------------------------------------------
+// D-Bus Scope Constants
+// -----------------------------------------------------------------------
+
+const (
+	// DBusScopeSystem connects to the system bus for system-wide units.
+	DBusScopeSystem = "system"
+
+	// DBusScopeSession connects to the user session bus for --user units.
+	DBusScopeSession = "session"
+)
+
 // -----------------------------------------------------------------------
 // Reconnection Configuration
 // -----------------------------------------------------------------------
@@ -45,14 +88,65 @@ const (
 	initialRetryDelay = 1 * time.Second
 	maxRetryDelay     = 30 * time.Second
 	backoffMultiplier = 2
-	checkTimeout      = 5 * time.Second
+
+	// DefaultCheckTimeout bounds each check's D-Bus calls when the schedule
+	// does not set a timeout.
+	DefaultCheckTimeout = 5 * time.Second
 )
 
+// ErrReconnectLimit is returned by CheckAndUpdateCacheWithReconnect when
+// the configured number of consecutive reconnection attempts has failed.
+var ErrReconnectLimit = errors.New("D-Bus reconnection attempts exhausted")
+
+// maxReconnectAttempts caps consecutive failed reconnection attempts per
+// checker; zero retries forever.
+var maxReconnectAttempts int
+
+// reconnectExhausted is called when a checker gives up reconnecting.
+var reconnectExhausted func(service string)
+
+// ConfigureReconnectLimit caps consecutive failed D-Bus reconnection
+// attempts (0 retries forever). When a checker exceeds the limit it stops
+// and calls onExhausted, which should shut the process down so a
+// supervisor can restart it.
+func ConfigureReconnectLimit(maxAttempts int, onExhausted func(service string)) {
+	maxReconnectAttempts = maxAttempts
+	reconnectExhausted = onExhausted
+}
+
+// proactiveReconnectInterval, when positive, is how long a D-Bus connection
+// is used before it is replaced even though it still works.
+var proactiveReconnectInterval time.Duration
+
+// ConfigureProactiveReconnect replaces each D-Bus connection once it is
+// interval old (0 disables), for environments where a restarted D-Bus
+// daemon leaves connections that return stale results before they fail.
+func ConfigureProactiveReconnect(interval time.Duration) {
+	proactiveReconnectInterval = interval
+}
+
+// reconnectDue reports whether a connection opened at connectedAt is due
+// for a proactive reconnect.
+func reconnectDue(connectedAt time.Time) bool {
+	return proactiveReconnectInterval > 0 && time.Since(connectedAt) >= proactiveReconnectInterval
+}
+
+// repeatedErrors keeps a persistent failure from logging the same line
+// every check; groups are service names and are reset on a good check.
+var repeatedErrors = logging.NewDeduper(logging.DefaultSummaryInterval)
+
+// ConfigureErrorLogSummary sets how often a repeating check error is
+// summarized rather than logged again (0 selects 60s).
+func ConfigureErrorLogSummary(interval time.Duration) {
+	repeatedErrors = logging.NewDeduper(interval)
+}
+
 // -----------------------------------------------------------------------
 // State Mapping
 // -----------------------------------------------------------------------
 
-var stateToStatusCode = map[string]int{
+// defaultStateToStatusCode treats only "active" as healthy.
+var defaultStateToStatusCode = map[string]int{
 	StateActive:       http.StatusOK,
 	StateInactive:     http.StatusServiceUnavailable,
 	StateFailed:       http.StatusServiceUnavailable,
@@ -61,6 +155,173 @@ var stateToStatusCode = map[string]int{
 	StateReloading:    http.StatusServiceUnavailable,
 }
 
+// stateToStatusCode is the effective mapping consulted by the checker. It
+// starts as the defaults and is replaced by ConfigureStateCodes at startup.
+var stateToStatusCode = defaultStateToStatusCode
+
+// ConfigureStateCodes merges overrides over the default state mapping and
+// installs the result.
+func ConfigureStateCodes(overrides map[string]int) {
+	merged := make(map[string]int, len(defaultStateToStatusCode)+len(overrides))
+	for state, code := range defaultStateToStatusCode {
+		merged[state] = code
+	}
+	for state, code := range overrides {
+		merged[state] = code
+	}
+	stateToStatusCode = merged
+}
+
+// Unit types accepted by ConfigureUnitType. A unit's name is the monitored
+// name plus the type suffix, e.g. "backup" with UnitTypeTimer is
+// "backup.timer".
+const (
+	UnitTypeService = "service"
+	UnitTypeTimer   = "timer"
+	UnitTypeSocket  = "socket"
+	UnitTypeMount   = "mount"
+	UnitTypeTarget  = "target"
+)
+
+// unitType is the suffix appended to monitored names.
+var unitType = UnitTypeService
+
+// ConfigureUnitType selects the systemd unit type of monitored names. An
+// empty value selects UnitTypeService.
+//
+// Every type reports health through ActiveState, so the default state
+// mapping applies unchanged: a timer waiting for its next elapse, a
+// listening socket and a mounted filesystem are all "active".
+func ConfigureUnitType(t string) {
+	if t == "" {
+		t = UnitTypeService
+	}
+	unitType = t
+}
+
+// UnitName returns the full systemd unit name for a monitored name. Names
+// that already carry the configured suffix are returned unchanged; the
+// suffix of a templated unit follows its instance (getty@tty1.service).
+func UnitName(service string) string {
+	suffix := "." + unitType
+	if strings.HasSuffix(service, suffix) {
+		return service
+	}
+	return service + suffix
+}
+
+// maxUnitNameLength is systemd's limit on unit names (UNIT_NAME_MAX less
+// the terminating NUL).
+const maxUnitNameLength = 255
+
+// unitNamePattern matches systemd's unit name charset: ASCII letters,
+// digits and ":-_.\", with "@" separating a template from its instance.
+// Instances may contain further "@".
+var unitNamePattern = regexp.MustCompile(`^[A-Za-z0-9:_.\\-]+(@[A-Za-z0-9:_.\\@-]+)?$`)
+
+// ValidateUnitName reports whether name, with or without its type suffix,
+// is a unit name systemd would accept, so a typo fails at startup instead
+// of as a D-Bus error on every check. Template units need an instance
+// (getty@tty1), since a bare template cannot be running.
+func ValidateUnitName(name string) error {
+	switch {
+	case len(name) > maxUnitNameLength:
+		return fmt.Errorf("invalid unit name %q: longer than %d characters", name, maxUnitNameLength)
+	case strings.HasPrefix(name, "."):
+		return fmt.Errorf("invalid unit name %q: cannot start with a dot", name)
+	case strings.HasPrefix(name, "@"):
+		return fmt.Errorf("invalid unit name %q: missing template name before @", name)
+	case strings.HasSuffix(name, "@") || strings.Contains(name, "@."):
+		return fmt.Errorf("invalid unit name %q: template units need an instance, e.g. getty@tty1", name)
+	case !unitNamePattern.MatchString(name):
+		return fmt.Errorf("invalid unit name %q: systemd allows only ASCII letters, digits, \":-_.\\\" and @ for instances", name)
+	}
+	return nil
+}
+
+// activatingGrace is how long a unit may stay activating and still be
+// reported healthy. Zero disables the grace period.
+var activatingGrace time.Duration
+
+// ConfigureActivatingGrace installs the activating grace period.
+func ConfigureActivatingGrace(grace time.Duration) {
+	activatingGrace = grace
+}
+
+// checkDependencies enables reading the states of each monitored unit's
+// declared dependencies on every check.
+var checkDependencies bool
+
+// dependencyProperties are the unit properties listing hard dependencies:
+// units whose failure stops or prevents starting the dependent unit.
+// Wants= is deliberately excluded since wanted units may legitimately be
+// inactive.
+var dependencyProperties = []string{"Requires", "Requisite", "BindsTo"}
+
+// ConfigureDependencyChecks enables dependency checks.
+func ConfigureDependencyChecks(enabled bool) {
+	checkDependencies = enabled
+}
+
+// transitionNotifier receives service state changes; nil disables
+// notifications.
+var transitionNotifier notify.Notifier
+
+// ConfigureNotifier installs the notifier that receives state transitions.
+func ConfigureNotifier(n notify.Notifier) {
+	transitionNotifier = n
+}
+
+// Flap detection policy: a service is flapping when it enters or leaves
+// the active state more than flapThreshold times within flapWindow. A zero
+// threshold disables detection.
+var (
+	flapThreshold int
+	flapWindow    time.Duration
+)
+
+// ConfigureFlapDetection installs the flap detection policy.
+func ConfigureFlapDetection(threshold int, window time.Duration) {
+	flapThreshold = threshold
+	flapWindow = window
+}
+
+// checkSlots bounds how many D-Bus checks run at once across all
+// checkers; nil means unlimited.
+var checkSlots chan struct{}
+
+// ConfigureMaxConcurrentChecks limits the number of D-Bus checks running at
+// the same time, so monitoring many units does not flood the bus. Zero
+// removes the limit.
+func ConfigureMaxConcurrentChecks(n int) {
+	checkSlots = nil
+	if n > 0 {
+		checkSlots = make(chan struct{}, n)
+	}
+}
+
+// acquireCheckSlot waits for a free check slot. It fails with ctx's error
+// if ctx is done first. Every successful call must be paired with
+// releaseCheckSlot.
+func acquireCheckSlot(ctx context.Context) error {
+	if checkSlots == nil {
+		return nil
+	}
+	select {
+	case checkSlots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// releaseCheckSlot frees a slot taken by acquireCheckSlot.
+func releaseCheckSlot() {
+	if checkSlots != nil {
+		<-checkSlots
+	}
+}
+
 var logc = slog.Default().With("component", "checker")
 
 // -----------------------------------------------------------------------
@@ -104,55 +365,146 @@ func (ch *CheckerHealth) IsHealthy(maxAge time.Duration) bool {
 // Periodic Checker Loop
 // -----------------------------------------------------------------------
 
-// StartServiceChecker runs a periodic loop that polls the systemd service
-// status and updates the shared cache. The loop respects context cancellation
-// for graceful shutdown and automatically reconnects to D-Bus with exponential
-// backoff on connection failures.
+// Schedule controls how often StartServiceChecker polls a service.
+type Schedule struct {
+	// Interval is the base time between checks.
+	Interval time.Duration
+
+	// JitterPercent randomizes each wait within ± this percentage of the
+	// effective interval (0 disables jitter) so many checkers don't hit
+	// D-Bus in lockstep.
+	JitterPercent int
+
+	// AdaptiveMax enables adaptive backoff when greater than Interval. Once
+	// AdaptiveThreshold consecutive checks find the service not active, the
+	// interval doubles with each further miss, capped at AdaptiveMax. It
+	// snaps back to Interval as soon as the service is active again.
+	AdaptiveMax       time.Duration
+	AdaptiveThreshold int
+
+	// Timeout bounds each check (its D-Bus calls, or a TCP/HTTP probe);
+	// zero selects DefaultCheckTimeout. A D-Bus check that times out
+	// triggers reconnection.
+	Timeout time.Duration
+}
+
+// checkTimeout returns the per-check D-Bus timeout.
+func (s Schedule) checkTimeout() time.Duration {
+	if s.Timeout <= 0 {
+		return DefaultCheckTimeout
+	}
+	return s.Timeout
+}
+
+// effectiveInterval returns the wait before the next check given the
+// number of consecutive checks that found the service not active.
+func (s Schedule) effectiveInterval(downStreak int) time.Duration {
+	if s.AdaptiveMax <= s.Interval || s.AdaptiveThreshold < 1 || downStreak < s.AdaptiveThreshold {
+		return s.Interval
+	}
+
+	d := s.Interval
+	for i := s.AdaptiveThreshold; i <= downStreak && d < s.AdaptiveMax; i++ {
+		d *= 2
+	}
+	return min(d, s.AdaptiveMax)
+}
+
+// jitteredInterval returns interval randomized uniformly within
+// ±jitterPercent. A non-positive percentage returns interval unchanged.
+func jitteredInterval(interval time.Duration, jitterPercent int) time.Duration {
+	if jitterPercent <= 0 {
+		return interval
+	}
+	spread := float64(interval) * float64(jitterPercent) / 100
+	return interval + time.Duration((rand.Float64()*2-1)*spread)
+}
+
+// StartServiceChecker runs a periodic loop that checks the target with
+// prober and updates the shared cache. The loop respects context
+// cancellation for graceful shutdown; reconnection on failures is up to the
+// prober (the D-Bus prober reconnects with exponential backoff). The prober
+// is closed when the loop exits.
 //
 // Parameters:
 //   - ctx: cancellation context; loop exits when done
-//   - conn: initial D-Bus connection
-//   - service: systemd unit name (without .service suffix)
+//   - prober: check mechanism for the target (see NewProber)
+//   - service: target name (for systemd, the unit name without suffix)
 //   - cache: shared cache for status updates
-//   - interval: time between checks
+//   - schedule: check interval, jitter, and adaptive backoff settings
 //   - checkerHealth: health tracker updated on successful checks
 func StartServiceChecker(
 	ctx context.Context,
-	conn *dbus.Conn,
+	prober Prober,
 	service string,
-	cache *cache.ServiceCache,
-	interval time.Duration,
+	cache cache.StatusStore,
+	schedule Schedule,
 	checkerHealth *CheckerHealth,
 ) {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+	interval := schedule.Interval
+	downStreak := 0
+	metrics.CheckEffectiveInterval.WithLabelValues(service).Set(interval.Seconds())
+
+	// A timer rather than a ticker so each wait can be re-randomized and
+	// stretched by adaptive backoff
+	timer := time.NewTimer(jitteredInterval(interval, schedule.JitterPercent))
+	defer timer.Stop()
 
-	currentConn := conn
-	defer func() {
-		if currentConn != nil {
-			currentConn.Close()
+	defer prober.Close()
+
+	giveUp := func() {
+		if reconnectExhausted != nil {
+			reconnectExhausted(service)
 		}
-	}()
+	}
 
 	// Perform immediate check on startup to ensure cache is populated quickly
-	currentConn = CheckAndUpdateCacheWithReconnect(ctx, currentConn, service, cache)
-	if currentConn != nil {
+	err := prober.Check(ctx, service, cache)
+	if errors.Is(err, ErrReconnectLimit) {
+		giveUp()
+		return
+	}
+	if err == nil {
 		checkerHealth.RecordSuccess()
 	}
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-timer.C:
 			// Use a timeout context for the check to prevent D-Bus hangs
 			// from blocking indefinitely
-			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
-			currentConn = CheckAndUpdateCacheWithReconnect(checkCtx, currentConn, service, cache)
+			checkCtx, cancel := context.WithTimeout(ctx, schedule.checkTimeout())
+			err := prober.Check(checkCtx, service, cache)
 			cancel()
 
-			if currentConn != nil {
+			if errors.Is(err, ErrReconnectLimit) {
+				giveUp()
+				return
+			}
+
+			if err == nil {
 				checkerHealth.RecordSuccess()
 			}
 
+			if _, state := cache.GetStatus(); state == StateActive {
+				downStreak = 0
+			} else {
+				downStreak++
+			}
+
+			if next := schedule.effectiveInterval(downStreak); next != interval {
+				logc.Info("effective check interval changed",
+					"service", service,
+					"from", interval.String(),
+					"to", next.String(),
+					"consecutive_down", downStreak,
+				)
+				interval = next
+				metrics.CheckEffectiveInterval.WithLabelValues(service).Set(interval.Seconds())
+			}
+
+			timer.Reset(jitteredInterval(interval, schedule.JitterPercent))
+
 		case <-ctx.Done():
 			logc.Info("stopping service checker")
 			return
@@ -164,47 +516,119 @@ func StartServiceChecker(
 // D-Bus Connection Management
 // -----------------------------------------------------------------------
 
+// Connect opens a D-Bus connection on the bus selected by scope. The session
+// bus is used to monitor user units (systemctl --user); any other value
+// selects the system bus.
+func Connect(ctx context.Context, scope string) (*dbus.Conn, error) {
+	if scope == DBusScopeSession {
+		return dbus.NewUserConnectionContext(ctx)
+	}
+	return dbus.NewSystemConnectionContext(ctx)
+}
+
+// JobPending is returned by RestartService when the restart job was queued
+// but had not completed when the context expired.
+const JobPending = "pending"
+
+// RestartService asks systemd to restart the unit for service and waits
+// for the job to finish. A dedicated connection is opened so the request
+// cannot interfere with a checker's connection or its reconnection logic.
+//
+// Returns the systemd job result ("done", "failed", "timeout", "canceled",
+// "dependency" or "skipped"), or JobPending if ctx expires first.
+func RestartService(ctx context.Context, scope, service string) (string, error) {
+	ctx, span := tracing.Start(ctx, "RestartService",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("systemd.unit", service)))
+	defer span.End()
+
+	conn, err := Connect(ctx, scope)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "D-Bus connection failed")
+		return "", fmt.Errorf("connect to D-Bus: %w", err)
+	}
+	defer conn.Close()
+
+	// Buffered so systemd's result does not block after we stop waiting
+	results := make(chan string, 1)
+	if _, err := conn.RestartUnitContext(ctx, UnitName(service), "replace", results); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "restart request failed")
+		return "", fmt.Errorf("restart %s: %w", UnitName(service), err)
+	}
+
+	select {
+	case result := <-results:
+		span.SetAttributes(attribute.String("systemd.job_result", result))
+		return result, nil
+	case <-ctx.Done():
+		return JobPending, nil
+	}
+}
+
 // CheckAndUpdateCacheWithReconnect attempts a cache update with the current
 // connection. On failure, it closes the connection and enters a reconnection
-// loop with exponential backoff. The context is checked before each backoff
-// wait to allow graceful shutdown during reconnection attempts.
+// loop with exponential backoff on the same D-Bus scope. The context is
+// checked before each backoff wait to allow graceful shutdown during
+// reconnection attempts.
+//
+// A nil conn skips straight to the reconnection loop.
+//
+// attempts holds the number of consecutive failed reconnection attempts. It
+// is carried across calls so backoff and the limit set by
+// ConfigureReconnectLimit span checks, and is reset on success.
 //
-// Returns the active D-Bus connection (or nil if ctx is cancelled).
+// Returns the active D-Bus connection, nil if ctx is cancelled, or
+// ErrReconnectLimit once the attempt limit is exceeded.
 func CheckAndUpdateCacheWithReconnect(
 	ctx context.Context,
 	conn *dbus.Conn,
+	scope string,
 	service string,
-	cache *cache.ServiceCache,
-) *dbus.Conn {
+	cache cache.StatusStore,
+	attempts *int,
+) (*dbus.Conn, error) {
 	// Try the check with current connection
-	if err := CheckAndUpdateCache(ctx, conn, service, cache); err == nil {
-		return conn
-	}
+	if conn != nil {
+		if err := CheckAndUpdateCache(ctx, conn, service, cache); err == nil {
+			*attempts = 0
+			return conn, nil
+		}
 
-	logc.Warn("D-Bus connection error; attempting reconnection")
+		repeatedErrors.Log(ctx, logc, slog.LevelWarn, service,
+			"D-Bus connection error; attempting reconnection", "service", service)
 
-	// Close old connection
-	if conn != nil {
+		// Close old connection
 		conn.Close()
 	}
 
 	// Reconnection loop with exponential backoff
-	attemptNum := 1
-	retryDelay := initialRetryDelay
-
 	for {
 		// Check context before any wait operation to allow graceful shutdown
 		select {
 		case <-ctx.Done():
 			logc.Info("shutdown requested during D-Bus reconnection",
-				"attempt", attemptNum,
+				"attempt", *attempts+1,
 				"reason", ctx.Err().Error())
-			return nil
+			return nil, nil
 		default:
 		}
 
+		if maxReconnectAttempts > 0 && *attempts >= maxReconnectAttempts {
+			logc.Error("giving up on D-Bus reconnection",
+				"service", service,
+				"attempts", *attempts,
+				"max_reconnect_attempts", maxReconnectAttempts)
+			return nil, ErrReconnectLimit
+		}
+
+		*attempts++
+		attemptNum := *attempts
+
 		// Attempt to establish new connection
-		newConn, err := dbus.NewSystemConnectionContext(ctx)
+		metrics.DBusReconnects.WithLabelValues(service).Inc()
+		newConn, err := Connect(ctx, scope)
 		if err == nil {
 			logc.Info("successfully reconnected to D-Bus",
 				"attempt", attemptNum,
@@ -212,43 +636,52 @@ func CheckAndUpdateCacheWithReconnect(
 
 			// Verify connection works with immediate check
 			if checkErr := CheckAndUpdateCache(ctx, newConn, service, cache); checkErr == nil {
-				return newConn
+				metrics.DBusReconnectSuccess.WithLabelValues(service).Inc()
+				*attempts = 0
+				return newConn, nil
 			}
 
 			// Check failed, close this connection and retry
-			logc.Warn("check failed on new connection; retrying",
+			repeatedErrors.Log(ctx, logc, slog.LevelWarn, service,
+				"check failed on new connection; retrying",
 				"attempt", attemptNum,
 				"service", service)
 			newConn.Close()
 		} else {
-			logc.Warn("failed to connect to D-Bus",
+			repeatedErrors.Log(ctx, logc, slog.LevelWarn, service,
+				"failed to connect to D-Bus",
 				"attempt", attemptNum,
+				"service", service,
 				"error", err.Error())
 		}
 
 		// Wait before retry with context awareness for shutdown
+		retryDelay := reconnectDelay(attemptNum)
 		select {
 		case <-ctx.Done():
 			logc.Info("shutdown requested during reconnection backoff",
 				"attempt", attemptNum,
 				"reason", ctx.Err().Error())
-			return nil
+			return nil, nil
 
 		case <-time.After(retryDelay):
 			logc.Debug("reconnection backoff completed",
 				"attempt", attemptNum,
-				"next_delay", (retryDelay * backoffMultiplier).String())
-
-			// Exponential backoff
-			retryDelay *= backoffMultiplier
-			if retryDelay > maxRetryDelay {
-				retryDelay = maxRetryDelay
-			}
-			attemptNum++
+				"next_delay", reconnectDelay(attemptNum+1).String())
 		}
 	}
 }
 
+// reconnectDelay returns the exponential backoff before retrying after the
+// given failed attempt (1-based), capped at maxRetryDelay.
+func reconnectDelay(attempt int) time.Duration {
+	delay := initialRetryDelay
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= backoffMultiplier
+	}
+	return min(delay, maxRetryDelay)
+}
+
 // -----------------------------------------------------------------------
 // Cache Update
 // -----------------------------------------------------------------------
@@ -258,38 +691,101 @@ func CheckAndUpdateCacheWithReconnect(
 // provided context is used for the D-Bus call to respect timeouts and
 // cancellation.
 //
+// LoadState is read before ActiveState so that a unit removed or masked
+// after startup is reported as such (503 with state "not-found" or "masked")
+// rather than as a generic D-Bus failure. A missing unit is not a connection
+// problem, so no error is returned and no reconnection is triggered.
+//
 // Returns an error if the D-Bus query fails or produces unexpected data.
 func CheckAndUpdateCache(
 	ctx context.Context,
 	conn *dbus.Conn,
 	service string,
-	cache *cache.ServiceCache,
+	cache cache.StatusStore,
 ) error {
+	ctx, span := tracing.Start(ctx, "CheckAndUpdateCache",
+		trace.WithAttributes(attribute.String("systemd.unit", service)))
+	defer span.End()
+
+	// Query service LoadState to detect units that were removed or masked
+	loadProp, err := getUnitProperty(ctx, conn, service, "LoadState")
+	if err != nil {
+		repeatedErrors.Log(ctx, logc, slog.LevelError, service,
+			"error checking service via D-Bus",
+			"service", service,
+			"error", err.Error(),
+			"context_err", ctx.Err())
+
+		updateCache(service, cache, http.StatusInternalServerError, StateError)
+		metrics.CheckFailures.WithLabelValues(service, "dbus_error").Inc()
+		return err
+	}
+
+	loadState, err := propertyValue[string](loadProp)
+	if err != nil {
+		return recordTypeError(service, cache, err)
+	}
+	if loadState == LoadStateNotFound || loadState == LoadStateMasked {
+		recordUnitStatus(ctx, conn, service, dbus.UnitStatus{LoadState: loadState}, cache)
+		return nil
+	}
+
 	// Query service ActiveState from systemd via D-Bus
-	prop, err := conn.GetUnitPropertyContext(ctx, service+".service", "ActiveState")
+	prop, err := getUnitProperty(ctx, conn, service, "ActiveState")
 	if err != nil {
-		logc.Error("error checking service via D-Bus",
+		repeatedErrors.Log(ctx, logc, slog.LevelError, service,
+			"error checking service via D-Bus",
 			"service", service,
 			"error", err.Error(),
 			"context_err", ctx.Err())
 
-		cache.UpdateStatus(http.StatusInternalServerError, "error")
+		updateCache(service, cache, http.StatusInternalServerError, StateError)
 		metrics.CheckFailures.WithLabelValues(service, "dbus_error").Inc()
 		return err
 	}
 
 	// Extract the ActiveState value from D-Bus variant type
-	activeStatus, ok := prop.Value.Value().(string)
-	if !ok {
-		logc.Error("unexpected type for ActiveState",
+	activeStatus, err := propertyValue[string](prop)
+	if err != nil {
+		return recordTypeError(service, cache, err)
+	}
+
+	// SubState is read by recordActivity
+	recordUnitStatus(ctx, conn, service, dbus.UnitStatus{LoadState: loadState, ActiveState: activeStatus}, cache)
+	return nil
+}
+
+// recordTypeError records a check that failed because a property had an
+// unexpected type, which points at a code issue rather than D-Bus, and
+// returns err.
+func recordTypeError(service string, cache cache.StatusStore, err error) error {
+	logc.Error("unexpected D-Bus property type", "service", service, "error", err.Error())
+
+	updateCache(service, cache, http.StatusInternalServerError, "type_error")
+	metrics.CheckFailures.WithLabelValues(service, "type_error").Inc()
+	return err
+}
+
+// recordUnitStatus updates the cache and metrics from a unit's LoadState
+// and ActiveState, however they were read (per unit or batched), and reads
+// the remaining per-unit properties over conn. An empty SubState is read
+// from D-Bus.
+func recordUnitStatus(ctx context.Context, conn *dbus.Conn, service string, status dbus.UnitStatus, cache cache.StatusStore) {
+	if status.LoadState == LoadStateNotFound || status.LoadState == LoadStateMasked {
+		repeatedErrors.Log(ctx, logc, slog.LevelError, service,
+			"monitored unit is not loaded",
 			"service", service,
-			"type", fmt.Sprintf("%T", prop.Value.Value()))
+			"load_state", status.LoadState)
 
-		cache.UpdateStatus(http.StatusInternalServerError, "type_error")
-		metrics.CheckFailures.WithLabelValues(service, "type_error").Inc()
-		return fmt.Errorf("unexpected ActiveState type: %T", prop.Value.Value())
+		updateCache(service, cache, http.StatusServiceUnavailable, status.LoadState)
+		metrics.CheckFailures.WithLabelValues(service, "unit_missing").Inc()
+		metrics.ServiceStatus.WithLabelValues(service, status.LoadState).Set(0)
+		metrics.ServiceActiveSince.DeleteLabelValues(service)
+		return
 	}
 
+	activeStatus := status.ActiveState
+
 	// Map systemd state to HTTP status code
 	statusCode, found := stateToStatusCode[activeStatus]
 	if !found {
@@ -299,15 +795,394 @@ func CheckAndUpdateCache(
 		statusCode = http.StatusInternalServerError
 	}
 
+	if activeStatus == StateActivating && withinActivatingGrace(cache) {
+		statusCode = http.StatusOK
+	}
+
 	// Update cache with new status
-	cache.UpdateStatus(statusCode, activeStatus)
+	updateCache(service, cache, statusCode, activeStatus)
+	recordRestarts(ctx, conn, service, cache)
+	recordActivity(ctx, conn, service, status, cache)
+	if checkDependencies {
+		recordDependencies(ctx, conn, service, cache)
+	}
 
-	// Update Prometheus gauge
-	if stateToStatusCode[activeStatus] == http.StatusOK {
+	// Update Prometheus gauge from the cached code, so it agrees with
+	// /health during the activating grace period
+	if statusCode == http.StatusOK {
 		metrics.ServiceStatus.WithLabelValues(service, activeStatus).Set(1)
 	} else {
 		metrics.ServiceStatus.WithLabelValues(service, activeStatus).Set(0)
 	}
 
-	return nil
+	// The next failure after a good check is logged in full
+	repeatedErrors.Reset(service)
+}
+
+// withinActivatingGrace reports whether a unit observed as activating is
+// still inside the grace period. The grace period is measured from the
+// first activating observation, so a unit that was not activating before
+// this check has just started.
+func withinActivatingGrace(c cache.StatusStore) bool {
+	if activatingGrace <= 0 {
+		return false
+	}
+	if _, state := c.GetStatus(); state != StateActivating {
+		return true
+	}
+	return time.Since(c.GetStateSince()) < activatingGrace
+}
+
+// updateCache stores a check result and notifies the configured notifier
+// when the systemd state changed. The first result after startup is not a
+// real transition and is not notified.
+func updateCache(service string, c cache.StatusStore, code int, state string) {
+	downtimeBefore := c.GetDowntime()
+	transition, changed := c.UpdateStatus(code, state)
+	if delta := c.GetDowntime() - downtimeBefore; delta > 0 {
+		metrics.ServiceDowntime.WithLabelValues(service).Add(delta.Seconds())
+	}
+	updateFlapping(service, c)
+
+	if !changed || transition.From == cache.UninitializedSystemdState {
+		return
+	}
+
+	var downtime time.Duration
+	from := knownFrom(c, transition)
+	if from != cache.UninitializedSystemdState && !healthyState(from) && healthyState(transition.To) {
+		downtime = recordRecovery(service, c, transition)
+	}
+
+	if transitionNotifier == nil {
+		return
+	}
+
+	transitionNotifier.Notify(notify.Event{
+		Service:   service,
+		From:      transition.From,
+		To:        transition.To,
+		Timestamp: transition.Timestamp,
+		Downtime:  downtime,
+	})
+}
+
+// healthyState reports whether state maps to 200.
+func healthyState(state string) bool {
+	return stateToStatusCode[state] == http.StatusOK
+}
+
+// knownFrom returns the state transition left, looking through StateError
+// to the last state actually read from systemd, so a D-Bus blip on a
+// healthy unit is not taken for a recovery. Returns the uninitialized
+// state when no state was read before.
+func knownFrom(c cache.StatusStore, transition cache.Transition) string {
+	if transition.From != StateError {
+		return transition.From
+	}
+	history := c.GetHistory()
+	for i := len(history) - 1; i >= 0; i-- {
+		t := history[i]
+		if t.Timestamp.Before(transition.Timestamp) && t.From != StateError {
+			return t.From
+		}
+	}
+	return cache.UninitializedSystemdState
+}
+
+// recordRecovery logs the end of an outage with its duration, counts it,
+// and returns the duration. The outage started at the first unhealthy
+// state read after the last healthy one, or at the first check if the
+// service was never healthy; StateError is skipped, as it says nothing
+// about the unit. When the start has rotated out of the history, the
+// oldest recorded transition is used.
+func recordRecovery(service string, c cache.StatusStore, recovery cache.Transition) time.Duration {
+	history := c.GetHistory()
+	downSince := recovery.Timestamp
+	for i := len(history) - 1; i >= 0; i-- {
+		t := history[i]
+		if !t.Timestamp.Before(recovery.Timestamp) {
+			continue
+		}
+		if t.To != StateError {
+			downSince = t.Timestamp
+		}
+		if t.From == cache.UninitializedSystemdState || healthyState(t.From) {
+			break
+		}
+	}
+
+	downtime := recovery.Timestamp.Sub(downSince)
+	metrics.ServiceRecoveries.WithLabelValues(service).Inc()
+	logc.Info("service recovered",
+		"service", service,
+		"from", recovery.From,
+		"to", recovery.To,
+		"down_since", downSince,
+		"downtime", downtime.Round(time.Second).String())
+	return downtime
+}
+
+// updateFlapping reclassifies the service from its recent transitions.
+// Re-evaluated on every check so the flag clears once the window passes
+// without enough transitions.
+func updateFlapping(service string, c cache.StatusStore) {
+	if flapThreshold <= 0 {
+		return
+	}
+
+	transitions := c.ActiveTransitionsSince(time.Now().Add(-flapWindow))
+	flapping := transitions > flapThreshold
+
+	if flapping != c.IsFlapping() {
+		if flapping {
+			logc.Warn("service is flapping",
+				"service", service,
+				"transitions", transitions,
+				"window", flapWindow.String())
+		} else {
+			logc.Info("service stopped flapping", "service", service)
+		}
+	}
+
+	c.SetFlapping(flapping)
+	if flapping {
+		metrics.ServiceFlapping.WithLabelValues(service).Set(1)
+	} else {
+		metrics.ServiceFlapping.WithLabelValues(service).Set(0)
+	}
+}
+
+// recordRestarts reads the unit's NRestarts so a crash-looping service that
+// is momentarily active can still be detected. The property is optional
+// (older systemd versions lack it), so a failed read is logged but does not
+// fail the check. Only service units have the property.
+func recordRestarts(ctx context.Context, conn *dbus.Conn, service string, c cache.StatusStore) {
+	if unitType != UnitTypeService {
+		return
+	}
+
+	prop, err := getServiceProperty(ctx, conn, service, "NRestarts")
+	if err != nil {
+		logc.Debug("could not read NRestarts", "service", service, "error", err.Error())
+		return
+	}
+
+	restarts, err := propertyValue[uint32](prop)
+	if err != nil {
+		logc.Debug("could not read NRestarts", "service", service, "error", err.Error())
+		return
+	}
+
+	c.SetRestarts(restarts)
+	metrics.ServiceRestarts.WithLabelValues(service).Set(float64(restarts))
+}
+
+// recordActivity reads the unit's SubState and ActiveEnterTimestamp. For
+// an inactive unit these tell "never started" (dead, no timestamp) from
+// "ran and stopped" (a timestamp, or exited for oneshot units). For an
+// active unit the timestamp is when it came up, exported as the
+// monitored_service_active_since_seconds gauge. Like NRestarts, they are
+// informational and a failed read does not fail the check. SubState is
+// taken from status when a batched query already returned it.
+func recordActivity(ctx context.Context, conn *dbus.Conn, service string, status dbus.UnitStatus, c cache.StatusStore) {
+	subState := status.SubState
+	if subState == "" {
+		subProp, err := getUnitProperty(ctx, conn, service, "SubState")
+		if err != nil {
+			logc.Debug("could not read SubState", "service", service, "error", err.Error())
+			return
+		}
+		if subState, err = propertyValue[string](subProp); err != nil {
+			logc.Debug("could not read SubState", "service", service, "error", err.Error())
+			return
+		}
+	}
+	enterProp, err := getUnitProperty(ctx, conn, service, "ActiveEnterTimestamp")
+	if err != nil {
+		logc.Debug("could not read ActiveEnterTimestamp", "service", service, "error", err.Error())
+		return
+	}
+
+	enterUsec, err := propertyValue[uint64](enterProp)
+	if err != nil {
+		logc.Debug("could not read ActiveEnterTimestamp", "service", service, "error", err.Error())
+		return
+	}
+	lastActive := lastActiveTime(enterUsec)
+	c.SetActivity(subState, lastActive)
+
+	if status.ActiveState == StateActive && !lastActive.IsZero() {
+		metrics.ServiceActiveSince.WithLabelValues(service).Set(float64(lastActive.UnixMicro()) / 1e6)
+	} else {
+		metrics.ServiceActiveSince.DeleteLabelValues(service)
+	}
+}
+
+// lastActiveTime converts an ActiveEnterTimestamp (microseconds since the
+// epoch) to a time. systemd reports 0 for a unit that has never been
+// active, which becomes the zero time.
+func lastActiveTime(usec uint64) time.Time {
+	if usec == 0 {
+		return time.Time{}
+	}
+	return time.UnixMicro(int64(usec))
+}
+
+// recordDependencies reads the states of the unit's hard dependencies.
+// Failing to read them does not fail the check: the previous states are
+// kept and the failure is logged.
+func recordDependencies(ctx context.Context, conn *dbus.Conn, service string, c cache.StatusStore) {
+	seen := make(map[string]bool)
+	var names []string
+	for _, property := range dependencyProperties {
+		prop, err := getUnitProperty(ctx, conn, service, property)
+		if err != nil {
+			logc.Warn("could not read dependencies",
+				"service", service,
+				"property", property,
+				"error", err.Error())
+			return
+		}
+		units, err := propertyValue[[]string](prop)
+		if err != nil {
+			logc.Warn("could not read dependencies",
+				"service", service,
+				"property", property,
+				"error", err.Error())
+			return
+		}
+		for _, unit := range units {
+			if !seen[unit] {
+				seen[unit] = true
+				names = append(names, unit)
+			}
+		}
+	}
+
+	if len(names) == 0 {
+		c.SetDependencies([]cache.Dependency{})
+		return
+	}
+
+	statuses, err := conn.ListUnitsByNamesContext(ctx, names)
+	if err != nil {
+		logc.Warn("could not read dependency states",
+			"service", service,
+			"error", err.Error())
+		return
+	}
+
+	deps := make([]cache.Dependency, 0, len(statuses))
+	for _, status := range statuses {
+		deps = append(deps, dependencyState(status))
+	}
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Unit < deps[j].Unit })
+
+	c.SetDependencies(deps)
+}
+
+// dependencyState classifies a dependency with the same state mapping as
+// the monitored unit. Units that are not loaded report their LoadState.
+func dependencyState(status dbus.UnitStatus) cache.Dependency {
+	state := status.ActiveState
+	if status.LoadState == LoadStateNotFound || status.LoadState == LoadStateMasked {
+		state = status.LoadState
+	}
+	return cache.Dependency{
+		Unit:    status.Name,
+		State:   state,
+		Healthy: stateToStatusCode[state] == http.StatusOK,
+	}
+}
+
+// getUnitProperty fetches a single unit property and records the call
+// latency, including failed and timed-out calls, so slow D-Bus responses are
+// visible before they become outright failures. Each call is traced as a
+// child span of the enclosing check.
+func getUnitProperty(ctx context.Context, conn *dbus.Conn, service, name string) (*dbus.Property, error) {
+	return queryProperty(ctx, "GetUnitPropertyContext", service, name, func(ctx context.Context) (*dbus.Property, error) {
+		return conn.GetUnitPropertyContext(ctx, UnitName(service), name)
+	})
+}
+
+// getServiceProperty is getUnitProperty for properties of the Service
+// interface, such as NRestarts.
+func getServiceProperty(ctx context.Context, conn *dbus.Conn, service, name string) (*dbus.Property, error) {
+	return queryProperty(ctx, "GetServicePropertyContext", service, name, func(ctx context.Context) (*dbus.Property, error) {
+		return conn.GetServicePropertyContext(ctx, UnitName(service), name)
+	})
+}
+
+// queryProperty runs a D-Bus property query inside a span and records its
+// latency.
+func queryProperty(
+	ctx context.Context,
+	spanName, service, name string,
+	query func(context.Context) (*dbus.Property, error),
+) (*dbus.Property, error) {
+	ctx, span := tracing.Start(ctx, spanName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("systemd.unit", service),
+			attribute.String("dbus.property", name),
+		))
+	defer span.End()
+
+	start := time.Now()
+	prop, err := query(ctx)
+	metrics.DBusQueryDuration.WithLabelValues(service).Observe(time.Since(start).Seconds())
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "D-Bus query failed")
+	}
+	return prop, err
+}
+
+// -----------------------------------------------------------------------
+// Property Values
+// -----------------------------------------------------------------------
+
+// PropertyTypeError reports a D-Bus property whose value does not have the
+// expected type.
+type PropertyTypeError struct {
+	Property string
+	Want     string
+	Got      string
+}
+
+// Error implements the error interface.
+func (e *PropertyTypeError) Error() string {
+	return fmt.Sprintf("unexpected type %s for D-Bus property %s (want %s)", e.Got, e.Property, e.Want)
+}
+
+// propertyValue extracts the value of prop as T without panicking. Unsigned
+// integers are converted when the value fits, so a uint32 property such as
+// NRestarts can be read as uint64 and vice versa. A nil prop or a value of
+// another type yields a *PropertyTypeError.
+func propertyValue[T string | bool | uint32 | uint64 | []string](prop *dbus.Property) (T, error) {
+	var out T
+	if prop == nil {
+		return out, &PropertyTypeError{Want: fmt.Sprintf("%T", out), Got: "<nil>"}
+	}
+
+	v := prop.Value.Value()
+	if value, ok := v.(T); ok {
+		return value, nil
+	}
+
+	switch p := any(&out).(type) {
+	case *uint64:
+		if n, ok := v.(uint32); ok {
+			*p = uint64(n)
+			return out, nil
+		}
+	case *uint32:
+		if n, ok := v.(uint64); ok && n <= math.MaxUint32 {
+			*p = uint32(n)
+			return out, nil
+		}
+	}
+
+	return out, &PropertyTypeError{Property: prop.Name, Want: fmt.Sprintf("%T", out), Got: fmt.Sprintf("%T", v)}
 }
@@ -12,8 +12,19 @@
 package checker
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/afreidah/health-check-service/internal/cache"
+	"github.com/afreidah/health-check-service/internal/metrics"
+	"github.com/afreidah/health-check-service/internal/notify"
+	"github.com/coreos/go-systemd/v22/dbus"
+	godbus "github.com/godbus/dbus/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 // -----------------------------------------------------------------------
@@ -75,3 +86,485 @@ func TestStateConstants(t *testing.T) {
 		})
 	}
 }
+
+// TestLoadStateConstants verifies the LoadState values match the exact
+// strings systemd reports. A mismatch would cause removed or masked units to
+// be misreported as generic D-Bus failures.
+func TestLoadStateConstants(t *testing.T) {
+	if LoadStateNotFound != "not-found" {
+		t.Errorf("LoadStateNotFound: expected %q, got %q", "not-found", LoadStateNotFound)
+	}
+	if LoadStateMasked != "masked" {
+		t.Errorf("LoadStateMasked: expected %q, got %q", "masked", LoadStateMasked)
+	}
+}
+
+// TestConfigureStateCodes verifies overrides are merged over the defaults
+// rather than replacing them. Dropping a default entry would turn that state
+// into an unknown state reported as 500.
+func TestConfigureStateCodes(t *testing.T) {
+	t.Cleanup(func() { ConfigureStateCodes(nil) })
+
+	ConfigureStateCodes(map[string]int{StateActivating: http.StatusOK})
+
+	if got := stateToStatusCode[StateActivating]; got != http.StatusOK {
+		t.Errorf("Overridden state %s: expected %d, got %d", StateActivating, http.StatusOK, got)
+	}
+	if got := stateToStatusCode[StateFailed]; got != http.StatusServiceUnavailable {
+		t.Errorf("Default state %s: expected %d, got %d", StateFailed, http.StatusServiceUnavailable, got)
+	}
+	if got := defaultStateToStatusCode[StateActivating]; got != http.StatusServiceUnavailable {
+		t.Errorf("Defaults were mutated: %s maps to %d", StateActivating, got)
+	}
+}
+
+// -----------------------------------------------------------------------
+// Scheduling Tests
+// -----------------------------------------------------------------------
+
+// TestJitteredInterval verifies jitter stays within interval ± percent and
+// that zero jitter preserves the fixed schedule for backward compatibility.
+func TestJitteredInterval(t *testing.T) {
+	interval := 10 * time.Second
+
+	if got := jitteredInterval(interval, 0); got != interval {
+		t.Errorf("Zero jitter: expected %v, got %v", interval, got)
+	}
+
+	low, high := 8*time.Second, 12*time.Second
+	for i := 0; i < 1000; i++ {
+		got := jitteredInterval(interval, 20)
+		if got < low || got > high {
+			t.Fatalf("20%% jitter: %v outside [%v, %v]", got, low, high)
+		}
+	}
+}
+
+// TestScheduleEffectiveInterval verifies adaptive backoff doubles the
+// interval only after the threshold is reached, respects the cap, and is a
+// no-op when disabled so the default schedule is unchanged.
+func TestScheduleEffectiveInterval(t *testing.T) {
+	adaptive := Schedule{
+		Interval:          2 * time.Second,
+		AdaptiveMax:       30 * time.Second,
+		AdaptiveThreshold: 3,
+	}
+
+	tests := []struct {
+		name       string
+		schedule   Schedule
+		downStreak int
+		want       time.Duration
+	}{
+		{"disabled", Schedule{Interval: 2 * time.Second}, 100, 2 * time.Second},
+		{"healthy", adaptive, 0, 2 * time.Second},
+		{"below threshold", adaptive, 2, 2 * time.Second},
+		{"at threshold", adaptive, 3, 4 * time.Second},
+		{"past threshold", adaptive, 5, 16 * time.Second},
+		{"capped", adaptive, 50, 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.schedule.effectiveInterval(tt.downStreak); got != tt.want {
+				t.Errorf("effectiveInterval(%d) = %v, want %v", tt.downStreak, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestScheduleCheckTimeout verifies the D-Bus timeout falls back to the
+// default when unset.
+func TestScheduleCheckTimeout(t *testing.T) {
+	if got := (Schedule{}).checkTimeout(); got != DefaultCheckTimeout {
+		t.Errorf("Expected default timeout %v, got %v", DefaultCheckTimeout, got)
+	}
+	if got := (Schedule{Timeout: 15 * time.Second}).checkTimeout(); got != 15*time.Second {
+		t.Errorf("Expected configured timeout 15s, got %v", got)
+	}
+}
+
+// -----------------------------------------------------------------------
+// Reconnection Tests
+// -----------------------------------------------------------------------
+
+// TestReconnectDelay verifies backoff doubles per failed attempt and is
+// capped, so it keeps growing across checks instead of resetting.
+func TestReconnectDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 1 * time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{6, 30 * time.Second},
+		{100, 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := reconnectDelay(tt.attempt); got != tt.want {
+			t.Errorf("reconnectDelay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+// TestReconnectLimit verifies a checker that has used up its attempts
+// gives up without trying again.
+func TestReconnectLimit(t *testing.T) {
+	ConfigureReconnectLimit(3, nil)
+	t.Cleanup(func() { ConfigureReconnectLimit(0, nil) })
+
+	attempts := 3
+	conn, err := CheckAndUpdateCacheWithReconnect(context.Background(), nil, DBusScopeSystem, "nginx", cache.New(), &attempts)
+	if !errors.Is(err, ErrReconnectLimit) {
+		t.Errorf("Expected ErrReconnectLimit, got %v", err)
+	}
+	if conn != nil {
+		t.Error("Expected no connection after giving up")
+	}
+	if attempts != 3 {
+		t.Errorf("Expected no further attempts, got %d", attempts)
+	}
+}
+
+// TestReconnectDue verifies connections are only replaced once they reach
+// the proactive reconnect interval, and never when it is disabled.
+func TestReconnectDue(t *testing.T) {
+	t.Cleanup(func() { ConfigureProactiveReconnect(0) })
+
+	old := time.Now().Add(-2 * time.Hour)
+	if reconnectDue(old) {
+		t.Error("Expected no proactive reconnect while disabled")
+	}
+
+	ConfigureProactiveReconnect(time.Hour)
+	if !reconnectDue(old) {
+		t.Error("Expected a 2h old connection to be due with a 1h interval")
+	}
+	if reconnectDue(time.Now()) {
+		t.Error("Expected a new connection not to be due")
+	}
+}
+
+// TestMaxConcurrentChecks verifies checks beyond the limit wait for a free
+// slot and give up when their context ends first.
+func TestMaxConcurrentChecks(t *testing.T) {
+	ConfigureMaxConcurrentChecks(1)
+	t.Cleanup(func() { ConfigureMaxConcurrentChecks(0) })
+
+	if err := acquireCheckSlot(context.Background()); err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := acquireCheckSlot(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquire with no free slot: got %v, want DeadlineExceeded", err)
+	}
+
+	releaseCheckSlot()
+	if err := acquireCheckSlot(context.Background()); err != nil {
+		t.Errorf("acquire after release: %v", err)
+	}
+	releaseCheckSlot()
+
+	// Unlimited never blocks
+	ConfigureMaxConcurrentChecks(0)
+	for range 3 {
+		if err := acquireCheckSlot(ctx); err != nil {
+			t.Errorf("unlimited acquire: %v", err)
+		}
+	}
+}
+
+// -----------------------------------------------------------------------
+// Unit Classification Tests
+// -----------------------------------------------------------------------
+
+// TestUnitName verifies the configured unit type is appended once, after
+// the instance of a templated unit.
+func TestUnitName(t *testing.T) {
+	t.Cleanup(func() { ConfigureUnitType("") })
+
+	if got := UnitName("nginx"); got != "nginx.service" {
+		t.Errorf("Expected default service suffix, got %q", got)
+	}
+	if got := UnitName("getty@tty1"); got != "getty@tty1.service" {
+		t.Errorf("Expected suffix after the instance, got %q", got)
+	}
+	if got := UnitName("user@1000.service"); got != "user@1000.service" {
+		t.Errorf("Expected templated unit with suffix unchanged, got %q", got)
+	}
+
+	ConfigureUnitType(UnitTypeTimer)
+	tests := map[string]string{
+		"backup":       "backup.timer",
+		"backup.timer": "backup.timer",
+	}
+	for name, want := range tests {
+		if got := UnitName(name); got != want {
+			t.Errorf("UnitName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// TestValidateUnitName verifies systemd's unit name rules, including
+// templated instances.
+func TestValidateUnitName(t *testing.T) {
+	tests := []struct {
+		name      string
+		shouldErr bool
+	}{
+		{"nginx", false},
+		{"nginx.service", false},
+		{"getty@tty1", false},
+		{"user@1000.service", false},
+		{"systemd-fsck@dev-disk-by\\x2duuid-1234.service", false},
+		{"-.mount", false},
+		{".hidden", true},
+		{"@tty1", true},
+		{"getty@", true},
+		{"getty@.service", true},
+		{"nginx/conf", true},
+		{"nginx;reboot", true},
+		{"caf\u00e9", true},
+		{strings.Repeat("a", 256), true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateUnitName(tt.name)
+		if (err != nil) != tt.shouldErr {
+			t.Errorf("ValidateUnitName(%q) error = %v, shouldErr %v", tt.name, err, tt.shouldErr)
+		}
+	}
+}
+
+// TestPropertyValue verifies variants are extracted by type, unsigned
+// integers convert when they fit, and mismatches return a
+// *PropertyTypeError instead of panicking.
+func TestPropertyValue(t *testing.T) {
+	prop := func(v any) *dbus.Property {
+		return &dbus.Property{Name: "Test", Value: godbus.MakeVariant(v)}
+	}
+
+	if got, err := propertyValue[string](prop("active")); err != nil || got != "active" {
+		t.Errorf("string: got %q, %v", got, err)
+	}
+	if got, err := propertyValue[bool](prop(true)); err != nil || !got {
+		t.Errorf("bool: got %v, %v", got, err)
+	}
+	if got, err := propertyValue[uint32](prop(uint32(3))); err != nil || got != 3 {
+		t.Errorf("uint32: got %d, %v", got, err)
+	}
+	if got, err := propertyValue[uint64](prop(uint32(3))); err != nil || got != 3 {
+		t.Errorf("uint32 as uint64: got %d, %v", got, err)
+	}
+	if got, err := propertyValue[uint32](prop(uint64(7))); err != nil || got != 7 {
+		t.Errorf("small uint64 as uint32: got %d, %v", got, err)
+	}
+	if got, err := propertyValue[[]string](prop([]string{"a.mount"})); err != nil || len(got) != 1 {
+		t.Errorf("[]string: got %v, %v", got, err)
+	}
+
+	var typeErr *PropertyTypeError
+	if _, err := propertyValue[uint32](prop(uint64(1 << 40))); !errors.As(err, &typeErr) {
+		t.Errorf("overflowing uint64 as uint32: expected PropertyTypeError, got %v", err)
+	}
+	if _, err := propertyValue[string](prop(uint32(1))); !errors.As(err, &typeErr) || typeErr.Want != "string" || typeErr.Got != "uint32" {
+		t.Errorf("uint32 as string: expected PropertyTypeError, got %v", err)
+	}
+	if _, err := propertyValue[string](nil); !errors.As(err, &typeErr) {
+		t.Errorf("nil property: expected PropertyTypeError, got %v", err)
+	}
+}
+
+// TestLastActiveTime verifies systemd's zero ActiveEnterTimestamp maps to
+// the zero time ("never started") and other values to microseconds since
+// the epoch.
+func TestLastActiveTime(t *testing.T) {
+	if got := lastActiveTime(0); !got.IsZero() {
+		t.Errorf("Expected zero time for 0, got %v", got)
+	}
+
+	want := time.Date(2025, 10, 15, 12, 0, 0, 0, time.UTC)
+	if got := lastActiveTime(uint64(want.UnixMicro())); !got.Equal(want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestDependencyState verifies dependencies are classified with the state
+// mapping and that unloaded units report their LoadState.
+func TestDependencyState(t *testing.T) {
+	tests := []struct {
+		status      dbus.UnitStatus
+		wantState   string
+		wantHealthy bool
+	}{
+		{dbus.UnitStatus{Name: "data.mount", LoadState: "loaded", ActiveState: "active"}, "active", true},
+		{dbus.UnitStatus{Name: "data.mount", LoadState: "loaded", ActiveState: "failed"}, "failed", false},
+		{dbus.UnitStatus{Name: "gone.mount", LoadState: "not-found", ActiveState: "inactive"}, "not-found", false},
+	}
+
+	for _, tt := range tests {
+		got := dependencyState(tt.status)
+		if got.Unit != tt.status.Name || got.State != tt.wantState || got.Healthy != tt.wantHealthy {
+			t.Errorf("dependencyState(%+v) = %+v", tt.status, got)
+		}
+	}
+}
+
+// TestWithinActivatingGrace verifies activating is only excused for the
+// grace period, measured from the first activating observation.
+func TestWithinActivatingGrace(t *testing.T) {
+	t.Cleanup(func() { ConfigureActivatingGrace(0) })
+
+	c := cache.New()
+	c.UpdateStatus(http.StatusServiceUnavailable, StateInactive)
+	if withinActivatingGrace(c) {
+		t.Error("Expected no grace when disabled")
+	}
+
+	ConfigureActivatingGrace(time.Hour)
+	if !withinActivatingGrace(c) {
+		t.Error("Expected grace for a unit that just started activating")
+	}
+
+	c.UpdateStatus(http.StatusOK, StateActivating)
+	if !withinActivatingGrace(c) {
+		t.Error("Expected grace while activating within the period")
+	}
+
+	ConfigureActivatingGrace(time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if withinActivatingGrace(c) {
+		t.Error("Expected grace to expire for a hung start")
+	}
+}
+
+// -----------------------------------------------------------------------
+// Cache Update and Notification Tests
+// -----------------------------------------------------------------------
+
+// recordingNotifier captures events for assertions.
+type recordingNotifier struct {
+	events []notify.Event
+}
+
+func (r *recordingNotifier) Notify(e notify.Event) {
+	r.events = append(r.events, e)
+}
+
+// TestUpdateCacheDetectsFlapping verifies a service is marked flapping once
+// it crosses the threshold and cleared when detection finds no churn.
+func TestUpdateCacheDetectsFlapping(t *testing.T) {
+	ConfigureFlapDetection(2, time.Minute)
+	t.Cleanup(func() { ConfigureFlapDetection(0, 0) })
+
+	c := cache.New()
+	updateCache("nginx", c, http.StatusOK, StateActive)
+	updateCache("nginx", c, http.StatusServiceUnavailable, StateFailed)
+	updateCache("nginx", c, http.StatusOK, StateActive)
+	if c.IsFlapping() {
+		t.Fatal("Expected not flapping at the threshold")
+	}
+
+	updateCache("nginx", c, http.StatusServiceUnavailable, StateFailed)
+	if !c.IsFlapping() {
+		t.Fatal("Expected flapping above the threshold")
+	}
+
+	// A window that has already elapsed sees no transitions
+	ConfigureFlapDetection(2, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	updateCache("nginx", c, http.StatusServiceUnavailable, StateFailed)
+	if c.IsFlapping() {
+		t.Error("Expected flapping to clear once the window passes")
+	}
+}
+
+// TestUpdateCacheCountsDowntime verifies the downtime counter advances by
+// the interval spent failed, not by the interval spent active.
+func TestUpdateCacheCountsDowntime(t *testing.T) {
+	counter := metrics.ServiceDowntime.WithLabelValues("downtime-test")
+	before := testutil.ToFloat64(counter)
+
+	c := cache.New()
+	updateCache("downtime-test", c, http.StatusServiceUnavailable, StateFailed)
+	c.SetLastChecked(time.Now().Add(-20 * time.Second))
+	updateCache("downtime-test", c, http.StatusOK, StateActive)
+	c.SetLastChecked(time.Now().Add(-20 * time.Second))
+	updateCache("downtime-test", c, http.StatusOK, StateActive)
+
+	got := testutil.ToFloat64(counter) - before
+	if got < 19 || got > 21 {
+		t.Errorf("Expected ~20s of downtime, got %f", got)
+	}
+}
+
+// TestUpdateCacheCountsRecoveries verifies only a return to a healthy
+// state counts as a recovery: not the first result after startup, and not
+// a move between two unhealthy states.
+func TestUpdateCacheCountsRecoveries(t *testing.T) {
+	counter := metrics.ServiceRecoveries.WithLabelValues("recovery-test")
+	before := testutil.ToFloat64(counter)
+
+	c := cache.New()
+	updateCache("recovery-test", c, http.StatusOK, StateActive)
+	updateCache("recovery-test", c, http.StatusServiceUnavailable, StateFailed)
+	updateCache("recovery-test", c, http.StatusServiceUnavailable, StateActivating)
+	if got := testutil.ToFloat64(counter) - before; got != 0 {
+		t.Fatalf("Expected no recoveries while down, got %f", got)
+	}
+
+	updateCache("recovery-test", c, http.StatusOK, StateActive)
+	updateCache("recovery-test", c, http.StatusOK, StateActive)
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Errorf("Expected 1 recovery, got %f", got)
+	}
+}
+
+// TestUpdateCacheRecoveryIgnoresCheckerErrors verifies a D-Bus error on a
+// healthy unit is not followed by a recovery, while an outage the error
+// interrupted still ends in one.
+func TestUpdateCacheRecoveryIgnoresCheckerErrors(t *testing.T) {
+	counter := metrics.ServiceRecoveries.WithLabelValues("error-recovery-test")
+	before := testutil.ToFloat64(counter)
+
+	c := cache.New()
+	updateCache("error-recovery-test", c, http.StatusOK, StateActive)
+	updateCache("error-recovery-test", c, http.StatusInternalServerError, StateError)
+	updateCache("error-recovery-test", c, http.StatusOK, StateActive)
+	if got := testutil.ToFloat64(counter) - before; got != 0 {
+		t.Fatalf("Expected no recovery after active -> error -> active, got %f", got)
+	}
+
+	updateCache("error-recovery-test", c, http.StatusServiceUnavailable, StateFailed)
+	updateCache("error-recovery-test", c, http.StatusInternalServerError, StateError)
+	updateCache("error-recovery-test", c, http.StatusOK, StateActive)
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Errorf("Expected 1 recovery after failed -> error -> active, got %f", got)
+	}
+}
+
+// TestUpdateCacheNotifiesTransitions verifies notifications fire once per
+// state change and not for the initial result after startup, which would
+// otherwise page on every restart.
+func TestUpdateCacheNotifiesTransitions(t *testing.T) {
+	rec := &recordingNotifier{}
+	ConfigureNotifier(rec)
+	t.Cleanup(func() { ConfigureNotifier(nil) })
+
+	c := cache.New()
+	updateCache("nginx", c, http.StatusOK, StateActive)
+	updateCache("nginx", c, http.StatusOK, StateActive)
+	updateCache("nginx", c, http.StatusServiceUnavailable, StateFailed)
+
+	if len(rec.events) != 1 {
+		t.Fatalf("Expected 1 notification, got %d: %+v", len(rec.events), rec.events)
+	}
+	e := rec.events[0]
+	if e.Service != "nginx" || e.From != StateActive || e.To != StateFailed {
+		t.Errorf("Unexpected event: %+v", e)
+	}
+}
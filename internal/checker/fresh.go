@@ -0,0 +1,87 @@
+// -----------------------------------------------------------------------
+// On-Demand Checks
+// -----------------------------------------------------------------------
+//
+// Handlers serve from cache, which can be up to one interval old. During
+// an incident an operator may want the current state instead, so CheckNow
+// probes targets once outside their checkers' schedules and records the
+// results in the same caches. The running checkers are not disturbed.
+//
+// -----------------------------------------------------------------------
+
+package checker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/afreidah/health-check-service/internal/cache"
+	"github.com/coreos/go-systemd/v22/dbus"
+)
+
+// CheckNow probes every target in targets that has a cache in caches,
+// concurrently, and records the results. systemd units share one D-Bus
+// connection opened for the call and hold a check slot each, like
+// scheduled checks. timeout bounds each check; zero selects
+// DefaultCheckTimeout.
+//
+// Check errors are logged and recorded by the probes as usual. The
+// returned error only reports that D-Bus could not be reached, in which
+// case systemd units keep their cached status.
+func CheckNow(
+	ctx context.Context,
+	targets map[string]Target,
+	caches map[string]cache.StatusStore,
+	scope string,
+	timeout time.Duration,
+) error {
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var conn *dbus.Conn
+	var connErr error
+	var wg sync.WaitGroup
+	for name, c := range caches {
+		target, ok := targets[name]
+		if !ok {
+			continue
+		}
+
+		if target.Kind != TargetDBus {
+			wg.Go(func() {
+				prober := NewProber(target, scope, nil, timeout)
+				defer prober.Close()
+				_ = prober.Check(ctx, name, c)
+			})
+			continue
+		}
+
+		if conn == nil && connErr == nil {
+			conn, connErr = Connect(ctx, scope)
+			if connErr != nil {
+				connErr = fmt.Errorf("failed to connect to D-Bus: %w", connErr)
+			}
+		}
+		if connErr != nil {
+			continue
+		}
+		wg.Go(func() {
+			if err := acquireCheckSlot(ctx); err != nil {
+				return
+			}
+			defer releaseCheckSlot()
+			_ = CheckAndUpdateCache(ctx, conn, name, c)
+		})
+	}
+	wg.Wait()
+
+	if conn != nil {
+		conn.Close()
+	}
+	return connErr
+}
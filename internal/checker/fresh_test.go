@@ -0,0 +1,44 @@
+// -----------------------------------------------------------------------
+// On-Demand Checks - Tests
+// -----------------------------------------------------------------------
+//
+// Validates that CheckNow probes each target with a cache once and
+// records the result without needing D-Bus for TCP and HTTP targets.
+//
+// -----------------------------------------------------------------------
+
+package checker
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/afreidah/health-check-service/internal/cache"
+)
+
+// TestCheckNow verifies every target with a cache is checked and targets
+// without one are skipped.
+func TestCheckNow(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	db := cache.New()
+	targets := map[string]Target{
+		"db":      {Name: "db", Kind: TargetTCP, Address: ln.Addr().String()},
+		"unknown": {Name: "unknown", Kind: TargetTCP, Address: "127.0.0.1:1"},
+	}
+	caches := map[string]cache.StatusStore{"db": db}
+
+	if err := CheckNow(context.Background(), targets, caches, DBusScopeSystem, time.Second); err != nil {
+		t.Fatalf("CheckNow() error = %v", err)
+	}
+	if code, state := db.GetStatus(); code != http.StatusOK || state != StateActive {
+		t.Errorf("Expected db to be checked and active, got %d %q", code, state)
+	}
+}
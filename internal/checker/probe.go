@@ -0,0 +1,293 @@
+// -----------------------------------------------------------------------
+// Probers
+// -----------------------------------------------------------------------
+//
+// A Prober is the mechanism StartServiceChecker uses to check one target.
+// Systemd units are checked over D-Bus; processes that systemd does not
+// manage can be checked with a TCP connect or an HTTP request instead.
+// Every prober records its result in the same cache with the same state
+// names, so handlers, metrics and notifications do not depend on how a
+// target was checked.
+//
+// -----------------------------------------------------------------------
+
+package checker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/afreidah/health-check-service/internal/cache"
+	"github.com/afreidah/health-check-service/internal/metrics"
+	"github.com/coreos/go-systemd/v22/dbus"
+)
+
+// Target kinds, selected by the scheme of a target string.
+const (
+	TargetDBus = "dbus"
+	TargetTCP  = "tcp"
+	TargetHTTP = "http"
+)
+
+// Target is a parsed monitoring target.
+type Target struct {
+	// Name identifies the target in the cache, metrics and /health/{name}.
+	Name string
+
+	// Kind is TargetDBus, TargetTCP or TargetHTTP.
+	Kind string
+
+	// Address is the unit name, host:port, or URL to probe.
+	Address string
+}
+
+// ParseTarget parses a target string:
+//
+//	nginx, dbus:nginx            systemd unit over D-Bus
+//	tcp:host:port, tcp://host:port  TCP connect
+//	http://host/path, https://...   HTTP GET, healthy on 2xx/3xx
+//
+// TCP and HTTP targets may be prefixed with "name=" to choose the name they
+// are reported under; otherwise the address (without scheme) is used. A
+// D-Bus target is always named after its unit.
+func ParseTarget(s string) (Target, error) {
+	spec := strings.TrimSpace(s)
+	name := ""
+	if i := strings.Index(spec, "="); i > 0 && !strings.ContainsAny(spec[:i], ":/") {
+		name, spec = spec[:i], spec[i+1:]
+	}
+
+	var t Target
+	switch {
+	case strings.HasPrefix(spec, "http://"), strings.HasPrefix(spec, "https://"):
+		u, err := url.Parse(spec)
+		if err != nil || u.Host == "" {
+			return Target{}, fmt.Errorf("invalid HTTP target %q: want http://host/path", s)
+		}
+		t = Target{Kind: TargetHTTP, Address: spec, Name: strings.TrimSuffix(u.Host+u.Path, "/")}
+
+	case strings.HasPrefix(spec, "tcp:"):
+		addr := strings.TrimPrefix(strings.TrimPrefix(spec, "tcp:"), "//")
+		if host, port, err := net.SplitHostPort(addr); err != nil || host == "" || port == "" {
+			return Target{}, fmt.Errorf("invalid TCP target %q: want tcp:host:port", s)
+		}
+		t = Target{Kind: TargetTCP, Address: addr, Name: addr}
+
+	case strings.HasPrefix(spec, "dbus:") || !strings.Contains(spec, ":"):
+		unit := strings.TrimPrefix(spec, "dbus:")
+		if unit == "" {
+			return Target{}, fmt.Errorf("invalid D-Bus target %q: want dbus:<unit>", s)
+		}
+		if err := ValidateUnitName(unit); err != nil {
+			return Target{}, fmt.Errorf("invalid D-Bus target %q: %w", s, err)
+		}
+		if name != "" && name != unit {
+			return Target{}, fmt.Errorf("invalid D-Bus target %q: D-Bus targets are named after their unit", s)
+		}
+		t = Target{Kind: TargetDBus, Address: unit, Name: unit}
+
+	default:
+		return Target{}, fmt.Errorf("unsupported target %q: want dbus:<unit>, tcp:host:port or http(s)://host/path", s)
+	}
+
+	if name != "" {
+		t.Name = name
+	}
+	return t, nil
+}
+
+// -----------------------------------------------------------------------
+// Prober Interface
+// -----------------------------------------------------------------------
+
+// Prober checks one target and records the result in its cache.
+type Prober interface {
+	// Check probes the target once and updates c. A target that is down
+	// is recorded in c and is not an error; an error means the target
+	// could not be checked at all (e.g. D-Bus is unreachable).
+	// ErrReconnectLimit stops the checker.
+	Check(ctx context.Context, service string, c cache.StatusStore) error
+
+	// Close releases the prober's resources.
+	Close()
+}
+
+// NewProber returns the prober for target. conn is the initial D-Bus
+// connection for D-Bus targets (nil connects on the first check) and is
+// ignored otherwise. timeout bounds each TCP or HTTP probe; zero selects
+// DefaultCheckTimeout.
+func NewProber(target Target, scope string, conn *dbus.Conn, timeout time.Duration) Prober {
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+
+	switch target.Kind {
+	case TargetTCP:
+		return &tcpProber{addr: target.Address, timeout: timeout}
+	case TargetHTTP:
+		return &httpProber{url: target.Address, timeout: timeout, client: &http.Client{}}
+	default:
+		return &dbusProber{conn: conn, scope: scope, connectedAt: time.Now()}
+	}
+}
+
+// -----------------------------------------------------------------------
+// D-Bus Prober
+// -----------------------------------------------------------------------
+
+// dbusProber checks a systemd unit over D-Bus, reconnecting with backoff
+// when the connection fails or, with ConfigureProactiveReconnect, when it
+// is due to be replaced.
+type dbusProber struct {
+	conn  *dbus.Conn
+	scope string
+
+	// connectedAt is when conn was opened, for proactive reconnects
+	connectedAt time.Time
+
+	// attempts counts consecutive failed reconnections across checks
+	attempts int
+}
+
+// Check runs CheckAndUpdateCacheWithReconnect and keeps the resulting
+// connection for the next check. A connection due for a proactive
+// reconnect is closed first. With ConfigureMaxConcurrentChecks it first
+// waits for a check slot; a check that cannot get one before ctx is done is
+// skipped, leaving the connection and cache untouched.
+func (p *dbusProber) Check(ctx context.Context, service string, c cache.StatusStore) error {
+	if err := acquireCheckSlot(ctx); err != nil {
+		repeatedErrors.Log(ctx, logc, slog.LevelWarn, service,
+			"no free check slot; skipping check", "service", service, "error", err.Error())
+		return fmt.Errorf("waiting for a check slot: %w", err)
+	}
+	defer releaseCheckSlot()
+
+	// Dropping the connection sends the check through the reconnection
+	// path, which only keeps a new connection that passes a check
+	if p.conn != nil && reconnectDue(p.connectedAt) {
+		logc.Info("proactively reconnecting to D-Bus",
+			"service", service,
+			"connection_age", time.Since(p.connectedAt).Round(time.Second).String())
+		p.Close()
+	}
+
+	conn, err := CheckAndUpdateCacheWithReconnect(ctx, p.conn, p.scope, service, c, &p.attempts)
+	if conn != nil && conn != p.conn {
+		p.connectedAt = time.Now()
+	}
+	p.conn = conn
+	if err != nil {
+		return err
+	}
+	if conn == nil {
+		// Reconnection was cut short by ctx
+		return fmt.Errorf("not connected to D-Bus: %w", ctx.Err())
+	}
+	return nil
+}
+
+// Close closes the current D-Bus connection, if any.
+func (p *dbusProber) Close() {
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn = nil
+	}
+}
+
+// -----------------------------------------------------------------------
+// TCP and HTTP Probers
+// -----------------------------------------------------------------------
+
+// tcpProber reports a target active when a TCP connection succeeds.
+type tcpProber struct {
+	addr    string
+	timeout time.Duration
+}
+
+// Check dials the target and closes the connection immediately.
+func (p *tcpProber) Check(ctx context.Context, service string, c cache.StatusStore) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		recordProbeFailure(ctx, service, c, "TCP probe failed", "addr", p.addr, "error", err.Error())
+		return nil
+	}
+	conn.Close()
+
+	recordProbe(service, c, StateActive)
+	return nil
+}
+
+// Close is a no-op; TCP probes hold no connection between checks.
+func (p *tcpProber) Close() {}
+
+// httpProber reports a target active when a GET returns 2xx or 3xx.
+type httpProber struct {
+	url     string
+	timeout time.Duration
+	client  *http.Client
+}
+
+// Check requests the URL and discards the body.
+func (p *httpProber) Check(ctx context.Context, service string, c cache.StatusStore) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "health-checker")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		recordProbeFailure(ctx, service, c, "HTTP probe failed", "url", p.url, "error", err.Error())
+		return nil
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 399 {
+		recordProbeFailure(ctx, service, c, "HTTP probe failed", "url", p.url, "status_code", resp.StatusCode)
+		return nil
+	}
+
+	recordProbe(service, c, StateActive)
+	return nil
+}
+
+// Close releases idle connections kept by the HTTP client.
+func (p *httpProber) Close() {
+	p.client.CloseIdleConnections()
+}
+
+// recordProbeFailure logs a failed probe (summarizing repeats) and records
+// the target as failed.
+func recordProbeFailure(ctx context.Context, service string, c cache.StatusStore, msg string, args ...any) {
+	repeatedErrors.Log(ctx, logc, slog.LevelWarn, service, msg, append([]any{"service", service}, args...)...)
+	metrics.CheckFailures.WithLabelValues(service, "probe_failed").Inc()
+	recordProbe(service, c, StateFailed)
+}
+
+// recordProbe stores a TCP or HTTP probe result using the same state
+// mapping and metrics as a D-Bus check.
+func recordProbe(service string, c cache.StatusStore, state string) {
+	updateCache(service, c, stateToStatusCode[state], state)
+
+	if stateToStatusCode[state] == http.StatusOK {
+		metrics.ServiceStatus.WithLabelValues(service, state).Set(1)
+		repeatedErrors.Reset(service)
+	} else {
+		metrics.ServiceStatus.WithLabelValues(service, state).Set(0)
+	}
+}
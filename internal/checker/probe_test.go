@@ -0,0 +1,156 @@
+// -----------------------------------------------------------------------
+// Probers - Tests
+// -----------------------------------------------------------------------
+//
+// Validates target parsing and that TCP and HTTP probes record the same
+// states and status codes as a D-Bus check.
+//
+// -----------------------------------------------------------------------
+
+package checker
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/afreidah/health-check-service/internal/cache"
+)
+
+// -----------------------------------------------------------------------
+// Target Parsing Tests
+// -----------------------------------------------------------------------
+
+// TestParseTarget verifies each target syntax, default naming, and that
+// malformed targets are rejected.
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		in        string
+		want      Target
+		shouldErr bool
+	}{
+		{"nginx", Target{Name: "nginx", Kind: TargetDBus, Address: "nginx"}, false},
+		{"dbus:nginx", Target{Name: "nginx", Kind: TargetDBus, Address: "nginx"}, false},
+		{"tcp:127.0.0.1:5432", Target{Name: "127.0.0.1:5432", Kind: TargetTCP, Address: "127.0.0.1:5432"}, false},
+		{"tcp://db:5432", Target{Name: "db:5432", Kind: TargetTCP, Address: "db:5432"}, false},
+		{"db=tcp:127.0.0.1:5432", Target{Name: "db", Kind: TargetTCP, Address: "127.0.0.1:5432"}, false},
+		{"http://localhost:9000/healthz", Target{Name: "localhost:9000/healthz", Kind: TargetHTTP, Address: "http://localhost:9000/healthz"}, false},
+		{"api=https://example.com/", Target{Name: "api", Kind: TargetHTTP, Address: "https://example.com/"}, false},
+		{"nginx=dbus:nginx", Target{Name: "nginx", Kind: TargetDBus, Address: "nginx"}, false},
+		{"web=dbus:nginx", Target{}, true},
+		{"dbus:", Target{}, true},
+		{"tcp:5432", Target{}, true},
+		{"tcp::5432", Target{}, true},
+		{"http://", Target{}, true},
+		{"udp:127.0.0.1:53", Target{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseTarget(tt.in)
+			if (err != nil) != tt.shouldErr {
+				t.Fatalf("ParseTarget(%q) error = %v, shouldErr %v", tt.in, err, tt.shouldErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTarget(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// -----------------------------------------------------------------------
+// Probe Tests
+// -----------------------------------------------------------------------
+
+// TestTCPProber verifies a listening port is active and a closed one failed.
+func TestTCPProber(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+
+	c := cache.New()
+	p := NewProber(Target{Name: "db", Kind: TargetTCP, Address: addr}, "", nil, time.Second)
+	defer p.Close()
+
+	if err := p.Check(context.Background(), "db", c); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if code, state := c.GetStatus(); code != http.StatusOK || state != StateActive {
+		t.Errorf("open port: got %d %q, want 200 %q", code, state, StateActive)
+	}
+
+	ln.Close()
+	if err := p.Check(context.Background(), "db", c); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if code, state := c.GetStatus(); code != http.StatusServiceUnavailable || state != StateFailed {
+		t.Errorf("closed port: got %d %q, want 503 %q", code, state, StateFailed)
+	}
+}
+
+// TestHTTPProber verifies 2xx and 3xx responses are active and anything
+// else, including an unreachable server, is failed.
+func TestHTTPProber(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.UserAgent(), "health-checker") {
+			t.Errorf("unexpected User-Agent %q", r.UserAgent())
+		}
+		w.WriteHeader(status)
+	}))
+
+	c := cache.New()
+	p := NewProber(Target{Name: "api", Kind: TargetHTTP, Address: srv.URL + "/healthz"}, "", nil, time.Second)
+	defer p.Close()
+
+	tests := []struct {
+		status    int
+		wantState string
+	}{
+		{http.StatusOK, StateActive},
+		{http.StatusNoContent, StateActive},
+		{http.StatusNotModified, StateActive},
+		{http.StatusNotFound, StateFailed},
+		{http.StatusInternalServerError, StateFailed},
+	}
+
+	for _, tt := range tests {
+		status = tt.status
+		if err := p.Check(context.Background(), "api", c); err != nil {
+			t.Fatalf("Check() error = %v", err)
+		}
+		if _, state := c.GetStatus(); state != tt.wantState {
+			t.Errorf("status %d: got state %q, want %q", tt.status, state, tt.wantState)
+		}
+	}
+
+	srv.Close()
+	status = http.StatusOK
+	if err := p.Check(context.Background(), "api", c); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if _, state := c.GetStatus(); state != StateFailed {
+		t.Errorf("unreachable server: got state %q, want %q", state, StateFailed)
+	}
+}
+
+// TestDBusProberWithoutConnection verifies that a check cut short before
+// D-Bus is reachable is reported as an error, so it does not count as a
+// successful check.
+func TestDBusProberWithoutConnection(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	p := NewProber(Target{Name: "nginx", Kind: TargetDBus, Address: "nginx"}, DBusScopeSystem, nil, 0)
+	defer p.Close()
+
+	if err := p.Check(ctx, "nginx", cache.New()); err == nil {
+		t.Error("Check() with cancelled context and no connection returned nil error")
+	}
+}
@@ -7,23 +7,40 @@
 // fail-fast behavior rather than runtime crashes.
 //
 // Precedence (highest to lowest): command-line flags, environment variables,
-// config file, default values. All configuration is validated before
-// returning to ensure correct operation.
+// drop-in files from the config directory, config file, default values.
+// All configuration is validated before returning to ensure correct
+// operation.
 //
 // -----------------------------------------------------------------------
 
 package config
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log/slog"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/afreidah/health-check-service/internal/cache"
+	"github.com/afreidah/health-check-service/internal/checker"
+	"github.com/afreidah/health-check-service/internal/journal"
+	"github.com/knadh/koanf/parsers/json"
+	"github.com/knadh/koanf/parsers/toml/v2"
 	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/env"
 	"github.com/knadh/koanf/providers/file"
@@ -38,18 +55,252 @@ import (
 
 // Config holds all application configuration values.
 type Config struct {
-	Port     int    `koanf:"port"`
-	Service  string `koanf:"service"`
-	Interval int    `koanf:"interval"`
+	Port     int      `koanf:"port"`
+	Service  string   `koanf:"service"`
+	Services []string `koanf:"services"`
+	Interval int      `koanf:"interval"`
+
+	// ListenAddr is the IP address the HTTP server (and the ACME challenge
+	// server) binds to. Empty listens on all interfaces.
+	ListenAddr string `koanf:"listen_addr"`
+
+	// Targets are additional monitoring targets parsed by
+	// checker.ParseTarget: dbus:<unit>, tcp:host:port or http(s)://...,
+	// optionally prefixed with name=. Service and Services are systemd
+	// units.
+	Targets []string `koanf:"target"`
+
+	// IntervalJitterPercent randomizes each check within Interval ± this
+	// percentage (0-50) to spread load across many checkers.
+	IntervalJitterPercent int `koanf:"interval_jitter_percent"`
+
+	// DBusTimeout (seconds) bounds the D-Bus calls of each check. A check
+	// that times out is treated as a connection failure and reconnects.
+	DBusTimeout int `koanf:"dbus_timeout_seconds"`
+
+	// MaxConcurrentChecks limits how many D-Bus checks run at once across
+	// all services. Zero means unlimited.
+	MaxConcurrentChecks int `koanf:"max_concurrent_checks"`
+
+	// BatchChecks reads the state of all systemd services with one
+	// ListUnitsByNames call per half interval instead of querying each
+	// unit separately.
+	BatchChecks bool `koanf:"batch_checks"`
+
+	// AdaptiveInterval slows polling of a service that stays down: after
+	// AdaptiveIntervalThreshold consecutive non-active checks the interval
+	// doubles per check, up to AdaptiveIntervalMax seconds.
+	AdaptiveInterval          bool `koanf:"adaptive_interval"`
+	AdaptiveIntervalMax       int  `koanf:"adaptive_interval_max"`
+	AdaptiveIntervalThreshold int  `koanf:"adaptive_interval_threshold"`
+
+	// HistorySize is the number of state transitions retained per service.
+	HistorySize int `koanf:"history_size"`
+
+	// AggregatePolicy decides when the aggregate /health is healthy: all
+	// (empty), any, or quorum:N services healthy.
+	AggregatePolicy string `koanf:"aggregate_policy"`
+
+	// FlapThreshold and FlapWindow (seconds) classify a service as
+	// flapping when it enters or leaves active more than FlapThreshold
+	// times within the window. Zero threshold disables detection.
+	FlapThreshold int `koanf:"flap_threshold"`
+	FlapWindow    int `koanf:"flap_window_seconds"`
+
+	// MaxReconnectAttempts caps consecutive failed D-Bus reconnection
+	// attempts before the process exits non-zero. Zero retries forever.
+	MaxReconnectAttempts int `koanf:"max_reconnect_attempts"`
+
+	// DBusReconnectInterval (seconds) replaces each D-Bus connection once
+	// it is this old, even if it still works. Zero disables it.
+	DBusReconnectInterval int `koanf:"dbus_reconnect_interval_seconds"`
+
+	// LogSummaryInterval (seconds) is how often an identical, repeating
+	// checker error is logged as a "still failing" summary instead of on
+	// every check. Zero selects 60s.
+	LogSummaryInterval int `koanf:"log_summary_interval_seconds"`
+
+	// DBusScope selects the system bus or the user session bus.
+	DBusScope string `koanf:"dbus_scope"`
+
+	// ActivatingGrace (seconds) reports a unit that is activating as
+	// healthy until it has been activating this long, so a slow start is
+	// not pulled from the load balancer but a hung start still fails.
+	// Zero disables the grace period.
+	ActivatingGrace int `koanf:"activating_grace_seconds"`
+
+	// CheckDependencies also evaluates the monitored units' hard
+	// dependencies (Requires=, Requisite=, BindsTo=) and reports the unit
+	// unhealthy when any of them is down.
+	CheckDependencies bool `koanf:"check_dependencies"`
+
+	// UnitType is the systemd unit type of the monitored names, appended
+	// as the unit suffix. Empty selects service.
+	UnitType string `koanf:"unit_type"`
+
+	// StateCodes overrides the HTTP status returned for systemd states,
+	// merged over the built-in defaults (only "active" is 200).
+	StateCodes map[string]int `koanf:"state_codes"`
+
+	// HealthBodyOK and HealthBodyFail are written as the body of healthy
+	// and failing health responses, for uptime monitors that match on the
+	// body. Empty keeps the body empty.
+	HealthBodyOK   string `koanf:"health_body_ok"`
+	HealthBodyFail string `koanf:"health_body_fail"`
+
+	// StaleStatusCode replaces the cached status on health endpoints while
+	// the cached data is stale. Zero keeps the cached status.
+	StaleStatusCode int `koanf:"stale_status_code"`
+
+	// WatchdogInterval is how often the checker watchdog runs, in seconds.
+	// WatchdogMultiplier scales Interval into the unresponsive threshold.
+	// Zero selects the defaults (10s, 2x).
+	WatchdogInterval   int     `koanf:"watchdog_interval_seconds"`
+	WatchdogMultiplier float64 `koanf:"watchdog_multiplier"`
+
+	// WebhookURL receives a JSON POST on every service state change.
+	WebhookURL string `koanf:"webhook_url"`
+
+	// SlackWebhookURL is a Slack incoming webhook notified when a service
+	// enters or leaves the active state.
+	SlackWebhookURL string `koanf:"slack_webhook_url"`
+
+	// EventLog is a file that service_up and service_down events are
+	// appended to as NDJSON, for log shippers. Reopened on SIGHUP.
+	EventLog string `koanf:"event_log"`
+
+	// HTTP Basic Auth credentials for /metrics and the dashboard. Each pair
+	// is optional; when unset the endpoint is unauthenticated.
+	MetricsAuthUser   string `koanf:"metrics_auth_user"`
+	MetricsAuthPass   string `koanf:"metrics_auth_pass"`
+	DashboardAuthUser string `koanf:"dashboard_auth_user"`
+	DashboardAuthPass string `koanf:"dashboard_auth_pass"`
+
+	// APIToken, when set, is required as a bearer token on /api/status and
+	// /api/history. Health endpoints stay unauthenticated.
+	APIToken string `koanf:"api_token"`
+
+	// RequestIDHeaders are the request headers a request ID is taken from,
+	// checked in order. A W3C traceparent contributes its trace ID. Empty
+	// always generates an ID.
+	RequestIDHeaders []string `koanf:"request_id_headers"`
+
+	// MetricsExemplars attaches request IDs as exemplars to the request
+	// duration histogram and serves /metrics in the OpenMetrics format
+	// when a scraper asks for it, which is the only format carrying them.
+	MetricsExemplars bool `koanf:"metrics_exemplars"`
+
+	// EnableDashboard, EnableAPI and EnableMetrics register the dashboard
+	// (/), the /api/* endpoints and /metrics. A disabled endpoint is not
+	// registered and answers 404.
+	EnableDashboard bool `koanf:"enable_dashboard"`
+	EnableAPI       bool `koanf:"enable_api"`
+	EnableMetrics   bool `koanf:"enable_metrics"`
+
+	// AllowRestart enables POST /admin/restart, which restarts a monitored
+	// unit over D-Bus. Requires APIToken.
+	AllowRestart bool `koanf:"allow_restart"`
+
+	// EnablePprof registers the net/http/pprof handlers under
+	// /debug/pprof/. Requires APIToken.
+	EnablePprof bool `koanf:"enable_pprof"`
+
+	// LogLines is the default and maximum number of journal entries
+	// returned by /api/logs; requests may ask for fewer.
+	LogLines int `koanf:"log_lines"`
+
+	// RequestTimeout (seconds) bounds each HTTP request; a handler that
+	// runs longer is answered with 503. Zero disables the limit.
+	RequestTimeout int `koanf:"request_timeout_seconds"`
+
+	// HTTP server timeouts in seconds: reading a request, writing a
+	// response, and keeping an idle keep-alive connection open. Zero
+	// selects the defaults (5s, 10s, 120s).
+	ReadTimeout  int `koanf:"read_timeout_seconds"`
+	WriteTimeout int `koanf:"write_timeout_seconds"`
+	IdleTimeout  int `koanf:"idle_timeout_seconds"`
+
+	// PreShutdownDelay (seconds) keeps serving in drain mode for this long
+	// after a shutdown signal, so load balancers see /health fail and stop
+	// routing before the server closes. Zero shuts down immediately.
+	PreShutdownDelay int `koanf:"pre_shutdown_delay_seconds"`
+
+	// AccessLog logs every health request at Info; when false they are
+	// logged at Debug. AccessLogSample logs only one in N health requests
+	// (0 or 1 logs all).
+	AccessLog       bool `koanf:"access_log"`
+	AccessLogSample int  `koanf:"access_log_sample"`
+
+	// StatsDAddr (host:port) enables mirroring of key metrics to a StatsD
+	// server over UDP every StatsDFlushInterval seconds (zero selects 10s).
+	// StatsDTags sends labels as DogStatsD tags instead of name segments.
+	StatsDAddr          string `koanf:"statsd_addr"`
+	StatsDFlushInterval int    `koanf:"statsd_flush_interval_seconds"`
+	StatsDTags          bool   `koanf:"statsd_tags"`
+
+	// StateFile, when set, persists the last known status of each service
+	// so a restarted health checker can serve it (marked stale) until its
+	// first check.
+	StateFile string `koanf:"state_file"`
+
+	// DashboardDir serves the dashboard from this directory instead of the
+	// embedded HTML, which remains the fallback for an empty directory.
+	DashboardDir string `koanf:"dashboard_dir"`
+
+	// DashboardPollInterval (seconds) is how often the dashboard is told to
+	// poll /api/status. Zero matches Interval, since polling faster than
+	// the checks run only returns the same result again.
+	DashboardPollInterval int `koanf:"dashboard_poll_interval_seconds"`
+
+	// Per-IP rate limits (requests/sec and burst) for each endpoint group.
+	HealthRate     float64 `koanf:"health_rate"`
+	HealthBurst    int     `koanf:"health_burst"`
+	DashboardRate  float64 `koanf:"dashboard_rate"`
+	DashboardBurst int     `koanf:"dashboard_burst"`
+	MetricsRate    float64 `koanf:"metrics_rate"`
+	MetricsBurst   int     `koanf:"metrics_burst"`
+
+	// Global rate limit per endpoint group, shared by all IPs. Zero
+	// disables it.
+	GlobalRate  float64 `koanf:"global_rate"`
+	GlobalBurst int     `koanf:"global_burst"`
+
+	// Rate limiter memory tuning, in seconds: how often idle client IPs
+	// are swept and how long they must be idle. Zero selects the defaults
+	// (300s, 600s).
+	RateLimitCleanupInterval int `koanf:"ratelimit_cleanup_interval_seconds"`
+	RateLimitIdleTimeout     int `koanf:"ratelimit_idle_timeout_seconds"`
+
+	// RateLimitMaxTrackedIPs caps the client IPs each rate limiter tracks;
+	// further IPs share one overflow bucket. Zero is unlimited.
+	RateLimitMaxTrackedIPs int `koanf:"ratelimit_max_tracked_ips"`
+
+	// RateLimitBypassCIDRs lists networks (IPv4 or IPv6) whose clients are
+	// never rate limited, such as Prometheus servers and LB health checkers.
+	RateLimitBypassCIDRs []string `koanf:"ratelimit_bypass_cidrs"`
+
+	// TrustedProxies lists networks whose X-Forwarded-For and X-Real-IP
+	// headers are honored. Empty trusts the headers from any client.
+	TrustedProxies []string `koanf:"trusted_proxies"`
 
 	TLSEnabled  bool   `koanf:"tls_enabled"`
 	TLSCertFile string `koanf:"tls_cert"`
 	TLSKeyFile  string `koanf:"tls_key"`
 
+	// TLSStrictExpiry fails startup, and rejects a reloaded certificate,
+	// when the certificate has expired or expires within
+	// TLSExpiryThresholdDays. Otherwise these are logged as warnings.
+	TLSStrictExpiry        bool `koanf:"tls_strict_expiry"`
+	TLSExpiryThresholdDays int  `koanf:"tls_expiry_threshold_days"`
+
 	TLSAutocert       bool   `koanf:"tls_autocert"`
 	TLSAutocertDomain string `koanf:"tls_autocert_domain"`
 	TLSAutocertCache  string `koanf:"tls_autocert_cache"`
 	TLSAutocertEmail  string `koanf:"tls_autocert_email"`
+
+	// CheckConfig validates the configuration, prints it and exits without
+	// connecting to D-Bus or binding a port, for gating config changes in CI.
+	CheckConfig bool `koanf:"check_config"`
 }
 
 // -----------------------------------------------------------------------
@@ -60,70 +311,370 @@ type Config struct {
 // Config. Sources are loaded in reverse precedence order (lowest to highest
 // priority). Returns a detailed error if any value is invalid.
 func Load() (*Config, error) {
-	k := koanf.New(".")
+	cfg, _, err := LoadWithSources()
+	return cfg, err
+}
+
+// LoadWithSources is Load that also reports which source supplied each
+// setting.
+func LoadWithSources() (*Config, Sources, error) {
+	sk := newSourcedKoanf()
+	k := sk.k
 
 	f := pflag.NewFlagSet("health-checker", pflag.ExitOnError)
 	f.Int("port", 8080, "port to listen on (1-65535)")
+	f.String("listen_addr", "", "IP address to listen on (default all interfaces)")
 	f.String("service", "", "systemd service to monitor (required)")
+	f.StringSlice("services", nil, "additional systemd services to monitor (comma-separated)")
+	f.StringSlice("target", nil, "additional target to monitor: dbus:<unit>, tcp:host:port or http(s)://host/path, optionally name=<target> (repeatable)")
 	f.Int("interval", 10, "check interval in seconds (minimum 1)")
-	f.String("config", "", "path to YAML config file (optional)")
+	f.Int("interval_jitter_percent", 0, "randomize each check within interval ± this percent (0-50)")
+	f.Int("dbus_timeout_seconds", 5, "timeout for each check's D-Bus calls in seconds")
+	f.Int("max_concurrent_checks", 0, "maximum D-Bus checks running at once across all services (0 = unlimited)")
+	f.Bool("batch_checks", false, "query all systemd services with one batched D-Bus call per interval")
+	f.Bool("adaptive_interval", false, "back off checks while the service is persistently down")
+	f.Int("adaptive_interval_max", 300, "maximum adaptive check interval in seconds")
+	f.Int("adaptive_interval_threshold", 5, "consecutive non-active checks before backing off")
+	f.Int("max_reconnect_attempts", 0, "exit after this many consecutive failed D-Bus reconnects (0 retries forever)")
+	f.Int("dbus_reconnect_interval_seconds", 0, "replace each D-Bus connection after this many seconds even if healthy (0 disables)")
+	f.Int("log_summary_interval_seconds", 60, "log repeated identical checker errors once per this many seconds")
+	f.String("dbus_scope", "system", "D-Bus scope: system or session (for --user units)")
+	f.Int("activating_grace_seconds", 0, "report activating as healthy for this many seconds (0 disables)")
+	f.Bool("check_dependencies", false, "also fail health when a unit's Requires/Requisite/BindsTo dependencies are down")
+	f.String("unit_type", "service", "systemd unit type to monitor: service, timer, socket, mount or target")
+	f.Int("history_size", 50, "number of state transitions retained per service")
+	f.String("aggregate_policy", "all", "services that must be healthy for /health: all, any or quorum:N")
+	f.Int("flap_threshold", 5, "active/non-active transitions within the flap window that mark a service flapping (0 disables)")
+	f.Int("flap_window_seconds", 300, "sliding window for flap detection in seconds")
+	f.String("health_body_ok", "", "body of healthy health responses (empty sends none)")
+	f.String("health_body_fail", "", "body of failing health responses (empty sends none)")
+	f.Int("stale_status_code", 0, "HTTP status for health endpoints while cached data is stale (0 keeps the cached status)")
+	f.Int("watchdog_interval_seconds", 10, "how often the checker watchdog runs, in seconds")
+	f.Float64("watchdog_multiplier", 2, "checker is unresponsive after interval x multiplier without an update")
+	f.String("webhook_url", "", "URL to POST JSON state change notifications to (optional)")
+	f.String("slack_webhook_url", "", "Slack incoming webhook URL for up/down alerts (optional)")
+	f.String("event_log", "", "file to append service_up/service_down events to as NDJSON (optional)")
+	f.String("metrics_auth_user", "", "HTTP Basic Auth username for /metrics (optional)")
+	f.String("metrics_auth_pass", "", "HTTP Basic Auth password for /metrics (optional)")
+	f.String("dashboard_auth_user", "", "HTTP Basic Auth username for the dashboard (optional)")
+	f.String("dashboard_auth_pass", "", "HTTP Basic Auth password for the dashboard (optional)")
+	f.String("api_token", "", "bearer token required for /api/status and /api/history (optional)")
+	f.Int("log_lines", 50, "journal entries returned by /api/logs (default and per-request maximum)")
+	f.Int("request_timeout_seconds", 8, "answer 503 when a request takes longer than this, in seconds (0 disables)")
+	f.Int("read_timeout_seconds", 5, "HTTP server timeout for reading a request, in seconds")
+	f.Int("write_timeout_seconds", 10, "HTTP server timeout for writing a response, in seconds")
+	f.Int("idle_timeout_seconds", 120, "how long idle keep-alive connections stay open, in seconds")
+	f.Int("pre_shutdown_delay_seconds", 0, "on shutdown, serve 503 from health endpoints for this many seconds before closing (0 disables)")
+	f.StringSlice("request_id_headers", []string{"X-Request-ID"}, "request headers to take the request ID from, checked in order; traceparent contributes its trace ID (comma-separated)")
+	f.Bool("metrics_exemplars", false, "attach request IDs as exemplars to request latency (served with OpenMetrics)")
+	f.Bool("enable_dashboard", true, "serve the dashboard at / (404 when disabled)")
+	f.Bool("enable_api", true, "serve /api/status, /api/history and /api/logs (404 when disabled)")
+	f.Bool("enable_metrics", true, "serve Prometheus metrics at /metrics (404 when disabled)")
+	f.Bool("allow_restart", false, "enable POST /admin/restart to restart the monitored unit (requires api_token)")
+	f.Bool("enable_pprof", false, "serve runtime profiles under /debug/pprof/ (requires api_token)")
+	f.Bool("access_log", true, "log each health request at info level (debug when false)")
+	f.Int("access_log_sample", 1, "log only one in N health requests (1 logs all)")
+	f.String("statsd_addr", "", "StatsD server (host:port) to mirror key metrics to over UDP (optional)")
+	f.Int("statsd_flush_interval_seconds", 10, "how often metrics are sent to StatsD, in seconds")
+	f.Bool("statsd_tags", true, "send labels as DogStatsD tags (false appends them to the metric name)")
+	f.String("state_file", "", "JSON file to persist the last known status across restarts (optional)")
+	f.String("dashboard_dir", "", "directory to serve the dashboard from instead of the embedded one (optional)")
+	f.Int("dashboard_poll_interval_seconds", 0, "how often the dashboard refreshes, in seconds (0 matches interval)")
+	f.Float64("health_rate", 100, "rate limit for health endpoints (req/sec per IP)")
+	f.Int("health_burst", 200, "burst size for health endpoints")
+	f.Float64("dashboard_rate", 10, "rate limit for dashboard and API (req/sec per IP)")
+	f.Int("dashboard_burst", 20, "burst size for dashboard and API")
+	f.Float64("metrics_rate", 2, "rate limit for /metrics (req/sec per IP)")
+	f.Int("metrics_burst", 10, "burst size for /metrics")
+	f.Float64("global_rate", 0, "total rate limit per endpoint group across all IPs (req/sec, 0 disables)")
+	f.Int("global_burst", 0, "burst size for the global rate limit")
+	f.Int("ratelimit_cleanup_interval_seconds", 300, "how often idle client IPs are removed from rate limiters")
+	f.Int("ratelimit_idle_timeout_seconds", 600, "how long a client IP must be idle before it is removed")
+	f.Int("ratelimit_max_tracked_ips", 0, "client IPs tracked per rate limiter before new IPs share one overflow limit (0 = unlimited)")
+	f.StringSlice("ratelimit_bypass_cidrs", nil, "CIDRs exempt from rate limiting (comma-separated)")
+	f.StringSlice("trusted_proxies", nil, "proxy CIDRs whose X-Forwarded-For/X-Real-IP headers are trusted (comma-separated)")
+	f.String("config", "", "path to YAML, JSON, or TOML config file (optional)")
+	f.String("config_dir", "", "directory of drop-in config files merged in lexical order after --config (optional)")
+	f.String("env_prefix", "", "prefix of environment variables to read settings from (default HEALTH_, or HEALTH_ENV_PREFIX)")
 	f.Bool("tls_enabled", false, "enable HTTPS/TLS with manual certificates")
 	f.String("tls_cert", "", "path to TLS certificate file (PEM format)")
 	f.String("tls_key", "", "path to TLS private key file (PEM format)")
+	f.Bool("tls_strict_expiry", false, "fail when the TLS certificate is expired or within tls_expiry_threshold_days of expiry, instead of warning")
+	f.Int("tls_expiry_threshold_days", 7, "days before TLS certificate expiry to warn, or fail with tls_strict_expiry")
 	f.Bool("tls_autocert", false, "enable Let's Encrypt automatic certificates")
 	f.String("tls_autocert_domain", "", "domain name for Let's Encrypt certificate")
 	f.String("tls_autocert_cache", "/var/cache/health-checker", "directory for certificate cache")
 	f.String("tls_autocert_email", "", "email for Let's Encrypt notifications (optional)")
+	f.Bool("check_config", false, "validate the configuration, print it as YAML and exit (0 valid, 1 invalid)")
 
 	if err := f.Parse(os.Args[1:]); err != nil {
-		return nil, fmt.Errorf("error parsing command-line flags: %w", err)
+		return nil, nil, fmt.Errorf("error parsing command-line flags: %w", err)
 	}
 
 	// Load config file if specified
 	configPath, _ := f.GetString("config")
 	if configPath != "" {
 		if _, err := os.Stat(configPath); err != nil {
-			return nil, fmt.Errorf("config file not found: %s (error: %w)", configPath, err)
+			return nil, nil, fmt.Errorf("config file not found: %s (error: %w)", configPath, err)
+		}
+
+		parser, err := parserForFile(configPath)
+		if err != nil {
+			return nil, nil, err
 		}
 
 		slog.Info("loading configuration from file", "path", configPath)
-		if err := k.Load(file.Provider(configPath), yaml.Parser()); err != nil {
-			return nil, fmt.Errorf("error parsing config file (%s): %w", configPath, err)
+		if err := sk.load("file:"+configPath, file.Provider(configPath), parser); err != nil {
+			return nil, nil, fmt.Errorf("error parsing config file (%s): %w", configPath, err)
 		}
 	} else {
 		slog.Debug("no config file specified, using defaults and environment")
 	}
 
-	// Load environment variables with HEALTH_ prefix
-	if err := k.Load(env.Provider("HEALTH_", ".", func(s string) string {
-		return strings.ToLower(strings.TrimPrefix(s, "HEALTH_"))
+	flagPrefix, _ := f.GetString("env_prefix")
+	prefix := envPrefix(flagPrefix)
+
+	// Drop-in files override the config file, like systemd's conf.d. The
+	// directory is needed before the environment is loaded, so its variable
+	// is read directly
+	configDir, _ := f.GetString("config_dir")
+	if !f.Changed("config_dir") {
+		configDir = os.Getenv(prefix + "CONFIG_DIR")
+	}
+	if configDir != "" {
+		if err := loadConfigDir(sk, configDir); err != nil {
+			return nil, nil, withEnvPrefix(err, prefix)
+		}
+	}
+
+	// Load environment variables with the configured prefix; list settings
+	// are comma-separated like their flags
+	if err := sk.load("env", env.ProviderWithValue(prefix, ".", func(key, value string) (string, interface{}) {
+		key = strings.ToLower(strings.TrimPrefix(key, prefix))
+		if listEnvKeys[key] {
+			return key, strings.Split(value, ",")
+		}
+		return key, value
 	}), nil); err != nil {
-		return nil, fmt.Errorf("error loading environment variables: %w", err)
+		return nil, nil, fmt.Errorf("error loading environment variables: %w", err)
 	}
 
 	// Load command-line flags (highest priority)
 	if err := k.Load(posflag.Provider(f, ".", k), nil); err != nil {
-		return nil, fmt.Errorf("error loading command-line flags: %w", err)
+		return nil, nil, fmt.Errorf("error loading command-line flags: %w", err)
 	}
+	f.Visit(func(flag *pflag.Flag) {
+		sk.sources[flag.Name] = "flag"
+	})
 
 	cfg := &Config{}
 	if err := k.Unmarshal("", cfg); err != nil {
-		return nil, fmt.Errorf("error unmarshaling configuration: %w", err)
+		return nil, nil, fmt.Errorf("error unmarshaling configuration: %w", err)
 	}
 
 	if err := cfg.Validate(); err != nil {
-		return nil, err
+		return nil, nil, withEnvPrefix(err, prefix)
 	}
 
 	slog.Info("configuration loaded successfully",
 		"service", cfg.Service,
+		"services", cfg.MonitoredServices(),
 		"port", cfg.Port,
+		"listen_addr", cfg.ListenAddr,
 		"interval_sec", cfg.Interval,
+		"dbus_scope", cfg.DBusScope,
+		"unit_type", cfg.UnitType,
 		"tls_enabled", cfg.TLSEnabled,
 		"tls_autocert", cfg.TLSAutocert,
 	)
 
-	return cfg, nil
+	return cfg, sk.sources, nil
+}
+
+// defaultEnvPrefix is the prefix of environment variables read as settings.
+const defaultEnvPrefix = "HEALTH_"
+
+// envPrefixVar overrides the environment prefix. Its name is fixed, since
+// the prefix must be known before any other variable can be read.
+const envPrefixVar = "HEALTH_ENV_PREFIX"
+
+// envPrefix returns the environment variable prefix: the --env_prefix
+// flag, then HEALTH_ENV_PREFIX, then HEALTH_. A trailing underscore is
+// added when missing, so WEB and WEB_ both read WEB_PORT.
+func envPrefix(flagValue string) string {
+	prefix := flagValue
+	if prefix == "" {
+		prefix = os.Getenv(envPrefixVar)
+	}
+	if prefix == "" {
+		return defaultEnvPrefix
+	}
+	if !strings.HasSuffix(prefix, "_") {
+		prefix += "_"
+	}
+	return prefix
+}
+
+// CheckConfigRequested reports whether args or the environment select
+// --check_config. It is resolved ahead of Load so logging can be moved off
+// stdout, which carries the printed configuration, before Load logs.
+func CheckConfigRequested(args []string) bool {
+	f := pflag.NewFlagSet("health-checker", pflag.ContinueOnError)
+	f.ParseErrorsWhitelist.UnknownFlags = true
+	f.Usage = func() {}
+	f.SetOutput(io.Discard)
+	checkConfig := f.Bool("check_config", false, "")
+	flagPrefix := f.String("env_prefix", "", "")
+
+	// Errors such as --help are reported by Load itself
+	_ = f.Parse(args)
+	if f.Changed("check_config") {
+		return *checkConfig
+	}
+
+	requested, _ := strconv.ParseBool(os.Getenv(envPrefix(*flagPrefix) + "CHECK_CONFIG"))
+	return requested
+}
+
+// hintError is a configuration error whose hints name environment
+// variables under a prefix other than HEALTH_.
+type hintError struct {
+	err    error
+	prefix string
+}
+
+// Error returns the wrapped message with HEALTH_ replaced by the prefix in
+// its "use:" and "specify with:" hint lines.
+func (e *hintError) Error() string {
+	lines := strings.Split(e.err.Error(), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "use:") || strings.HasPrefix(line, "specify with:") {
+			lines[i] = strings.ReplaceAll(line, defaultEnvPrefix, e.prefix)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Unwrap returns the original error.
+func (e *hintError) Unwrap() error {
+	return e.err
+}
+
+// withEnvPrefix makes the hints of err name the variables read under
+// prefix. Hints are written for the default prefix, so err is returned
+// unchanged when that is in use.
+func withEnvPrefix(err error, prefix string) error {
+	if prefix == defaultEnvPrefix {
+		return err
+	}
+	return &hintError{err: err, prefix: prefix}
+}
+
+// listEnvKeys are settings whose environment variables hold
+// comma-separated lists.
+var listEnvKeys = map[string]bool{
+	"services":               true,
+	"target":                 true,
+	"ratelimit_bypass_cidrs": true,
+	"trusted_proxies":        true,
+	"request_id_headers":     true,
+}
+
+// Sources maps each setting name to the source that supplied its value:
+// "flag", "env", or "file:<path>". Settings left at their default are
+// absent.
+type Sources map[string]string
+
+// Of returns the source of setting key, or "default" when no source set it.
+func (s Sources) Of(key string) string {
+	if source, ok := s[key]; ok {
+		return source
+	}
+	return "default"
+}
+
+// sourcedKoanf is a koanf instance that records which source last set
+// each top-level setting.
+type sourcedKoanf struct {
+	k       *koanf.Koanf
+	sources Sources
+}
+
+// newSourcedKoanf returns an empty sourcedKoanf.
+func newSourcedKoanf() *sourcedKoanf {
+	return &sourcedKoanf{k: koanf.New("."), sources: make(Sources)}
+}
+
+// load reads p into its own koanf instance, attributes every setting it
+// contains to source, and merges it over what is already loaded. Nested
+// keys such as state_codes.failed are attributed to their top-level
+// setting.
+func (sk *sourcedKoanf) load(source string, p koanf.Provider, parser koanf.Parser) error {
+	layer := koanf.New(".")
+	if err := layer.Load(p, parser); err != nil {
+		return err
+	}
+	for _, key := range layer.Keys() {
+		name, _, _ := strings.Cut(key, ".")
+		sk.sources[name] = source
+	}
+	return sk.k.Merge(layer)
+}
+
+// configDirExtensions are the drop-in file extensions loaded from the
+// config directory; other files, such as editor backups, are skipped.
+var configDirExtensions = map[string]bool{
+	".yaml": true,
+	".yml":  true,
+	".json": true,
+	".toml": true,
+}
+
+// loadConfigDir merges every drop-in file in dir into sk in lexical order,
+// so later files override earlier ones. The directory was named
+// explicitly, so it not existing is an error.
+func loadConfigDir(sk *sourcedKoanf, dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("cannot read config directory (%s): %w\n"+
+			"use: --config_dir /etc/health-checker/conf.d or HEALTH_CONFIG_DIR=...", dir, err)
+	}
+
+	// ReadDir returns entries sorted by filename
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || !configDirExtensions[ext] {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		parser, err := parserForFile(path)
+		if err != nil {
+			return err
+		}
+
+		slog.Info("loading configuration drop-in", "path", path)
+		if err := sk.load("file:"+path, file.Provider(path), parser); err != nil {
+			return fmt.Errorf("error parsing config file (%s): %w", path, err)
+		}
+	}
+	return nil
+}
+
+// parserForFile selects a koanf parser from the config file extension.
+// Files without an extension are parsed as YAML.
+func parserForFile(path string) (koanf.Parser, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case "", ".yaml", ".yml":
+		return yaml.Parser(), nil
+	case ".json":
+		return json.Parser(), nil
+	case ".toml":
+		return toml.Parser(), nil
+	default:
+		return nil, fmt.Errorf("unsupported config file extension %q (%s)\n"+
+			"supported formats: .yaml, .yml, .json, .toml", ext, path)
+	}
 }
 
 // -----------------------------------------------------------------------
@@ -143,15 +694,63 @@ func (c *Config) Validate() error {
 			c.Port)
 	}
 
-	// Service name validation
-	if c.Service == "" {
+	if c.ListenAddr != "" && net.ParseIP(c.ListenAddr) == nil {
+		return fmt.Errorf(
+			"invalid listen address %q: must be an IP address or empty for all interfaces\n"+
+				"use: --listen_addr 127.0.0.1 or HEALTH_LISTEN_ADDR=127.0.0.1", c.ListenAddr)
+	}
+
+	// Service name validation; targets alone are enough to monitor
+	if c.Service == "" && len(c.Targets) == 0 {
 		return fmt.Errorf(
 			"service name is required\n" +
 				"specify with: --service nginx or HEALTH_SERVICE=nginx or config file")
 	}
 
-	if strings.Contains(c.Service, " ") || strings.Contains(c.Service, "\t") {
-		return fmt.Errorf("service name cannot contain whitespace: %q", c.Service)
+	for _, name := range append([]string{c.Service}, c.Services...) {
+		if strings.Contains(name, " ") || strings.Contains(name, "\t") {
+			return fmt.Errorf("service name cannot contain whitespace: %q", name)
+		}
+		if name == "" {
+			continue
+		}
+		if err := checker.ValidateUnitName(name); err != nil {
+			return fmt.Errorf("%w\n"+
+				"use: --service nginx or HEALTH_SERVICE=nginx (templated units as getty@tty1)", err)
+		}
+	}
+
+	// Targets must parse and must not reuse a name
+	names := make(map[string]string)
+	for _, name := range append([]string{c.Service}, c.Services...) {
+		names[strings.TrimSpace(name)] = name
+	}
+	for _, spec := range c.Targets {
+		target, err := checker.ParseTarget(spec)
+		if err != nil {
+			return fmt.Errorf("%w\n"+
+				"use: --target tcp:127.0.0.1:5432 or HEALTH_TARGET=tcp:127.0.0.1:5432", err)
+		}
+		if prev, ok := names[target.Name]; ok && target.Kind != checker.TargetDBus {
+			return fmt.Errorf(
+				"target %q is named %q, which is already used by %q\n"+
+					"use: --target name=%s to choose another name", spec, target.Name, prev, spec)
+		}
+		names[target.Name] = spec
+	}
+
+	// Aggregate policy validation; a quorum larger than the number of
+	// services could never be met
+	policy, err := cache.ParseAggregatePolicy(c.AggregatePolicy)
+	if err != nil {
+		return fmt.Errorf("%w\n"+
+			"use: --aggregate_policy quorum:2 or HEALTH_AGGREGATE_POLICY=quorum:2", err)
+	}
+	if n := len(c.MonitoredServices()); policy.Mode == cache.PolicyQuorum && policy.Quorum > n {
+		return fmt.Errorf(
+			"aggregate quorum of %d exceeds the %d monitored services\n"+
+				"use: --aggregate_policy quorum:%d or HEALTH_AGGREGATE_POLICY=quorum:%d",
+			policy.Quorum, n, n, n)
 	}
 
 	// Interval validation
@@ -166,6 +765,352 @@ func (c *Config) Validate() error {
 		slog.Warn("unusually long check interval", "interval_sec", c.Interval)
 	}
 
+	// D-Bus timeout validation (zero selects the default)
+	if c.DBusTimeout < 0 {
+		return fmt.Errorf(
+			"D-Bus timeout must be positive, got %d\n"+
+				"use: --dbus_timeout_seconds 5 or HEALTH_DBUS_TIMEOUT_SECONDS=5",
+			c.DBusTimeout)
+	}
+
+	if c.DBusTimeout > c.Interval {
+		slog.Warn("D-Bus timeout exceeds check interval; slow checks will delay the next one",
+			"dbus_timeout_sec", c.DBusTimeout,
+			"interval_sec", c.Interval)
+	}
+
+	if c.MaxConcurrentChecks < 0 {
+		return fmt.Errorf(
+			"max concurrent checks must not be negative, got %d\n"+
+				"use: --max_concurrent_checks 10 or HEALTH_MAX_CONCURRENT_CHECKS=10 (0 = unlimited)",
+			c.MaxConcurrentChecks)
+	}
+
+	if c.IntervalJitterPercent < 0 || c.IntervalJitterPercent > 50 {
+		return fmt.Errorf(
+			"interval jitter must be between 0-50 percent, got %d\n"+
+				"use: --interval_jitter_percent 10 or HEALTH_INTERVAL_JITTER_PERCENT=10",
+			c.IntervalJitterPercent)
+	}
+
+	if c.AdaptiveInterval {
+		if c.AdaptiveIntervalMax < c.Interval {
+			return fmt.Errorf(
+				"adaptive interval max (%d) must be at least the check interval (%d)\n"+
+					"use: --adaptive_interval_max 300 or HEALTH_ADAPTIVE_INTERVAL_MAX=300",
+				c.AdaptiveIntervalMax, c.Interval)
+		}
+		if c.AdaptiveIntervalThreshold < 1 {
+			return fmt.Errorf(
+				"adaptive interval threshold must be at least 1, got %d\n"+
+					"use: --adaptive_interval_threshold 5 or HEALTH_ADAPTIVE_INTERVAL_THRESHOLD=5",
+				c.AdaptiveIntervalThreshold)
+		}
+	}
+
+	if c.HistorySize < 0 {
+		return fmt.Errorf(
+			"history size cannot be negative, got %d\n"+
+				"use: --history_size 50 or HEALTH_HISTORY_SIZE=50",
+			c.HistorySize)
+	}
+
+	// Flap detection only sees transitions still in the history buffer
+	if c.FlapThreshold < 0 {
+		return fmt.Errorf(
+			"flap threshold cannot be negative, got %d\n"+
+				"use: --flap_threshold 5 or HEALTH_FLAP_THRESHOLD=5 (0 disables)",
+			c.FlapThreshold)
+	}
+
+	if c.FlapThreshold > 0 {
+		if c.FlapWindow < 1 {
+			return fmt.Errorf(
+				"flap window must be at least 1 second when flap detection is enabled, got %d\n"+
+					"use: --flap_window_seconds 300 or HEALTH_FLAP_WINDOW_SECONDS=300",
+				c.FlapWindow)
+		}
+
+		historySize := c.HistorySize
+		if historySize == 0 {
+			historySize = cache.DefaultHistorySize
+		}
+		if c.FlapThreshold >= historySize {
+			return fmt.Errorf(
+				"flap threshold (%d) must be below history size (%d) or flapping is never detected\n"+
+					"use: --history_size %d or a lower --flap_threshold",
+				c.FlapThreshold, historySize, c.FlapThreshold*2)
+		}
+	}
+
+	// Watchdog tuning (zero selects the defaults)
+	if c.WatchdogInterval < 0 {
+		return fmt.Errorf(
+			"watchdog interval cannot be negative, got %d\n"+
+				"use: --watchdog_interval_seconds 10 or HEALTH_WATCHDOG_INTERVAL_SECONDS=10",
+			c.WatchdogInterval)
+	}
+
+	if c.WatchdogMultiplier != 0 && c.WatchdogMultiplier < 1 {
+		return fmt.Errorf(
+			"watchdog multiplier must be at least 1, got %v\n"+
+				"a smaller value flags the checker as unresponsive between checks\n"+
+				"use: --watchdog_multiplier 3 or HEALTH_WATCHDOG_MULTIPLIER=3",
+			c.WatchdogMultiplier)
+	}
+
+	if c.MaxReconnectAttempts < 0 {
+		return fmt.Errorf(
+			"max reconnect attempts cannot be negative, got %d\n"+
+				"use: --max_reconnect_attempts 10 or HEALTH_MAX_RECONNECT_ATTEMPTS=10 (0 retries forever)",
+			c.MaxReconnectAttempts)
+	}
+
+	if c.DBusReconnectInterval < 0 {
+		return fmt.Errorf(
+			"D-Bus reconnect interval cannot be negative, got %d\n"+
+				"use: --dbus_reconnect_interval_seconds 3600 or HEALTH_DBUS_RECONNECT_INTERVAL_SECONDS=3600 (0 disables)",
+			c.DBusReconnectInterval)
+	}
+
+	if c.LogSummaryInterval < 0 {
+		return fmt.Errorf(
+			"log summary interval cannot be negative, got %d\n"+
+				"use: --log_summary_interval_seconds 60 or HEALTH_LOG_SUMMARY_INTERVAL_SECONDS=60",
+			c.LogSummaryInterval)
+	}
+
+	// D-Bus scope validation (empty defaults to the system bus)
+	if c.DBusScope != "" && c.DBusScope != "system" && c.DBusScope != "session" {
+		return fmt.Errorf(
+			"invalid D-Bus scope: must be system or session, got %q\n"+
+				"use: --dbus_scope session or HEALTH_DBUS_SCOPE=session",
+			c.DBusScope)
+	}
+
+	if c.ActivatingGrace < 0 {
+		return fmt.Errorf(
+			"activating grace period cannot be negative, got %d\n"+
+				"use: --activating_grace_seconds 60 or HEALTH_ACTIVATING_GRACE_SECONDS=60 (0 disables)",
+			c.ActivatingGrace)
+	}
+
+	// Unit type validation (empty defaults to service)
+	switch c.UnitType {
+	case "", "service", "timer", "socket", "mount", "target":
+	default:
+		return fmt.Errorf(
+			"invalid unit type: must be service, timer, socket, mount or target, got %q\n"+
+				"use: --unit_type timer or HEALTH_UNIT_TYPE=timer",
+			c.UnitType)
+	}
+
+	// State code overrides must map to real HTTP status codes
+	for state, code := range c.StateCodes {
+		if code < 100 || code > 599 {
+			return fmt.Errorf(
+				"invalid status code for state %q: must be between 100-599, got %d\n"+
+					"example config file entry: state_codes: { activating: 200 }",
+				state, code)
+		}
+	}
+
+	if c.StaleStatusCode != 0 && (c.StaleStatusCode < 100 || c.StaleStatusCode > 599) {
+		return fmt.Errorf(
+			"invalid stale status code: must be between 100-599 or 0 to keep the cached status, got %d\n"+
+				"use: --stale_status_code 503 or HEALTH_STALE_STATUS_CODE=503",
+			c.StaleStatusCode)
+	}
+
+	if c.WebhookURL != "" {
+		if err := validateWebhookURL(c.WebhookURL); err != nil {
+			return fmt.Errorf("invalid webhook URL: %w\n"+
+				"use: --webhook_url https://hooks.example.com/health or HEALTH_WEBHOOK_URL=...", err)
+		}
+	}
+
+	if c.SlackWebhookURL != "" {
+		if err := validateWebhookURL(c.SlackWebhookURL); err != nil {
+			return fmt.Errorf("invalid Slack webhook URL: %w\n"+
+				"use: --slack_webhook_url https://hooks.slack.com/services/... or HEALTH_SLACK_WEBHOOK_URL=...", err)
+		}
+	}
+
+	// Basic Auth credentials must be configured as complete pairs
+	authPairs := []struct {
+		name, user, pass string
+	}{
+		{"metrics", c.MetricsAuthUser, c.MetricsAuthPass},
+		{"dashboard", c.DashboardAuthUser, c.DashboardAuthPass},
+	}
+	for _, pair := range authPairs {
+		if (pair.user == "") != (pair.pass == "") {
+			return fmt.Errorf(
+				"%s auth requires both a username and a password\n"+
+					"use: --%s_auth_user admin --%s_auth_pass <secret> or HEALTH_%s_AUTH_USER/HEALTH_%s_AUTH_PASS",
+				pair.name, pair.name, pair.name, strings.ToUpper(pair.name), strings.ToUpper(pair.name))
+		}
+	}
+
+	if c.LogLines < 0 || c.LogLines > journal.MaxLines {
+		return fmt.Errorf(
+			"log lines must be between 0 and %d, got %d\n"+
+				"use: --log_lines 50 or HEALTH_LOG_LINES=50",
+			journal.MaxLines, c.LogLines)
+	}
+
+	if c.RequestTimeout < 0 {
+		return fmt.Errorf(
+			"request timeout must not be negative, got %d\n"+
+				"use: --request_timeout_seconds 8 or HEALTH_REQUEST_TIMEOUT_SECONDS=8",
+			c.RequestTimeout)
+	}
+
+	// HTTP server timeouts (zero selects the defaults)
+	serverTimeouts := []struct {
+		name  string
+		value int
+	}{
+		{"read_timeout_seconds", c.ReadTimeout},
+		{"write_timeout_seconds", c.WriteTimeout},
+		{"idle_timeout_seconds", c.IdleTimeout},
+	}
+	for _, timeout := range serverTimeouts {
+		if timeout.value < 0 {
+			return fmt.Errorf(
+				"%s must be positive, got %d\n"+
+					"use: --%s N or HEALTH_%s=N",
+				timeout.name, timeout.value, timeout.name, strings.ToUpper(timeout.name))
+		}
+	}
+
+	if c.PreShutdownDelay < 0 {
+		return fmt.Errorf(
+			"pre-shutdown delay cannot be negative, got %d\n"+
+				"use: --pre_shutdown_delay_seconds 15 or HEALTH_PRE_SHUTDOWN_DELAY_SECONDS=15 (0 disables)",
+			c.PreShutdownDelay)
+	}
+
+	if c.RequestTimeout > 0 && c.WriteTimeout > 0 && c.RequestTimeout >= c.WriteTimeout {
+		slog.Warn("request timeout is not below the write timeout; slow requests are cut off without a 503",
+			"request_timeout_sec", c.RequestTimeout,
+			"write_timeout_sec", c.WriteTimeout)
+	}
+
+	// The dashboard renders /api/status
+	if c.EnableDashboard && !c.EnableAPI {
+		slog.Warn("dashboard is enabled but the API is disabled; the dashboard will not show any status")
+	}
+
+	// Restarting units must never be reachable without authentication
+	if c.AllowRestart && c.APIToken == "" {
+		return fmt.Errorf(
+			"allow_restart requires an API token\n" +
+				"use: --api_token <secret> or HEALTH_API_TOKEN=...")
+	}
+
+	// Profiles expose memory contents and can stall the process
+	if c.EnablePprof && c.APIToken == "" {
+		return fmt.Errorf(
+			"enable_pprof requires an API token\n" +
+				"use: --api_token <secret> or HEALTH_API_TOKEN=...")
+	}
+
+	if c.AccessLogSample < 0 {
+		return fmt.Errorf(
+			"access log sample cannot be negative, got %d\n"+
+				"use: --access_log_sample 10 or HEALTH_ACCESS_LOG_SAMPLE=10",
+			c.AccessLogSample)
+	}
+
+	if c.StatsDAddr != "" {
+		if _, _, err := net.SplitHostPort(c.StatsDAddr); err != nil {
+			return fmt.Errorf("invalid StatsD address %q: %w\n"+
+				"use: --statsd_addr 127.0.0.1:8125 or HEALTH_STATSD_ADDR=127.0.0.1:8125", c.StatsDAddr, err)
+		}
+	}
+
+	if c.StatsDFlushInterval < 0 {
+		return fmt.Errorf(
+			"StatsD flush interval cannot be negative, got %d\n"+
+				"use: --statsd_flush_interval_seconds 10 or HEALTH_STATSD_FLUSH_INTERVAL_SECONDS=10",
+			c.StatsDFlushInterval)
+	}
+
+	if c.DashboardDir != "" {
+		info, err := os.Stat(c.DashboardDir)
+		if err != nil {
+			return fmt.Errorf("cannot access dashboard directory (%s): %w\n"+
+				"use: --dashboard_dir /path/to/dashboard or HEALTH_DASHBOARD_DIR=...", c.DashboardDir, err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("dashboard path is not a directory: %s\n"+
+				"use: --dashboard_dir /path/to/dashboard or HEALTH_DASHBOARD_DIR=...", c.DashboardDir)
+		}
+	}
+
+	if c.DashboardPollInterval < 0 {
+		return fmt.Errorf(
+			"dashboard poll interval cannot be negative, got %d\n"+
+				"use: --dashboard_poll_interval_seconds 10 or HEALTH_DASHBOARD_POLL_INTERVAL_SECONDS=10",
+			c.DashboardPollInterval)
+	}
+
+	// Rate limits must be non-negative
+	rateLimits := []struct {
+		name  string
+		value float64
+	}{
+		{"health_rate", c.HealthRate},
+		{"health_burst", float64(c.HealthBurst)},
+		{"dashboard_rate", c.DashboardRate},
+		{"dashboard_burst", float64(c.DashboardBurst)},
+		{"metrics_rate", c.MetricsRate},
+		{"metrics_burst", float64(c.MetricsBurst)},
+		{"global_rate", c.GlobalRate},
+		{"global_burst", float64(c.GlobalBurst)},
+		{"ratelimit_cleanup_interval_seconds", float64(c.RateLimitCleanupInterval)},
+		{"ratelimit_idle_timeout_seconds", float64(c.RateLimitIdleTimeout)},
+		{"ratelimit_max_tracked_ips", float64(c.RateLimitMaxTrackedIPs)},
+	}
+	for _, rl := range rateLimits {
+		if rl.value < 0 {
+			return fmt.Errorf(
+				"%s cannot be negative, got %v\n"+
+					"use: --%s <value> or HEALTH_%s=<value>",
+				rl.name, rl.value, rl.name, strings.ToUpper(rl.name))
+		}
+	}
+
+	// CIDR lists must parse; a typo would silently throttle or trust the
+	// wrong clients
+	cidrLists := []struct {
+		name, key string
+		cidrs     []string
+	}{
+		{"rate limit bypass", "ratelimit_bypass_cidrs", c.RateLimitBypassCIDRs},
+		{"trusted proxy", "trusted_proxies", c.TrustedProxies},
+	}
+	for _, list := range cidrLists {
+		for _, cidr := range list.cidrs {
+			if _, _, err := net.ParseCIDR(strings.TrimSpace(cidr)); err != nil {
+				return fmt.Errorf(
+					"invalid %s CIDR %q\n"+
+						"use: --%s 10.0.0.0/8,fd00::/8 or HEALTH_%s=...",
+					list.name, cidr, list.key, strings.ToUpper(list.key))
+			}
+		}
+	}
+
+	for _, header := range c.RequestIDHeaders {
+		if header = strings.TrimSpace(header); header == "" || strings.ContainsAny(header, " \t:") {
+			return fmt.Errorf(
+				"invalid request ID header name %q\n"+
+					"use: --request_id_headers X-Request-ID,traceparent or HEALTH_REQUEST_ID_HEADERS=...",
+				header)
+		}
+	}
+
 	// TLS configuration validation
 	if c.TLSEnabled && c.TLSAutocert {
 		return fmt.Errorf(
@@ -190,6 +1135,139 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// ListenAddress returns the host:port to bind for port, using ListenAddr
+// as the host.
+func (c *Config) ListenAddress(port int) string {
+	return net.JoinHostPort(c.ListenAddr, strconv.Itoa(port))
+}
+
+// DashboardPollSeconds returns how often the dashboard should poll
+// /api/status: DashboardPollInterval, or Interval when it is zero.
+func (c *Config) DashboardPollSeconds() int {
+	if c.DashboardPollInterval > 0 {
+		return c.DashboardPollInterval
+	}
+	return c.Interval
+}
+
+// MonitoredServices returns the primary service followed by any additional
+// services, with duplicates and empty entries removed. Order is preserved so
+// that aggregation and logging are deterministic.
+func (c *Config) MonitoredServices() []string {
+	var out []string
+	for _, target := range c.monitoredTargets() {
+		out = append(out, target.Name)
+	}
+	return out
+}
+
+// MonitoredTargets returns how each monitored service is checked, keyed
+// by name. Service and Services are D-Bus targets.
+func (c *Config) MonitoredTargets() map[string]checker.Target {
+	out := make(map[string]checker.Target)
+	for _, target := range c.monitoredTargets() {
+		out[target.Name] = target
+	}
+	return out
+}
+
+// SystemdServices returns the monitored services checked over D-Bus, in
+// configuration order. Only these have a unit to restart or read logs for.
+func (c *Config) SystemdServices() []string {
+	var out []string
+	for _, target := range c.monitoredTargets() {
+		if target.Kind == checker.TargetDBus {
+			out = append(out, target.Name)
+		}
+	}
+	return out
+}
+
+// monitoredTargets returns the primary service, additional services, and
+// targets in configuration order, with duplicates, empty entries, and
+// unparseable targets (rejected by Validate) removed.
+func (c *Config) monitoredTargets() []checker.Target {
+	seen := make(map[string]bool, len(c.Services)+len(c.Targets)+1)
+	out := make([]checker.Target, 0, len(c.Services)+len(c.Targets)+1)
+	add := func(target checker.Target) {
+		if target.Name == "" || seen[target.Name] {
+			return
+		}
+		seen[target.Name] = true
+		out = append(out, target)
+	}
+
+	for _, name := range append([]string{c.Service}, c.Services...) {
+		name = strings.TrimSpace(name)
+		add(checker.Target{Name: name, Kind: checker.TargetDBus, Address: name})
+	}
+	for _, spec := range c.Targets {
+		if target, err := checker.ParseTarget(spec); err == nil {
+			add(target)
+		}
+	}
+	return out
+}
+
+// validateWebhookURL checks that a notification URL is an absolute http(s)
+// URL. The URL itself is not echoed since it may embed a secret token.
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("cannot parse URL")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("scheme must be http or https, got %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("URL has no host")
+	}
+	return nil
+}
+
+// -----------------------------------------------------------------------
+// Effective Configuration
+// -----------------------------------------------------------------------
+
+// redactedValue replaces secrets in the effective configuration.
+const redactedValue = "[REDACTED]"
+
+// secretKeys are settings whose values are never printed. Webhook URLs
+// carry their token in the path.
+var secretKeys = map[string]bool{
+	"metrics_auth_pass":   true,
+	"dashboard_auth_pass": true,
+	"api_token":           true,
+	"webhook_url":         true,
+	"slack_webhook_url":   true,
+}
+
+// Effective returns the resolved configuration keyed by setting name, as
+// it would be written in a config file. Secrets that are set are replaced
+// with a placeholder; unset ones stay empty so it is clear they are unset.
+func (c *Config) Effective() map[string]interface{} {
+	out := make(map[string]interface{})
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := t.Field(i).Tag.Get("koanf")
+		if key == "" || key == "check_config" {
+			continue
+		}
+		value := v.Field(i).Interface()
+		if secretKeys[key] && !v.Field(i).IsZero() {
+			value = redactedValue
+		}
+		out[key] = value
+	}
+	return out
+}
+
+// EffectiveYAML renders Effective as YAML, sorted by setting name.
+func (c *Config) EffectiveYAML() ([]byte, error) {
+	return yaml.Parser().Marshal(c.Effective())
+}
+
 // -----------------------------------------------------------------------
 // TLS Validation Helpers
 // -----------------------------------------------------------------------
@@ -229,7 +1307,21 @@ func (c *Config) validateManualTLS() error {
 		return fmt.Errorf("cannot access TLS key file (%s): %w", c.TLSKeyFile, err)
 	}
 
-	if err := validateTLSCertificatePair(c.TLSCertFile, c.TLSKeyFile); err != nil {
+	if c.TLSExpiryThresholdDays < 0 {
+		return fmt.Errorf(
+			"TLS expiry threshold must be non-negative (got %d days)\n"+
+				"use: --tls_expiry_threshold_days 14 or HEALTH_TLS_EXPIRY_THRESHOLD_DAYS=14",
+			c.TLSExpiryThresholdDays)
+	}
+
+	err := ValidateTLSCertificatePair(c.TLSCertFile, c.TLSKeyFile, c.CertExpiryPolicy())
+	if errors.Is(err, ErrCertificateExpiry) {
+		return fmt.Errorf("TLS certificate validation failed: %w\n"+
+			"cert: %s\n"+
+			"renew the certificate, or unset --tls_strict_expiry to only warn",
+			err, c.TLSCertFile)
+	}
+	if err != nil {
 		return fmt.Errorf("TLS certificate validation failed: %w\n"+
 			"cert: %s\n"+
 			"key:  %s\n"+
@@ -267,7 +1359,11 @@ func (c *Config) validateAutocert() error {
 
 	cacheDir := c.TLSAutocertCache
 	if cacheDir != "" {
-		testFile := cacheDir + "/.acme-test"
+		if err := ensureAutocertCacheDir(cacheDir); err != nil {
+			return err
+		}
+
+		testFile := filepath.Join(cacheDir, ".acme-test")
 		if err := os.WriteFile(testFile, []byte("test"), 0o600); err != nil {
 			return fmt.Errorf(
 				"autocert cache directory is not writable: %s\n"+
@@ -287,14 +1383,59 @@ func (c *Config) validateAutocert() error {
 	return nil
 }
 
+// ensureAutocertCacheDir confirms dir is a directory, creating it when
+// absent as autocert expects. A missing directory whose parent cannot be
+// written to is reported against the parent, the path the user must fix.
+func ensureAutocertCacheDir(dir string) error {
+	info, err := os.Stat(dir)
+	switch {
+	case err == nil:
+		if !info.IsDir() {
+			return fmt.Errorf(
+				"autocert cache path is not a directory: %s\n"+
+					"use: --tls_autocert_cache /var/cache/health-checker or HEALTH_TLS_AUTOCERT_CACHE=...", dir)
+		}
+		return nil
+	case !errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("cannot access autocert cache directory %s: %w", dir, err)
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf(
+			"cannot create autocert cache directory %s: %w\n"+
+				"ensure parent directory %s exists and is writable by the service user",
+			dir, err, filepath.Dir(dir))
+	}
+	slog.Info("created autocert cache directory", "path", dir)
+	return nil
+}
+
 // -----------------------------------------------------------------------
 // Certificate Validation
 // -----------------------------------------------------------------------
 
-// validateTLSCertificatePair verifies that certificate and key files are
+// ErrCertificateExpiry marks a certificate rejected by a strict
+// CertExpiryPolicy for being expired or too close to expiry.
+var ErrCertificateExpiry = errors.New("certificate expiry")
+
+// CertExpiryPolicy controls how ValidateTLSCertificatePair treats a
+// certificate that has expired or expires within ThresholdDays: an error
+// when Strict, otherwise a logged warning.
+type CertExpiryPolicy struct {
+	Strict        bool
+	ThresholdDays int
+}
+
+// CertExpiryPolicy returns the configured certificate expiry policy.
+func (c *Config) CertExpiryPolicy() CertExpiryPolicy {
+	return CertExpiryPolicy{Strict: c.TLSStrictExpiry, ThresholdDays: c.TLSExpiryThresholdDays}
+}
+
+// ValidateTLSCertificatePair verifies that certificate and key files are
 // valid PEM format, the certificate is parseable, and the key matches the
-// certificate.
-func validateTLSCertificatePair(certFile, keyFile string) error {
+// certificate. Exported so renewed certificates can be checked before they
+// are swapped into a running server.
+func ValidateTLSCertificatePair(certFile, keyFile string, expiry CertExpiryPolicy) error {
 	certPEM, err := os.ReadFile(certFile)
 	if err != nil {
 		return fmt.Errorf("failed to read certificate file: %w", err)
@@ -305,6 +1446,11 @@ func validateTLSCertificatePair(certFile, keyFile string) error {
 		return fmt.Errorf("failed to read key file: %w", err)
 	}
 
+	keyType, err := checkPrivateKeyPEM(keyPEM)
+	if err != nil {
+		return err
+	}
+
 	_, err = tls.X509KeyPair(certPEM, keyPEM)
 	if err != nil {
 		errMsg := err.Error()
@@ -314,23 +1460,85 @@ func validateTLSCertificatePair(certFile, keyFile string) error {
 		if strings.Contains(errMsg, "tls: failed to find certificate") {
 			return fmt.Errorf("certificate file does not contain a valid certificate (must be PEM): %w", err)
 		}
-		if strings.Contains(errMsg, "public key in certificate doesn't match") {
-			return fmt.Errorf("certificate and private key do not match: %w", err)
+		if strings.Contains(errMsg, "public key in certificate doesn't match") ||
+			strings.Contains(errMsg, "does not match public key") {
+			return fmt.Errorf("certificate and private key do not match (key is %s): %w", keyType, err)
 		}
 		return fmt.Errorf("certificate pair validation failed: %w", err)
 	}
 
-	err = validateCertificateExpiration(certPEM)
-	if err != nil {
-		slog.Warn("certificate expiration check warning", "err", err)
+	if err := validateCertificateExpiration(certPEM, expiry.ThresholdDays); err != nil {
+		if expiry.Strict {
+			return fmt.Errorf("%w: %w", ErrCertificateExpiry, err)
+		}
+		slog.Warn("certificate expiration check warning", "cert_file", certFile, "err", err)
 	}
 
 	return nil
 }
 
+// checkPrivateKeyPEM finds the private key block in keyPEM and parses it
+// according to its PEM type, so a key labelled with the wrong type or in
+// an unsupported form gets specific guidance rather than the generic
+// tls.X509KeyPair error. Returns a description of the key for later
+// messages.
+func checkPrivateKeyPEM(keyPEM []byte) (string, error) {
+	var found []string
+	for block, rest := pem.Decode(keyPEM); block != nil; block, rest = pem.Decode(rest) {
+		switch block.Type {
+		case "RSA PRIVATE KEY":
+			if _, err := x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+				return "", fmt.Errorf("key file has an RSA PRIVATE KEY (PKCS#1) block that is not an RSA key: %w\n"+
+					"an EC key should be labelled EC PRIVATE KEY and a PKCS#8 key PRIVATE KEY; "+
+					"convert with: openssl pkey -in key.pem -out key-pkcs8.pem", err)
+			}
+			return "RSA (PKCS#1)", nil
+
+		case "EC PRIVATE KEY":
+			if _, err := x509.ParseECPrivateKey(block.Bytes); err != nil {
+				return "", fmt.Errorf("key file has an EC PRIVATE KEY (SEC 1) block that is not an EC key: %w\n"+
+					"an RSA key should be labelled RSA PRIVATE KEY and a PKCS#8 key PRIVATE KEY; "+
+					"convert with: openssl pkey -in key.pem -out key-pkcs8.pem", err)
+			}
+			return "EC (SEC 1)", nil
+
+		case "PRIVATE KEY":
+			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return "", fmt.Errorf("key file has a PRIVATE KEY (PKCS#8) block that cannot be parsed: %w\n"+
+					"supported PKCS#8 keys are RSA, ECDSA and Ed25519; a PKCS#1 RSA key should be labelled RSA PRIVATE KEY "+
+					"and a SEC 1 EC key EC PRIVATE KEY", err)
+			}
+			switch key.(type) {
+			case *rsa.PrivateKey:
+				return "RSA (PKCS#8)", nil
+			case *ecdsa.PrivateKey:
+				return "ECDSA (PKCS#8)", nil
+			case ed25519.PrivateKey:
+				return "Ed25519 (PKCS#8)", nil
+			}
+			return "PKCS#8", nil
+
+		case "ENCRYPTED PRIVATE KEY":
+			return "", fmt.Errorf("key file is an encrypted PKCS#8 key; the service cannot prompt for a passphrase\n" +
+				"decrypt with: openssl pkey -in key.pem -out key-decrypted.pem")
+		}
+		found = append(found, block.Type)
+	}
+
+	if len(found) == 0 {
+		return "", fmt.Errorf("key file contains no PEM blocks (must be PEM, not DER)\n" +
+			"convert with: openssl pkey -inform DER -in key.der -out key.pem")
+	}
+	return "", fmt.Errorf("key file contains no private key (found %s)\n"+
+		"expected a RSA PRIVATE KEY, EC PRIVATE KEY or PRIVATE KEY block; check --tls_key is not the certificate",
+		strings.Join(found, ", "))
+}
+
 // validateCertificateExpiration checks whether a certificate has expired or
-// is expiring soon. Returns an error with expiration details if concerning.
-func validateCertificateExpiration(certPEM []byte) error {
+// expires within thresholdDays. Returns an error with expiration details if
+// concerning.
+func validateCertificateExpiration(certPEM []byte, thresholdDays int) error {
 	block, _ := pem.Decode(certPEM)
 	if block == nil {
 		return fmt.Errorf("failed to decode PEM block")
@@ -348,7 +1556,7 @@ func validateCertificateExpiration(certPEM []byte) error {
 	}
 
 	daysUntilExpiry := int(time.Until(cert.NotAfter).Hours() / 24)
-	if daysUntilExpiry < 7 {
+	if daysUntilExpiry < thresholdDays {
 		return fmt.Errorf("certificate expires in %d days (until %s)",
 			daysUntilExpiry, cert.NotAfter.Format("2006-01-02"))
 	}
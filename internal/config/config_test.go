@@ -11,7 +11,26 @@
 package config
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/afreidah/health-check-service/internal/checker"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/v2"
 )
 
 // -----------------------------------------------------------------------
@@ -50,59 +69,806 @@ func TestValidatePortRange(t *testing.T) {
 				t.Errorf("Expected error for port %d, got nil", tt.port)
 			}
 
-			if !tt.shouldErr && err != nil {
-				t.Errorf("Expected no error for port %d, got: %v", tt.port, err)
+			if !tt.shouldErr && err != nil {
+				t.Errorf("Expected no error for port %d, got: %v", tt.port, err)
+			}
+		})
+	}
+}
+
+// TestValidateServiceRequired verifies that service name is mandatory.
+func TestValidateServiceRequired(t *testing.T) {
+	cfg := &Config{
+		Port:     8080,
+		Service:  "",
+		Interval: 10,
+	}
+
+	err := cfg.Validate()
+
+	if err == nil {
+		t.Error("Expected error for missing service name, got nil")
+	}
+}
+
+// TestValidateServiceName verifies service names follow systemd's unit
+// naming rules, in both service and services.
+func TestValidateServiceName(t *testing.T) {
+	tests := []struct {
+		service   string
+		services  []string
+		shouldErr bool
+	}{
+		{"nginx", nil, false},
+		{"getty@tty1", []string{"user@1000.service"}, false},
+		{"nginx", []string{"redis", ""}, false},
+		{".nginx", nil, true},
+		{"nginx/conf", nil, true},
+		{"nginx", []string{"getty@"}, true},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{Port: 8080, Service: tt.service, Services: tt.services, Interval: 10}
+		err := cfg.Validate()
+		if (err != nil) != tt.shouldErr {
+			t.Errorf("service %q, services %v: error = %v, shouldErr %v", tt.service, tt.services, err, tt.shouldErr)
+		}
+	}
+}
+
+// TestValidateIntervalMinimum verifies interval must be at least 1 second.
+func TestValidateIntervalMinimum(t *testing.T) {
+	tests := []struct {
+		name      string
+		interval  int
+		shouldErr bool
+	}{
+		{"valid interval 1", 1, false},
+		{"valid interval 10", 10, false},
+		{"valid interval 60", 60, false},
+		{"invalid interval 0", 0, true},
+		{"invalid interval -1", -1, true},
+		{"invalid interval -10", -10, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Port:     8080,
+				Service:  "nginx",
+				Interval: tt.interval,
+			}
+
+			err := cfg.Validate()
+
+			if tt.shouldErr && err == nil {
+				t.Errorf("Expected error for interval %d, got nil", tt.interval)
+			}
+
+			if !tt.shouldErr && err != nil {
+				t.Errorf("Expected no error for interval %d, got: %v", tt.interval, err)
+			}
+		})
+	}
+}
+
+// TestValidateIntervalJitter verifies jitter is limited to 0-50 percent.
+// Above 50% a check could fire at under half the configured interval.
+func TestValidateIntervalJitter(t *testing.T) {
+	tests := []struct {
+		jitter    int
+		shouldErr bool
+	}{
+		{0, false},
+		{25, false},
+		{50, false},
+		{51, true},
+		{-1, true},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{
+			Port:                  8080,
+			Service:               "nginx",
+			Interval:              10,
+			IntervalJitterPercent: tt.jitter,
+		}
+
+		err := cfg.Validate()
+		if (err != nil) != tt.shouldErr {
+			t.Errorf("jitter %d: error = %v, shouldErr %v", tt.jitter, err, tt.shouldErr)
+		}
+	}
+}
+
+// TestValidateDBusTimeout verifies a negative D-Bus timeout is rejected.
+// Zero selects the default; a timeout above the interval only warns.
+func TestValidateDBusTimeout(t *testing.T) {
+	tests := []struct {
+		timeout   int
+		shouldErr bool
+	}{
+		{0, false},
+		{5, false},
+		{30, false},
+		{-1, true},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{
+			Port:        8080,
+			Service:     "nginx",
+			Interval:    10,
+			DBusTimeout: tt.timeout,
+		}
+
+		err := cfg.Validate()
+		if (err != nil) != tt.shouldErr {
+			t.Errorf("timeout %d: error = %v, shouldErr %v", tt.timeout, err, tt.shouldErr)
+		}
+	}
+}
+
+// TestValidateMaxConcurrentChecks verifies zero (unlimited) and positive
+// limits are accepted and negative ones rejected.
+func TestValidateMaxConcurrentChecks(t *testing.T) {
+	tests := []struct {
+		limit     int
+		shouldErr bool
+	}{
+		{0, false},
+		{10, false},
+		{-1, true},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{Port: 8080, Service: "nginx", Interval: 10, MaxConcurrentChecks: tt.limit}
+
+		err := cfg.Validate()
+		if (err != nil) != tt.shouldErr {
+			t.Errorf("limit %d: error = %v, shouldErr %v", tt.limit, err, tt.shouldErr)
+		}
+	}
+}
+
+// TestValidateAdaptiveInterval verifies adaptive backoff settings are only
+// checked when the mode is enabled, and that the cap cannot be below the
+// base interval (which would make "backoff" poll faster).
+func TestValidateAdaptiveInterval(t *testing.T) {
+	tests := []struct {
+		name      string
+		modify    func(*Config)
+		shouldErr bool
+	}{
+		{"disabled ignores fields", func(c *Config) {}, false},
+		{"valid", func(c *Config) {
+			c.AdaptiveInterval = true
+			c.AdaptiveIntervalMax = 300
+			c.AdaptiveIntervalThreshold = 5
+		}, false},
+		{"max below interval", func(c *Config) {
+			c.AdaptiveInterval = true
+			c.AdaptiveIntervalMax = 5
+			c.AdaptiveIntervalThreshold = 5
+		}, true},
+		{"zero threshold", func(c *Config) {
+			c.AdaptiveInterval = true
+			c.AdaptiveIntervalMax = 300
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Port: 8080, Service: "nginx", Interval: 10}
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("Validate() error = %v, shouldErr %v", err, tt.shouldErr)
+			}
+		})
+	}
+}
+
+// TestValidateWebhookURL verifies only absolute http(s) URLs are accepted
+// so a typo fails at startup rather than silently dropping notifications.
+func TestValidateWebhookURL(t *testing.T) {
+	tests := []struct {
+		url       string
+		shouldErr bool
+	}{
+		{"", false},
+		{"https://hooks.example.com/health", false},
+		{"http://10.0.0.5:9000/notify", false},
+		{"hooks.example.com/health", true},
+		{"ftp://example.com/hook", true},
+		{"https://", true},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{Port: 8080, Service: "nginx", Interval: 10, WebhookURL: tt.url}
+
+		err := cfg.Validate()
+		if (err != nil) != tt.shouldErr {
+			t.Errorf("webhook %q: error = %v, shouldErr %v", tt.url, err, tt.shouldErr)
+		}
+	}
+}
+
+// TestValidateAuthPairs verifies Basic Auth credentials must be set as a
+// pair. A username without a password would otherwise silently leave the
+// endpoint open or lock everyone out.
+func TestValidateAuthPairs(t *testing.T) {
+	tests := []struct {
+		name      string
+		modify    func(*Config)
+		shouldErr bool
+	}{
+		{"unset", func(c *Config) {}, false},
+		{"metrics pair", func(c *Config) { c.MetricsAuthUser = "prom"; c.MetricsAuthPass = "secret" }, false},
+		{"metrics user only", func(c *Config) { c.MetricsAuthUser = "prom" }, true},
+		{"dashboard pass only", func(c *Config) { c.DashboardAuthPass = "secret" }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Port: 8080, Service: "nginx", Interval: 10}
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("Validate() error = %v, shouldErr %v", err, tt.shouldErr)
+			}
+		})
+	}
+}
+
+// TestValidateLogLines verifies the journal line count is bounded so a
+// request cannot make journalctl dump the whole journal.
+func TestValidateLogLines(t *testing.T) {
+	tests := []struct {
+		name      string
+		lines     int
+		shouldErr bool
+	}{
+		{"default", 0, false},
+		{"custom", 200, false},
+		{"at cap", 1000, false},
+		{"negative", -1, true},
+		{"above cap", 1001, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Port: 8080, Service: "nginx", Interval: 10, LogLines: tt.lines}
+
+			err := cfg.Validate()
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("Validate() error = %v, shouldErr %v", err, tt.shouldErr)
+			}
+		})
+	}
+}
+
+// TestValidateRequestTimeout verifies that zero disables the request
+// timeout and a negative value is rejected.
+func TestValidateRequestTimeout(t *testing.T) {
+	tests := []struct {
+		name      string
+		timeout   int
+		shouldErr bool
+	}{
+		{"disabled", 0, false},
+		{"default", 8, false},
+		{"long", 60, false},
+		{"negative", -1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Port: 8080, Service: "nginx", Interval: 10, RequestTimeout: tt.timeout}
+
+			err := cfg.Validate()
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("Validate() error = %v, shouldErr %v", err, tt.shouldErr)
+			}
+		})
+	}
+}
+
+// TestValidateServerTimeouts verifies each HTTP server timeout accepts zero
+// (the default) and positive values and rejects negative ones.
+func TestValidateServerTimeouts(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       Config
+		shouldErr bool
+	}{
+		{"defaults", Config{}, false},
+		{"custom", Config{ReadTimeout: 10, WriteTimeout: 60, IdleTimeout: 600}, false},
+		{"negative read", Config{ReadTimeout: -1}, true},
+		{"negative write", Config{WriteTimeout: -1}, true},
+		{"negative idle", Config{IdleTimeout: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.Port, cfg.Service, cfg.Interval = 8080, "nginx", 10
+
+			err := cfg.Validate()
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("Validate() error = %v, shouldErr %v", err, tt.shouldErr)
+			}
+		})
+	}
+}
+
+// TestValidateAccessLogSample verifies a negative sample rate is rejected
+// while 0 and 1 both log every request.
+func TestValidateAccessLogSample(t *testing.T) {
+	for _, sample := range []int{0, 1, 100} {
+		cfg := &Config{Port: 8080, Service: "nginx", Interval: 10, AccessLogSample: sample}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Validate() with sample %d: unexpected error %v", sample, err)
+		}
+	}
+
+	cfg := &Config{Port: 8080, Service: "nginx", Interval: 10, AccessLogSample: -1}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for negative access_log_sample")
+	}
+}
+
+// TestValidateStatsD verifies the StatsD address must be host:port and the
+// flush interval cannot be negative.
+func TestValidateStatsD(t *testing.T) {
+	tests := []struct {
+		name      string
+		addr      string
+		interval  int
+		shouldErr bool
+	}{
+		{"disabled", "", 0, false},
+		{"host and port", "127.0.0.1:8125", 10, false},
+		{"missing port", "statsd.local", 0, true},
+		{"negative interval", "127.0.0.1:8125", -1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Port: 8080, Service: "nginx", Interval: 10,
+				StatsDAddr: tt.addr, StatsDFlushInterval: tt.interval}
+
+			err := cfg.Validate()
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("Validate() error = %v, shouldErr %v", err, tt.shouldErr)
+			}
+		})
+	}
+}
+
+// TestValidateAggregatePolicy verifies the policy syntax and that a quorum
+// cannot exceed the number of monitored services.
+func TestValidateAggregatePolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    string
+		shouldErr bool
+	}{
+		{"default", "", false},
+		{"all", "all", false},
+		{"any", "any", false},
+		{"quorum within services", "quorum:2", false},
+		{"quorum above services", "quorum:4", true},
+		{"zero quorum", "quorum:0", true},
+		{"unknown", "most", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Port: 8080, Service: "redis-1", Services: []string{"redis-2", "redis-3"},
+				Interval: 10, AggregatePolicy: tt.policy}
+
+			err := cfg.Validate()
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("Validate() error = %v, shouldErr %v", err, tt.shouldErr)
+			}
+		})
+	}
+}
+
+// TestValidateStaleStatusCode verifies the stale status code is either
+// unset or a real HTTP status code.
+func TestValidateStaleStatusCode(t *testing.T) {
+	tests := []struct {
+		code      int
+		shouldErr bool
+	}{
+		{0, false},
+		{503, false},
+		{200, false},
+		{99, true},
+		{600, true},
+		{-1, true},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{Port: 8080, Service: "nginx", Interval: 10, StaleStatusCode: tt.code}
+		err := cfg.Validate()
+		if (err != nil) != tt.shouldErr {
+			t.Errorf("stale_status_code %d: error = %v, shouldErr %v", tt.code, err, tt.shouldErr)
+		}
+	}
+}
+
+// TestValidatePreShutdownDelay verifies a negative delay is rejected and
+// zero (shut down immediately) is allowed.
+func TestValidatePreShutdownDelay(t *testing.T) {
+	for _, tt := range []struct {
+		delay     int
+		shouldErr bool
+	}{
+		{0, false},
+		{15, false},
+		{-1, true},
+	} {
+		cfg := &Config{Port: 8080, Service: "nginx", Interval: 10, PreShutdownDelay: tt.delay}
+		err := cfg.Validate()
+		if (err != nil) != tt.shouldErr {
+			t.Errorf("pre_shutdown_delay_seconds %d: error = %v, shouldErr %v", tt.delay, err, tt.shouldErr)
+		}
+	}
+}
+
+// TestValidateAllowRestartRequiresToken verifies the restart endpoint can
+// only be enabled behind the API token.
+func TestValidateAllowRestartRequiresToken(t *testing.T) {
+	cfg := &Config{Port: 8080, Service: "nginx", Interval: 10, AllowRestart: true}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for allow_restart without api_token")
+	}
+
+	cfg.APIToken = "secret"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected no error with api_token set, got %v", err)
+	}
+}
+
+// TestValidateEnablePprofRequiresToken verifies the profiling endpoints
+// can only be enabled together with an API token.
+func TestValidateEnablePprofRequiresToken(t *testing.T) {
+	cfg := &Config{Port: 8080, Service: "nginx", Interval: 10, EnablePprof: true}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for enable_pprof without api_token")
+	}
+
+	cfg.APIToken = "secret"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected no error with api_token set, got %v", err)
+	}
+}
+
+// TestDashboardPollSeconds verifies the dashboard polls at the check
+// interval unless an interval of its own is configured, and that a
+// negative one is rejected.
+func TestDashboardPollSeconds(t *testing.T) {
+	cfg := &Config{Port: 8080, Service: "nginx", Interval: 30}
+	if got := cfg.DashboardPollSeconds(); got != 30 {
+		t.Errorf("Expected the check interval (30), got %d", got)
+	}
+
+	cfg.DashboardPollInterval = 5
+	if got := cfg.DashboardPollSeconds(); got != 5 {
+		t.Errorf("Expected the configured interval (5), got %d", got)
+	}
+
+	cfg.DashboardPollInterval = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for a negative dashboard poll interval")
+	}
+}
+
+// TestValidateDashboardDir verifies a configured dashboard directory must
+// exist and be a directory, so a typo fails at startup instead of silently
+// serving the embedded dashboard.
+func TestValidateDashboardDir(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "index.html")
+	if err := os.WriteFile(file, []byte("<html></html>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		dir       string
+		shouldErr bool
+	}{
+		{"unset", "", false},
+		{"existing directory", dir, false},
+		{"missing", filepath.Join(dir, "missing"), true},
+		{"regular file", file, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Port: 8080, Service: "nginx", Interval: 10, DashboardDir: tt.dir}
+
+			err := cfg.Validate()
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("Validate() error = %v, shouldErr %v", err, tt.shouldErr)
+			}
+		})
+	}
+}
+
+// TestValidateStateCodes verifies state code overrides must be valid HTTP
+// status codes. An invalid code would be written verbatim by the health
+// handler and confuse load balancers.
+func TestValidateStateCodes(t *testing.T) {
+	tests := []struct {
+		name      string
+		codes     map[string]int
+		shouldErr bool
+	}{
+		{"no overrides", nil, false},
+		{"activating healthy", map[string]int{"activating": 200}, false},
+		{"reloading healthy", map[string]int{"activating": 200, "reloading": 200}, false},
+		{"code too low", map[string]int{"activating": 99}, true},
+		{"code too high", map[string]int{"activating": 600}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Port:       8080,
+				Service:    "nginx",
+				Interval:   10,
+				StateCodes: tt.codes,
+			}
+
+			err := cfg.Validate()
+
+			if tt.shouldErr && err == nil {
+				t.Errorf("Expected error for state codes %v, got nil", tt.codes)
+			}
+
+			if !tt.shouldErr && err != nil {
+				t.Errorf("Expected no error for state codes %v, got: %v", tt.codes, err)
+			}
+		})
+	}
+}
+
+// TestValidateDBusScope verifies only the system and session buses are
+// accepted. An unknown scope would silently fall back to the system bus and
+// fail to find --user units.
+func TestValidateDBusScope(t *testing.T) {
+	tests := []struct {
+		scope     string
+		shouldErr bool
+	}{
+		{"", false},
+		{"system", false},
+		{"session", false},
+		{"user", true},
+		{"SYSTEM", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			cfg := &Config{
+				Port:      8080,
+				Service:   "syncthing",
+				Interval:  10,
+				DBusScope: tt.scope,
+			}
+
+			err := cfg.Validate()
+
+			if tt.shouldErr && err == nil {
+				t.Errorf("Expected error for scope %q, got nil", tt.scope)
+			}
+
+			if !tt.shouldErr && err != nil {
+				t.Errorf("Expected no error for scope %q, got: %v", tt.scope, err)
+			}
+		})
+	}
+}
+
+// TestValidateActivatingGrace verifies a negative grace period is rejected.
+func TestValidateActivatingGrace(t *testing.T) {
+	cfg := &Config{Port: 8080, Service: "nginx", Interval: 10, ActivatingGrace: -1}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for negative activating grace period")
+	}
+
+	cfg.ActivatingGrace = 120
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+// TestValidateMaxReconnectAttempts verifies a negative limit is rejected;
+// zero keeps the retry-forever default.
+func TestValidateMaxReconnectAttempts(t *testing.T) {
+	for _, tt := range []struct {
+		attempts  int
+		shouldErr bool
+	}{{0, false}, {10, false}, {-1, true}} {
+		cfg := &Config{Port: 8080, Service: "nginx", Interval: 10, MaxReconnectAttempts: tt.attempts}
+
+		err := cfg.Validate()
+		if (err != nil) != tt.shouldErr {
+			t.Errorf("attempts %d: error = %v, shouldErr %v", tt.attempts, err, tt.shouldErr)
+		}
+	}
+}
+
+// TestValidateLogSummaryInterval verifies a negative interval is rejected;
+// zero selects the default.
+func TestValidateLogSummaryInterval(t *testing.T) {
+	for _, tt := range []struct {
+		interval  int
+		shouldErr bool
+	}{{0, false}, {300, false}, {-1, true}} {
+		cfg := &Config{Port: 8080, Service: "nginx", Interval: 10, LogSummaryInterval: tt.interval}
+
+		err := cfg.Validate()
+		if (err != nil) != tt.shouldErr {
+			t.Errorf("interval %d: error = %v, shouldErr %v", tt.interval, err, tt.shouldErr)
+		}
+	}
+}
+
+// TestValidateUnitType verifies only the supported unit types are accepted.
+// An unknown type would only fail later, when no unit can be found.
+func TestValidateUnitType(t *testing.T) {
+	tests := []struct {
+		unitType  string
+		shouldErr bool
+	}{
+		{"", false},
+		{"service", false},
+		{"timer", false},
+		{"socket", false},
+		{"mount", false},
+		{"target", false},
+		{"path", true},
+		{".timer", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.unitType, func(t *testing.T) {
+			cfg := &Config{
+				Port:     8080,
+				Service:  "backup",
+				Interval: 10,
+				UnitType: tt.unitType,
+			}
+
+			err := cfg.Validate()
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("Validate() error = %v, shouldErr %v", err, tt.shouldErr)
+			}
+		})
+	}
+}
+
+// TestValidateRateLimits verifies negative rate limit values are rejected.
+// Zero is allowed since it is an explicit (if unusual) way to block a group.
+func TestValidateRateLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		modify    func(*Config)
+		shouldErr bool
+	}{
+		{"zero values", func(c *Config) {}, false},
+		{"custom values", func(c *Config) { c.MetricsRate = 12; c.MetricsBurst = 60 }, false},
+		{"negative health rate", func(c *Config) { c.HealthRate = -1 }, true},
+		{"negative dashboard burst", func(c *Config) { c.DashboardBurst = -5 }, true},
+		{"negative metrics rate", func(c *Config) { c.MetricsRate = -0.5 }, true},
+		{"global limit", func(c *Config) { c.GlobalRate = 500; c.GlobalBurst = 1000 }, false},
+		{"negative global burst", func(c *Config) { c.GlobalBurst = -1 }, true},
+		{"cleanup tuning", func(c *Config) { c.RateLimitCleanupInterval = 30; c.RateLimitIdleTimeout = 60 }, false},
+		{"negative idle timeout", func(c *Config) { c.RateLimitIdleTimeout = -1 }, true},
+		{"max tracked IPs", func(c *Config) { c.RateLimitMaxTrackedIPs = 100000 }, false},
+		{"negative max tracked IPs", func(c *Config) { c.RateLimitMaxTrackedIPs = -1 }, true},
+		{"bypass CIDRs", func(c *Config) { c.RateLimitBypassCIDRs = []string{"10.0.0.0/8", "fd00::/8"} }, false},
+		{"bypass bare IP", func(c *Config) { c.RateLimitBypassCIDRs = []string{"10.0.0.1"} }, true},
+		{"bypass bad prefix", func(c *Config) { c.RateLimitBypassCIDRs = []string{"fd00::/200"} }, true},
+		{"trusted proxies", func(c *Config) { c.TrustedProxies = []string{"10.0.0.0/8", "::1/128"} }, false},
+		{"trusted proxy hostname", func(c *Config) { c.TrustedProxies = []string{"proxy.internal"} }, true},
+		{"request ID headers", func(c *Config) { c.RequestIDHeaders = []string{"X-Amzn-Trace-Id", "traceparent"} }, false},
+		{"empty request ID header", func(c *Config) { c.RequestIDHeaders = []string{"X-Request-ID", " "} }, true},
+		{"request ID header with colon", func(c *Config) { c.RequestIDHeaders = []string{"X-Request-ID:"} }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Port:     8080,
+				Service:  "nginx",
+				Interval: 10,
+			}
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("Validate() error = %v, shouldErr %v", err, tt.shouldErr)
 			}
 		})
 	}
 }
 
-// TestValidateServiceRequired verifies that service name is mandatory.
-func TestValidateServiceRequired(t *testing.T) {
-	cfg := &Config{
-		Port:     8080,
-		Service:  "",
-		Interval: 10,
+// TestValidateWatchdog verifies watchdog tuning bounds. A multiplier below 1
+// would mark the checker unresponsive between normal checks, so it is
+// rejected; zero means "use the default" and must stay valid.
+func TestValidateWatchdog(t *testing.T) {
+	tests := []struct {
+		name       string
+		interval   int
+		multiplier float64
+		shouldErr  bool
+	}{
+		{"defaults", 0, 0, false},
+		{"custom", 30, 5, false},
+		{"multiplier of one", 10, 1, false},
+		{"negative interval", -1, 0, true},
+		{"multiplier below one", 10, 0.5, true},
 	}
 
-	err := cfg.Validate()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Port:               8080,
+				Service:            "nginx",
+				Interval:           10,
+				WatchdogInterval:   tt.interval,
+				WatchdogMultiplier: tt.multiplier,
+			}
 
-	if err == nil {
-		t.Error("Expected error for missing service name, got nil")
+			err := cfg.Validate()
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("Validate() error = %v, shouldErr %v", err, tt.shouldErr)
+			}
+		})
 	}
 }
 
-// TestValidateIntervalMinimum verifies interval must be at least 1 second.
-func TestValidateIntervalMinimum(t *testing.T) {
+// TestValidateFlapDetection verifies flap detection bounds. The threshold
+// must be reachable within the retained history or it can never trigger.
+func TestValidateFlapDetection(t *testing.T) {
 	tests := []struct {
-		name      string
-		interval  int
-		shouldErr bool
+		name        string
+		threshold   int
+		window      int
+		historySize int
+		shouldErr   bool
 	}{
-		{"valid interval 1", 1, false},
-		{"valid interval 10", 10, false},
-		{"valid interval 60", 60, false},
-		{"invalid interval 0", 0, true},
-		{"invalid interval -1", -1, true},
-		{"invalid interval -10", -10, true},
+		{"disabled", 0, 0, 0, false},
+		{"enabled", 5, 300, 0, false},
+		{"negative threshold", -1, 300, 0, true},
+		{"missing window", 5, 0, 0, true},
+		{"threshold above default history", 50, 300, 0, true},
+		{"threshold within custom history", 50, 300, 100, false},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			cfg := &Config{
-				Port:     8080,
-				Service:  "nginx",
-				Interval: tt.interval,
+				Port:          8080,
+				Service:       "nginx",
+				Interval:      10,
+				FlapThreshold: tt.threshold,
+				FlapWindow:    tt.window,
+				HistorySize:   tt.historySize,
 			}
 
 			err := cfg.Validate()
-
-			if tt.shouldErr && err == nil {
-				t.Errorf("Expected error for interval %d, got nil", tt.interval)
-			}
-
-			if !tt.shouldErr && err != nil {
-				t.Errorf("Expected no error for interval %d, got: %v", tt.interval, err)
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("Validate() error = %v, shouldErr %v", err, tt.shouldErr)
 			}
 		})
 	}
@@ -146,6 +912,180 @@ func TestValidateTLSRequiresCertAndKey(t *testing.T) {
 	}
 }
 
+// generateTestCertPEM returns a self-signed certificate valid until
+// notAfter and its private key, PEM encoded. keyType selects the key
+// algorithm and encoding: rsa (PKCS#1), ec (SEC 1), pkcs8-rsa or pkcs8-ec.
+func generateTestCertPEM(t *testing.T, keyType string, notAfter time.Time) ([]byte, []byte) {
+	t.Helper()
+
+	var (
+		key      crypto.Signer
+		keyBlock *pem.Block
+		err      error
+	)
+	switch keyType {
+	case "rsa", "pkcs8-rsa":
+		var rsaKey *rsa.PrivateKey
+		rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		key = rsaKey
+		if err == nil && keyType == "rsa" {
+			keyBlock = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}
+		}
+	case "ec", "pkcs8-ec":
+		var ecKey *ecdsa.PrivateKey
+		ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		key = ecKey
+		if err == nil && keyType == "ec" {
+			var der []byte
+			der, err = x509.MarshalECPrivateKey(ecKey)
+			keyBlock = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+		}
+	default:
+		t.Fatalf("unknown key type %q", keyType)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyBlock == nil {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyBlock = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(keyBlock)
+}
+
+// writeTestFile writes data to name in dir and returns its path.
+func writeTestFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeTestCertPair writes a self-signed ECDSA certificate valid until
+// notAfter and its key to dir, returning their paths.
+func writeTestCertPair(t *testing.T, dir string, notAfter time.Time) (string, string) {
+	t.Helper()
+	certPEM, keyPEM := generateTestCertPEM(t, "ec", notAfter)
+	return writeTestFile(t, dir, "cert.pem", certPEM), writeTestFile(t, dir, "key.pem", keyPEM)
+}
+
+// TestValidateTLSCertificatePairKeyFormats verifies RSA, EC and PKCS#8
+// keys load through tls.X509KeyPair, and that mislabelled, encrypted,
+// missing and mismatched keys are reported specifically.
+func TestValidateTLSCertificatePairKeyFormats(t *testing.T) {
+	notAfter := time.Now().Add(90 * 24 * time.Hour)
+
+	for _, keyType := range []string{"rsa", "ec", "pkcs8-rsa", "pkcs8-ec"} {
+		t.Run(keyType, func(t *testing.T) {
+			dir := t.TempDir()
+			certPEM, keyPEM := generateTestCertPEM(t, keyType, notAfter)
+			certFile := writeTestFile(t, dir, "cert.pem", certPEM)
+			keyFile := writeTestFile(t, dir, "key.pem", keyPEM)
+
+			if err := ValidateTLSCertificatePair(certFile, keyFile, CertExpiryPolicy{}); err != nil {
+				t.Errorf("Expected %s key to validate, got %v", keyType, err)
+			}
+		})
+	}
+
+	ecCert, ecKey := generateTestCertPEM(t, "ec", notAfter)
+	rsaCert, rsaKey := generateTestCertPEM(t, "rsa", notAfter)
+	_, otherECKey := generateTestCertPEM(t, "ec", notAfter)
+	relabel := func(keyPEM []byte, blockType string) []byte {
+		block, _ := pem.Decode(keyPEM)
+		return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: block.Bytes})
+	}
+
+	tests := []struct {
+		name    string
+		certPEM []byte
+		keyPEM  []byte
+		wantErr string
+	}{
+		{"EC key labelled RSA", ecCert, relabel(ecKey, "RSA PRIVATE KEY"), "RSA PRIVATE KEY (PKCS#1) block"},
+		{"RSA key labelled EC", rsaCert, relabel(rsaKey, "EC PRIVATE KEY"), "EC PRIVATE KEY (SEC 1) block"},
+		{"SEC 1 key labelled PKCS#8", ecCert, relabel(ecKey, "PRIVATE KEY"), "PRIVATE KEY (PKCS#8) block"},
+		{"encrypted key", ecCert, relabel(ecKey, "ENCRYPTED PRIVATE KEY"), "encrypted"},
+		{"certificate as key", ecCert, ecCert, "no private key (found CERTIFICATE)"},
+		{"DER key", ecCert, []byte("not pem"), "no PEM blocks"},
+		{"mismatched EC keys", ecCert, otherECKey, "do not match (key is EC (SEC 1))"},
+		{"RSA key for EC certificate", ecCert, rsaKey, "do not match (key is RSA (PKCS#1))"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			certFile := writeTestFile(t, dir, "cert.pem", tt.certPEM)
+			keyFile := writeTestFile(t, dir, "key.pem", tt.keyPEM)
+
+			err := ValidateTLSCertificatePair(certFile, keyFile, CertExpiryPolicy{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestValidateTLSStrictExpiry verifies expired and near-expiry certificates
+// only warn by default and fail validation with TLSStrictExpiry.
+func TestValidateTLSStrictExpiry(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		name      string
+		notAfter  time.Duration
+		strict    bool
+		threshold int
+		shouldErr bool
+	}{
+		{"expired warns by default", -day, false, 7, false},
+		{"expired fails when strict", -day, true, 7, true},
+		{"near expiry fails when strict", 3 * day, true, 7, true},
+		{"outside threshold passes when strict", 30 * day, true, 7, false},
+		{"custom threshold fails when strict", 20 * day, true, 30, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certFile, keyFile := writeTestCertPair(t, t.TempDir(), time.Now().Add(tt.notAfter))
+			cfg := &Config{
+				Port:                   8443,
+				Service:                "nginx",
+				Interval:               10,
+				TLSEnabled:             true,
+				TLSCertFile:            certFile,
+				TLSKeyFile:             keyFile,
+				TLSStrictExpiry:        tt.strict,
+				TLSExpiryThresholdDays: tt.threshold,
+			}
+
+			err := cfg.Validate()
+			if tt.shouldErr && !errors.Is(err, ErrCertificateExpiry) {
+				t.Errorf("Expected certificate expiry error, got %v", err)
+			}
+			if !tt.shouldErr && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
 // TestValidateAutocertRequiresDomain verifies that autocert requires a domain.
 func TestValidateAutocertRequiresDomain(t *testing.T) {
 	cfg := &Config{
@@ -240,6 +1180,50 @@ func TestValidateWithAutocert(t *testing.T) {
 	}
 }
 
+// TestValidateAutocertCacheDir verifies the autocert cache must be a
+// directory, is created when missing, and reports an unwritable parent.
+func TestValidateAutocertCacheDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	newConfig := func(cache string) *Config {
+		return &Config{
+			Port:              443,
+			Service:           "nginx",
+			Interval:          10,
+			TLSAutocert:       true,
+			TLSAutocertDomain: "example.com",
+			TLSAutocertCache:  cache,
+		}
+	}
+
+	regular := filepath.Join(tmpDir, "cache-file")
+	if err := os.WriteFile(regular, []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := newConfig(regular).Validate(); err == nil || !strings.Contains(err.Error(), "not a directory") {
+		t.Errorf("Expected not-a-directory error for a regular file, got %v", err)
+	}
+
+	missing := filepath.Join(tmpDir, "autocert", "cache")
+	if err := newConfig(missing).Validate(); err != nil {
+		t.Fatalf("Expected missing cache directory to be created, got %v", err)
+	}
+	if info, err := os.Stat(missing); err != nil || !info.IsDir() {
+		t.Errorf("Expected %s to be created as a directory, got %v", missing, err)
+	}
+
+	if os.Geteuid() == 0 {
+		t.Skip("root bypasses directory permissions")
+	}
+	readOnly := filepath.Join(tmpDir, "read-only")
+	if err := os.Mkdir(readOnly, 0o500); err != nil {
+		t.Fatal(err)
+	}
+	err := newConfig(filepath.Join(readOnly, "cache")).Validate()
+	if err == nil || !strings.Contains(err.Error(), readOnly) {
+		t.Errorf("Expected error naming unwritable parent %s, got %v", readOnly, err)
+	}
+}
+
 // -----------------------------------------------------------------------
 // Edge Cases
 // -----------------------------------------------------------------------
@@ -271,3 +1255,373 @@ func TestValidateMaximumPort(t *testing.T) {
 		t.Errorf("Expected port 65535 to be valid, got error: %v", err)
 	}
 }
+
+// TestValidateListenAddr verifies the listen address must be an IP address
+// (or empty for all interfaces), and that it is joined with the port.
+func TestValidateListenAddr(t *testing.T) {
+	tests := []struct {
+		addr      string
+		want      string
+		shouldErr bool
+	}{
+		{"", ":8080", false},
+		{"127.0.0.1", "127.0.0.1:8080", false},
+		{"::1", "[::1]:8080", false},
+		{"localhost", "", true},
+		{"10.0.0.1:80", "", true},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{Port: 8080, Service: "nginx", Interval: 10, ListenAddr: tt.addr}
+
+		err := cfg.Validate()
+		if (err != nil) != tt.shouldErr {
+			t.Errorf("addr %q: error = %v, shouldErr %v", tt.addr, err, tt.shouldErr)
+		}
+		if err == nil && cfg.ListenAddress(cfg.Port) != tt.want {
+			t.Errorf("addr %q: ListenAddress = %q, want %q", tt.addr, cfg.ListenAddress(cfg.Port), tt.want)
+		}
+	}
+}
+
+// TestMonitoredServices verifies the primary service is listed first and that
+// duplicates and empty entries are dropped. Duplicates would start two
+// checkers writing to the same cache.
+func TestMonitoredServices(t *testing.T) {
+	cfg := &Config{
+		Service:  "nginx",
+		Services: []string{"redis", "", "nginx", " postgresql ", "redis"},
+	}
+
+	got := cfg.MonitoredServices()
+	want := []string{"nginx", "redis", "postgresql"}
+
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Index %d: expected %q, got %q", i, want[i], got[i])
+		}
+	}
+}
+
+// TestValidateTargets verifies target syntax, that targets alone satisfy
+// the service requirement, and that a probe cannot reuse a service name.
+func TestValidateTargets(t *testing.T) {
+	tests := []struct {
+		name      string
+		service   string
+		targets   []string
+		shouldErr bool
+	}{
+		{"tcp target", "nginx", []string{"tcp:127.0.0.1:5432"}, false},
+		{"http target", "nginx", []string{"https://example.com/healthz"}, false},
+		{"targets without service", "", []string{"tcp:127.0.0.1:5432"}, false},
+		{"dbus target repeating service", "nginx", []string{"dbus:nginx"}, false},
+		{"invalid target", "nginx", []string{"tcp:5432"}, true},
+		{"unknown scheme", "nginx", []string{"udp:127.0.0.1:53"}, true},
+		{"probe named like service", "nginx", []string{"nginx=tcp:127.0.0.1:80"}, true},
+		{"duplicate probe names", "nginx", []string{"db=tcp:10.0.0.1:5432", "db=tcp:10.0.0.2:5432"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Port: 8080, Service: tt.service, Targets: tt.targets, Interval: 10}
+
+			err := cfg.Validate()
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("Validate() error = %v, shouldErr %v", err, tt.shouldErr)
+			}
+		})
+	}
+}
+
+// TestMonitoredTargets verifies that services map to D-Bus targets, probes
+// are appended in order, and only systemd services are reported as such.
+func TestMonitoredTargets(t *testing.T) {
+	cfg := &Config{
+		Service:  "nginx",
+		Services: []string{"redis"},
+		Targets:  []string{"dbus:redis", "db=tcp:127.0.0.1:5432", "http://localhost:9000/healthz"},
+	}
+
+	got := cfg.MonitoredServices()
+	want := []string{"nginx", "redis", "db", "localhost:9000/healthz"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MonitoredServices() = %v, want %v", got, want)
+	}
+
+	targets := cfg.MonitoredTargets()
+	if targets["nginx"].Kind != checker.TargetDBus {
+		t.Errorf("nginx kind = %q, want %q", targets["nginx"].Kind, checker.TargetDBus)
+	}
+	if targets["db"].Kind != checker.TargetTCP || targets["db"].Address != "127.0.0.1:5432" {
+		t.Errorf("db target = %+v", targets["db"])
+	}
+
+	if got := cfg.SystemdServices(); !reflect.DeepEqual(got, []string{"nginx", "redis"}) {
+		t.Errorf("SystemdServices() = %v, want [nginx redis]", got)
+	}
+}
+
+// -----------------------------------------------------------------------
+// Config File Format Tests
+// -----------------------------------------------------------------------
+
+// TestParserForFile verifies the config file parser is chosen by extension.
+// Extensionless paths fall back to YAML for backward compatibility, while
+// unrecognized extensions are rejected rather than parsed as the wrong format.
+func TestParserForFile(t *testing.T) {
+	tests := []struct {
+		path      string
+		shouldErr bool
+	}{
+		{"config.yaml", false},
+		{"config.yml", false},
+		{"CONFIG.JSON", false},
+		{"config.toml", false},
+		{"config", false},
+		{"config.ini", true},
+		{"config.xml", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			parser, err := parserForFile(tt.path)
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("parserForFile(%q) error = %v, shouldErr %v", tt.path, err, tt.shouldErr)
+			}
+			if err == nil && parser == nil {
+				t.Errorf("parserForFile(%q) returned nil parser", tt.path)
+			}
+		})
+	}
+}
+
+// TestParsersDecodeConfig verifies each supported format decodes into the
+// same configuration keys, so switching formats needs no other changes.
+func TestParsersDecodeConfig(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": "service: nginx\ninterval: 5\n",
+		"config.json": `{"service": "nginx", "interval": 5}`,
+		"config.toml": "service = \"nginx\"\ninterval = 5\n",
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			parser, err := parserForFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			k := koanf.New(".")
+			if err := k.Load(file.Provider(path), parser); err != nil {
+				t.Fatalf("failed to parse %s: %v", name, err)
+			}
+
+			cfg := &Config{}
+			if err := k.Unmarshal("", cfg); err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Service != "nginx" || cfg.Interval != 5 {
+				t.Errorf("Expected service nginx interval 5, got %q %d", cfg.Service, cfg.Interval)
+			}
+		})
+	}
+}
+
+// TestLoadConfigDir verifies drop-in files are merged in lexical order with
+// later files overriding earlier ones, files with other extensions are
+// skipped, and a missing directory is an error.
+func TestLoadConfigDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"10-base.yaml":     "service: nginx\ninterval: 5\nport: 9090\n",
+		"20-interval.json": `{"interval": 30}`,
+		"30-port.toml":     "port = 9191\n",
+		"40-ignored.yaml~": "port: 1\n",
+		"50-notes.txt":     "port: 2\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sk := newSourcedKoanf()
+	if err := loadConfigDir(sk, dir); err != nil {
+		t.Fatalf("loadConfigDir: %v", err)
+	}
+
+	cfg := &Config{}
+	if err := sk.k.Unmarshal("", cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Service != "nginx" || cfg.Interval != 30 || cfg.Port != 9191 {
+		t.Errorf("Expected service nginx interval 30 port 9191, got %q %d %d", cfg.Service, cfg.Interval, cfg.Port)
+	}
+
+	if got, want := sk.sources.Of("port"), "file:"+filepath.Join(dir, "30-port.toml"); got != want {
+		t.Errorf("Expected port from %s, got %s", want, got)
+	}
+	if got := sk.sources.Of("history_size"); got != "default" {
+		t.Errorf("Expected unset setting to be a default, got %s", got)
+	}
+
+	if err := loadConfigDir(newSourcedKoanf(), filepath.Join(dir, "missing")); err == nil {
+		t.Error("Expected an error for a missing config directory")
+	}
+}
+
+// TestLoadConfigDirFromEnv verifies the drop-in directory can be named by
+// HEALTH_CONFIG_DIR, which is read before the rest of the environment.
+func TestLoadConfigDirFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "10-base.yaml"), []byte("service: nginx\nport: 9191\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	args := os.Args
+	os.Args = []string{"health-checker"}
+	t.Cleanup(func() { os.Args = args })
+	t.Setenv(envPrefixVar, "")
+	t.Setenv("HEALTH_CONFIG_DIR", dir)
+
+	cfg, sources, err := LoadWithSources()
+	if err != nil {
+		t.Fatalf("LoadWithSources: %v", err)
+	}
+	if cfg.Service != "nginx" || cfg.Port != 9191 {
+		t.Errorf("Expected service nginx port 9191 from the drop-in, got %q %d", cfg.Service, cfg.Port)
+	}
+	if got, want := sources.Of("port"), "file:"+filepath.Join(dir, "10-base.yaml"); got != want {
+		t.Errorf("Expected port from %s, got %s", want, got)
+	}
+}
+
+// TestEffectiveRedactsSecrets verifies the effective configuration is keyed
+// by setting name, hides secrets that are set and leaves unset ones empty.
+func TestEffectiveRedactsSecrets(t *testing.T) {
+	cfg := &Config{
+		Port:            8080,
+		Service:         "nginx",
+		MetricsAuthUser: "prometheus",
+		MetricsAuthPass: "hunter2",
+		APIToken:        "s3cret",
+		SlackWebhookURL: "https://hooks.slack.com/services/T000/B000/XXXX",
+		CheckConfig:     true,
+	}
+
+	got := cfg.Effective()
+	if got["port"] != 8080 || got["service"] != "nginx" || got["metrics_auth_user"] != "prometheus" {
+		t.Errorf("Expected plain settings keyed by name, got port=%v service=%v metrics_auth_user=%v",
+			got["port"], got["service"], got["metrics_auth_user"])
+	}
+	for _, key := range []string{"metrics_auth_pass", "api_token", "slack_webhook_url"} {
+		if got[key] != redactedValue {
+			t.Errorf("Expected %s to be redacted, got %v", key, got[key])
+		}
+	}
+	if got["dashboard_auth_pass"] != "" {
+		t.Errorf("Expected unset secret to stay empty, got %v", got["dashboard_auth_pass"])
+	}
+	if _, ok := got["check_config"]; ok {
+		t.Error("Expected check_config to be left out")
+	}
+
+	out, err := cfg.EffectiveYAML()
+	if err != nil {
+		t.Fatalf("EffectiveYAML: %v", err)
+	}
+	for _, secret := range []string{"hunter2", "s3cret", "XXXX"} {
+		if strings.Contains(string(out), secret) {
+			t.Errorf("YAML output leaks %q:\n%s", secret, out)
+		}
+	}
+}
+
+// TestEnvPrefix verifies the flag overrides HEALTH_ENV_PREFIX, which
+// overrides the HEALTH_ default, and that a trailing underscore is added.
+func TestEnvPrefix(t *testing.T) {
+	tests := []struct {
+		name string
+		flag string
+		env  string
+		want string
+	}{
+		{"default", "", "", "HEALTH_"},
+		{"env var", "", "WEB_", "WEB_"},
+		{"env var without underscore", "", "WEB", "WEB_"},
+		{"flag overrides env var", "API", "WEB_", "API_"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envPrefixVar, tt.env)
+			if got := envPrefix(tt.flag); got != tt.want {
+				t.Errorf("envPrefix(%q) with %s=%q = %q, want %q", tt.flag, envPrefixVar, tt.env, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCheckConfigRequested verifies --check_config is detected among the
+// other flags, and from the environment under the active prefix, before
+// the configuration is loaded.
+func TestCheckConfigRequested(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want bool
+	}{
+		{"absent", []string{"--service", "nginx", "--port", "9090"}, nil, false},
+		{"flag", []string{"--service", "nginx", "--check_config"}, nil, true},
+		{"flag false", []string{"--check_config=false"}, map[string]string{"HEALTH_CHECK_CONFIG": "true"}, false},
+		{"env", []string{"--service", "nginx"}, map[string]string{"HEALTH_CHECK_CONFIG": "true"}, true},
+		{"env with prefix flag", []string{"--env_prefix", "WEB_"}, map[string]string{"WEB_CHECK_CONFIG": "1"}, true},
+		{"env under other prefix", []string{"--env_prefix", "WEB_"}, map[string]string{"HEALTH_CHECK_CONFIG": "1"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envPrefixVar, "")
+			t.Setenv("HEALTH_CHECK_CONFIG", "")
+			t.Setenv("WEB_CHECK_CONFIG", "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if got := CheckConfigRequested(tt.args); got != tt.want {
+				t.Errorf("CheckConfigRequested(%q) = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestValidationHintsUseEnvPrefix verifies validation hints name the
+// environment variables under the active prefix, and that the original
+// error is still reachable.
+func TestValidationHintsUseEnvPrefix(t *testing.T) {
+	err := (&Config{Port: 0, Service: "nginx", Interval: 10}).Validate()
+	if err == nil {
+		t.Fatal("Expected an error for port 0")
+	}
+
+	if got := withEnvPrefix(err, defaultEnvPrefix); got != err {
+		t.Errorf("Expected the error unchanged under the default prefix, got %v", got)
+	}
+
+	got := withEnvPrefix(err, "WEB_")
+	if !strings.Contains(got.Error(), "WEB_PORT=8080") || strings.Contains(got.Error(), "HEALTH_") {
+		t.Errorf("Expected the hint to name WEB_PORT, got %q", got)
+	}
+	if !errors.Is(got, err) {
+		t.Error("Expected the original error to be wrapped")
+	}
+}
@@ -7,25 +7,58 @@
 // request, preventing connection exhaustion under high load.
 //
 // Endpoints:
-//   GET /health - Returns service health with appropriate HTTP status codes
+//   GET / - Serves the dashboard (embedded, or from a configured directory)
+//   GET /health - Returns aggregate health with appropriate HTTP status codes
 //                 (200, 503, or 500)
+//   GET /health/{service} - Returns health of a single monitored service
+//   GET /livez - Returns 200 while the background checker is responsive
+//   GET /readyz - Same as /health; fails when a monitored service is down
 //   GET /api/status - Returns JSON status for dashboard and programmatic access
+//   GET /api/history - Returns recent state transitions as JSON
+//   GET /api/logs - Returns recent journald entries for a monitored unit
+//   GET /version - Returns build metadata as JSON
+//   POST /admin/drain - Forces health endpoints to 503 for graceful LB removal
+//   POST /admin/undrain - Clears drain mode
+//   GET /admin/ratelimit - Returns rate limiter stats per endpoint group
+//   POST /admin/restart - Restarts a monitored unit (only with allow_restart)
+//   POST /admin/reset-stats - Resets a service's uptime and downtime counters
 //
 // -----------------------------------------------------------------------
 
 package handlers
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
+	"mime"
 	"net/http"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/afreidah/health-check-service/internal/cache"
+	"github.com/afreidah/health-check-service/internal/checker"
+	"github.com/afreidah/health-check-service/internal/journal"
+	"github.com/afreidah/health-check-service/internal/logging"
 	"github.com/afreidah/health-check-service/internal/metrics"
+	"github.com/afreidah/health-check-service/internal/ratelimit"
+	"github.com/afreidah/health-check-service/internal/tracing"
+	"github.com/afreidah/health-check-service/internal/version"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // -----------------------------------------------------------------------
@@ -38,32 +71,284 @@ const (
 
 	// allowedMethods lists HTTP methods accepted by health endpoints
 	allowedMethods = "GET, HEAD"
+
+	// initializingWarning is the Warning header sent before the first
+	// check of a service has completed.
+	initializingWarning = "199 - Health checker initializing"
+
+	// drainingState is the state reported by health endpoints in drain mode
+	drainingState = "draining"
 )
 
 var logh = slog.Default().With("component", "http")
 
+// accessLog and accessLogSample control the per-request health log; see
+// ConfigureAccessLog. accessLogCount numbers requests for sampling.
+var (
+	accessLog              = true
+	accessLogSample uint64 = 1
+	accessLogCount  atomic.Uint64
+)
+
+// ConfigureAccessLog sets how health requests are logged. When enabled is
+// false the per-request log is written at Debug instead of Info; sample
+// logs only one in every sample requests (values below 2 log all). Metrics
+// are recorded for every request either way. Must be called before the
+// HTTP server starts since the settings are read without locking.
+func ConfigureAccessLog(enabled bool, sample int) {
+	accessLog = enabled
+	accessLogSample = 1
+	if sample > 1 {
+		accessLogSample = uint64(sample)
+	}
+}
+
+// accessLogLevel returns the level for this request's access log, or false
+// when sampling skips it.
+func accessLogLevel() (slog.Level, bool) {
+	if accessLogSample > 1 && (accessLogCount.Add(1)-1)%accessLogSample != 0 {
+		return 0, false
+	}
+	if !accessLog {
+		return slog.LevelDebug, true
+	}
+	return slog.LevelInfo, true
+}
+
+// aggregatePolicy decides when the aggregate /health is healthy; see
+// ConfigureAggregatePolicy.
+var aggregatePolicy cache.AggregatePolicy
+
+// ConfigureAggregatePolicy sets how many monitored services must be healthy
+// for the aggregate /health to return 200. The default requires all of
+// them. Must be called before the HTTP server starts since the policy is
+// read without locking.
+func ConfigureAggregatePolicy(policy cache.AggregatePolicy) {
+	aggregatePolicy = policy
+}
+
+// pollInterval is the dashboard refresh interval in seconds reported by
+// /api/status; see ConfigurePollInterval.
+var pollInterval atomic.Int64
+
+// ConfigurePollInterval sets the refresh interval, in seconds, that
+// /api/status tells the dashboard to use. Zero omits it, leaving the
+// dashboard at its built-in default. Safe to call while serving, e.g. on
+// config reload.
+func ConfigurePollInterval(seconds int) {
+	pollInterval.Store(int64(seconds))
+}
+
+// healthBodyOK and healthBodyFail are written as the body of healthy and
+// failing health responses; see ConfigureHealthBodies.
+var healthBodyOK, healthBodyFail string
+
+// ConfigureHealthBodies sets plain-text bodies for healthy (200) and
+// failing health responses, for uptime monitors that match on the body
+// rather than the status code. An empty string keeps the body empty. Must
+// be called before the HTTP server starts since the bodies are read
+// without locking.
+func ConfigureHealthBodies(ok, fail string) {
+	healthBodyOK = ok
+	healthBodyFail = fail
+}
+
+// exemplars attaches request IDs to request duration observations; see
+// ConfigureExemplars.
+var exemplars bool
+
+// ConfigureExemplars enables request ID exemplars on the request duration
+// histogram. They are only exposed when /metrics is scraped in the
+// OpenMetrics format. Must be called before the HTTP server starts since
+// the setting is read without locking.
+func ConfigureExemplars(enabled bool) {
+	exemplars = enabled
+}
+
+// requestIDHeaders are the request headers a request ID is taken from;
+// see ConfigureRequestIDHeaders.
+var requestIDHeaders = []string{requestIDHeader}
+
+// ConfigureRequestIDHeaders sets the request headers a request ID is taken
+// from, checked in order, so IDs assigned upstream (X-Amzn-Trace-Id, a
+// mesh's traceparent) correlate with this service's logs. A traceparent
+// header contributes its trace ID. With none present a random ID is
+// generated. Must be called before the HTTP server starts since the list
+// is read without locking.
+func ConfigureRequestIDHeaders(headers []string) {
+	requestIDHeaders = nil
+	for _, header := range headers {
+		if header = strings.TrimSpace(header); header != "" {
+			requestIDHeaders = append(requestIDHeaders, header)
+		}
+	}
+}
+
+// staleStatusCode replaces the cached status of a stale health response;
+// zero keeps the cached status. See ConfigureStaleStatusCode.
+var staleStatusCode int
+
+// ConfigureStaleStatusCode sets the status health endpoints return while
+// their data is older than staleThreshold, e.g. 503 so load balancers route
+// away from a node whose checker is stuck. Zero keeps the cached status.
+// Must be called before the HTTP server starts since the code is read
+// without locking.
+func ConfigureStaleStatusCode(code int) {
+	staleStatusCode = code
+}
+
+// ProcessStart is when the process started, reported as process uptime by
+// the status API. main sets it first thing; the package-init value is only
+// a fallback.
+var ProcessStart = time.Now()
+
 // -----------------------------------------------------------------------
 // Request Helpers
 // -----------------------------------------------------------------------
 
-// requestID returns or generates a request ID for tracing. Checks
-// X-Request-ID header first, then generates a random ID if not present.
+// requestIDHeader carries the request ID back out in responses, and is
+// the default header it is taken from.
+const requestIDHeader = "X-Request-ID"
+
+// traceparentHeader is the W3C Trace Context header.
+const traceparentHeader = "traceparent"
+
+// requestKey is the context key for the requestInfo set by WithRequestLogger.
+type requestKey struct{}
+
+// requestInfo is the per-request ID and the logger tagged with it.
+type requestInfo struct {
+	id  string
+	log *slog.Logger
+}
+
+// WithRequestLogger returns r carrying its request ID and a logger tagged
+// with request_id, client_ip and endpoint, so every line logged for the
+// request correlates. client_ip is the peer address or trusted proxy
+// header rather than the raw X-Forwarded-For, which the caller controls.
+// The ID is echoed in the X-Request-ID response header. Call after
+// WithEndpoint; the rate limiting middleware does so for every route.
+func WithRequestLogger(w http.ResponseWriter, r *http.Request) *http.Request {
+	id := newRequestID(r)
+	w.Header().Set(requestIDHeader, id)
+
+	log := logging.WithRequest(r.Context(), logh, map[string]any{
+		"request_id": id,
+		"client_ip":  ratelimit.GetIP(r),
+		"endpoint":   endpointName(r),
+	})
+	return r.WithContext(context.WithValue(r.Context(), requestKey{}, requestInfo{id: id, log: log}))
+}
+
+// requestID returns the ID set by WithRequestLogger. Requests that did not
+// pass through it get one from newRequestID.
 func requestID(r *http.Request) string {
-	if id := r.Header.Get("X-Request-ID"); id != "" {
-		return id
+	if info, ok := r.Context().Value(requestKey{}).(requestInfo); ok {
+		return info.id
+	}
+	return newRequestID(r)
+}
+
+// requestLog returns the logger set by WithRequestLogger, or the package
+// logger tagged the same way for requests that did not pass through it.
+func requestLog(r *http.Request) *slog.Logger {
+	if info, ok := r.Context().Value(requestKey{}).(requestInfo); ok {
+		return info.log
+	}
+	return logh.With("request_id", requestID(r), "client_ip", ratelimit.GetIP(r), "endpoint", endpointName(r))
+}
+
+// newRequestID returns the value of the first configured request ID header
+// that is present, or a random ID if none is. A malformed traceparent is
+// skipped.
+func newRequestID(r *http.Request) string {
+	for _, header := range requestIDHeaders {
+		value := strings.TrimSpace(r.Header.Get(header))
+		if value == "" {
+			continue
+		}
+		if strings.EqualFold(header, traceparentHeader) {
+			if traceID, ok := traceparentTraceID(value); ok {
+				return traceID
+			}
+			continue
+		}
+		return value
 	}
 	var b [12]byte
 	_, _ = rand.Read(b[:])
 	return hex.EncodeToString(b[:])
 }
 
-// clientIP extracts the client IP from the request, respecting X-Forwarded-For
-// header when behind a proxy.
-func clientIP(r *http.Request) string {
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		return xff
+// traceparentTraceID returns the trace ID of a W3C traceparent header,
+// version-traceid-parentid-flags, such as
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01. The all-zero
+// trace ID and version ff are invalid.
+func traceparentTraceID(value string) (string, bool) {
+	parts := strings.Split(value, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 {
+		return "", false
+	}
+	traceID := parts[1]
+	if !isLowerHex(parts[0]) || !isLowerHex(traceID) || strings.Trim(traceID, "0") == "" {
+		return "", false
+	}
+	return traceID, true
+}
+
+// isLowerHex reports whether s is non-empty lowercase hexadecimal, the
+// only form Trace Context allows.
+func isLowerHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// observeDuration records a request's latency for its endpoint. With
+// exemplars enabled the request ID is attached, linking a slow bucket to
+// the request that landed in it. IDs an exemplar cannot carry (invalid
+// UTF-8, or over prometheus.ExemplarMaxRunes with the label name) are
+// observed without one.
+func observeDuration(r *http.Request, reqID string, seconds float64) {
+	observer := metrics.RequestDuration.WithLabelValues(endpointName(r))
+	if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok && exemplars && exemplarFits(reqID) {
+		exemplarObserver.ObserveWithExemplar(seconds, prometheus.Labels{exemplarLabel: reqID})
+		return
+	}
+	observer.Observe(seconds)
+}
+
+// exemplarLabel is the exemplar label holding the request ID.
+const exemplarLabel = "request_id"
+
+// exemplarFits reports whether reqID is a valid exemplar label value;
+// ObserveWithExemplar panics on one that is not.
+func exemplarFits(reqID string) bool {
+	return utf8.ValidString(reqID) &&
+		utf8.RuneCountInString(exemplarLabel)+utf8.RuneCountInString(reqID) <= prometheus.ExemplarMaxRunes
+}
+
+// endpointKey is the context key for the endpoint name set by WithEndpoint.
+type endpointKey struct{}
+
+// WithEndpoint returns r tagged with the endpoint name used to label its
+// request metrics. The rate limiting middleware sets it for every route.
+func WithEndpoint(r *http.Request, endpoint string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), endpointKey{}, endpoint))
+}
+
+// endpointName returns the endpoint set by WithEndpoint, or "unknown".
+func endpointName(r *http.Request) string {
+	if endpoint, ok := r.Context().Value(endpointKey{}).(string); ok {
+		return endpoint
 	}
-	return r.RemoteAddr
+	return "unknown"
 }
 
 // -----------------------------------------------------------------------
@@ -97,24 +382,275 @@ func validateMethod(w http.ResponseWriter, r *http.Request) bool {
 
 // HealthHandler serves the /health endpoint by returning the cached service
 // status. Returns 200 if active, 503 if unavailable, 500 if error checking.
+// A checker in the error state always returns 500, and a service that has
+// not been checked yet 503 with an initializing Warning header.
+// The body is empty unless the client sends Accept: application/json, in
+// which case the /api/status payload is returned with the same status code,
+// or passes ?verbose=1, in which case a failing response explains why in
+// plain text.
 //
 // The handler reads from cache rather than querying systemd directly to
 // prevent D-Bus connection exhaustion under high request volume. Metrics are
-// recorded regardless of outcome via defer.
-func HealthHandler(w http.ResponseWriter, r *http.Request, serviceCache *cache.ServiceCache) {
+// recorded regardless of outcome via defer, with serviceName labelling the
+// staleness gauge and naming the service in the body.
+func HealthHandler(w http.ResponseWriter, r *http.Request, serviceCache cache.StatusStore, serviceName string) {
+	r, span := tracing.StartRequest(r, "HealthHandler")
+	defer span.End()
+
+	statusCode, state := effectiveStatus(serviceCache)
+	services := map[string]cache.StatusStore{serviceName: serviceCache}
+	writeHealth(w, r, statusCode, state, serviceCache.GetLastChecked(), services, healthBody{
+		json: func() any {
+			return statusResponse(serviceCache, serviceName)
+		},
+		reason: func() string {
+			return healthReason(serviceName, serviceCache)
+		},
+	})
+}
+
+// effectiveStatus returns the cached status folded with the service's
+// dependencies: an otherwise healthy service with a failed dependency is
+// reported as 503 with the state "<unit>:<state>" of that dependency.
+// The cache's lifecycle state comes first, so a checker in the error state
+// always answers 500 and one that has not checked yet 503, on both the
+// per-service and aggregate endpoints.
+func effectiveStatus(serviceCache cache.StatusStore) (int, string) {
+	statusCode, state := serviceCache.GetStatus()
+	switch {
+	case serviceCache.IsError():
+		return http.StatusInternalServerError, state
+	case serviceCache.IsUninitialized():
+		return http.StatusServiceUnavailable, state
+	}
+	if statusCode != http.StatusOK {
+		return statusCode, state
+	}
+	if dep, failed := serviceCache.FailedDependency(); failed {
+		return http.StatusServiceUnavailable, dep.Unit + ":" + dep.State
+	}
+	return statusCode, state
+}
+
+// ServiceHealthHandler serves /health/{service} by looking up the named
+// service's cache. Returns 404 if the service is not monitored, allowing each
+// service to be wired into a separate load balancer health probe.
+func ServiceHealthHandler(w http.ResponseWriter, r *http.Request, caches map[string]cache.StatusStore) {
+	name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/health/"), "/")
+
+	serviceCache, ok := caches[name]
+	if !ok {
+		metrics.RequestsTotal.WithLabelValues(endpointName(r), "404").Inc()
+		http.NotFound(w, r)
+		return
+	}
+
+	HealthHandler(w, r, serviceCache, name)
+}
+
+// AggregateHealthHandler serves /health across all monitored services.
+// Returns 200 when enough services are healthy for the aggregate policy
+// (by default, all of them); otherwise returns the status of the first
+// unhealthy service (in name order). Staleness is judged by the oldest
+// cache so a stuck checker for any service is surfaced.
+func AggregateHealthHandler(w http.ResponseWriter, r *http.Request, caches map[string]cache.StatusStore) {
+	r, span := tracing.StartRequest(r, "AggregateHealthHandler")
+	defer span.End()
+
+	statusCode, state, lastChecked := aggregateStatus(caches)
+	writeHealth(w, r, statusCode, state, lastChecked, caches, healthBody{
+		json: func() any {
+			return aggregateHealthResponse(caches, statusCode, state)
+		},
+		reason: func() string {
+			return aggregateHealthReason(caches)
+		},
+	})
+}
+
+// AggregateHealthResponse is the JSON body of /health when the client asks
+// for application/json and more than one service is monitored.
+type AggregateHealthResponse struct {
+	Healthy    bool             `json:"healthy"`
+	StatusCode int              `json:"status_code"`
+	State      string           `json:"state"`
+	Policy     string           `json:"policy"`
+	Services   []StatusResponse `json:"services"`
+}
+
+// aggregateHealthResponse builds the JSON body for the aggregate /health
+// endpoint. A single monitored service gets the plain /api/status payload so
+// the common case matches /health/{service}.
+func aggregateHealthResponse(caches map[string]cache.StatusStore, statusCode int, state string) any {
+	names := make([]string, 0, len(caches))
+	for name := range caches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(names) == 1 {
+		return statusResponse(caches[names[0]], names[0])
+	}
+
+	services := make([]StatusResponse, 0, len(names))
+	for _, name := range names {
+		services = append(services, statusResponse(caches[name], name))
+	}
+
+	return AggregateHealthResponse{
+		Healthy:    statusCode == http.StatusOK,
+		StatusCode: statusCode,
+		State:      state,
+		Policy:     aggregatePolicy.String(),
+		Services:   services,
+	}
+}
+
+// AggregateStatus is the aggregate policy evaluated over the monitored
+// services, with the per-service results it was based on.
+type AggregateStatus struct {
+	Policy       string          `json:"policy"`
+	Healthy      bool            `json:"healthy"`
+	HealthyCount int             `json:"healthy_count"`
+	Services     []ServiceHealth `json:"services"`
+}
+
+// ServiceHealth is one service's contribution to an AggregateStatus.
+type ServiceHealth struct {
+	Service    string `json:"service"`
+	Healthy    bool   `json:"healthy"`
+	StatusCode int    `json:"status_code"`
+	State      string `json:"state"`
+}
+
+// evaluateAggregate applies policy to the effective status of each
+// service. Services are listed in name order.
+func evaluateAggregate(caches map[string]cache.StatusStore, policy cache.AggregatePolicy) AggregateStatus {
+	names := make([]string, 0, len(caches))
+	for name := range caches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	agg := AggregateStatus{
+		Policy:   policy.String(),
+		Services: make([]ServiceHealth, 0, len(names)),
+	}
+	for _, name := range names {
+		code, state := effectiveStatus(caches[name])
+		healthy := code == http.StatusOK
+		if healthy {
+			agg.HealthyCount++
+		}
+		agg.Services = append(agg.Services, ServiceHealth{
+			Service:    name,
+			Healthy:    healthy,
+			StatusCode: code,
+			State:      state,
+		})
+	}
+	agg.Healthy = policy.Satisfied(agg.HealthyCount, len(names))
+	return agg
+}
+
+// aggregateStatus folds per-service cache status into a single result
+// using the configured aggregate policy. When the policy is met but some
+// services are down, the state is "degraded". Otherwise the first
+// unhealthy service (in name order) is reported, so the result is stable
+// across requests.
+func aggregateStatus(caches map[string]cache.StatusStore) (int, string, time.Time) {
+	// No services means nothing can be vouched for
+	if len(caches) == 0 {
+		return http.StatusServiceUnavailable, "uninitialized", time.Time{}
+	}
+
+	var oldest time.Time
+	for _, c := range caches {
+		if lastChecked := c.GetLastChecked(); oldest.IsZero() || lastChecked.Before(oldest) {
+			oldest = lastChecked
+		}
+	}
+
+	agg := evaluateAggregate(caches, aggregatePolicy)
+	if agg.Healthy {
+		if agg.HealthyCount < len(agg.Services) {
+			return http.StatusOK, "degraded", oldest
+		}
+		return http.StatusOK, "active", oldest
+	}
+
+	for _, s := range agg.Services {
+		if s.Healthy {
+			continue
+		}
+		state := s.State
+		if len(agg.Services) > 1 {
+			state = s.Service + ":" + s.State
+		}
+		return s.StatusCode, state, oldest
+	}
+
+	// Every service is healthy but there are fewer than the quorum
+	return http.StatusServiceUnavailable, "quorum_not_met", oldest
+}
+
+// recordStaleness sets the staleness gauge of each cache older than
+// staleThreshold under its own service label. Caches without a name are
+// skipped rather than merged into an empty label shared by every service.
+func recordStaleness(services map[string]cache.StatusStore) {
+	for name, c := range services {
+		if name == "" {
+			continue
+		}
+		if staleness := time.Since(c.GetLastChecked()); staleness > staleThreshold {
+			metrics.CacheStaleness.WithLabelValues(name).Set(staleness.Seconds())
+		}
+	}
+}
+
+// healthBody renders the optional bodies of a health response. Either
+// function may be nil when the endpoint has no such body.
+type healthBody struct {
+	// json returns the payload for clients sending Accept: application/json
+	json func() any
+
+	// reason returns a plain-text explanation for ?verbose=1 on failure
+	reason func() string
+}
+
+// writeHealth writes a health response for the given status and records
+// request metrics. A Warning header is added when lastChecked is older than
+// staleThreshold, the status is replaced by the configured stale status
+// code if any, and the staleness of each stale cache in services, keyed by
+// service name, is recorded. The body is the configured health body, if
+// any, unless the client asks for JSON or, on a failing status, for a
+// verbose reason; HEAD requests never get one.
+func writeHealth(
+	w http.ResponseWriter,
+	r *http.Request,
+	statusCode int,
+	state string,
+	lastChecked time.Time,
+	services map[string]cache.StatusStore,
+	body healthBody,
+) {
 	reqID := requestID(r)
 	start := time.Now()
-	var statusCode int
+	span := trace.SpanFromContext(r.Context())
+	span.SetAttributes(attribute.String("request_id", reqID))
 
 	defer func() {
 		duration := time.Since(start).Seconds()
-		metrics.RequestDuration.Observe(duration)
+		observeDuration(r, reqID, duration)
 		metrics.RequestsTotal.
-			WithLabelValues(fmt.Sprintf("%d", statusCode)).
+			WithLabelValues(endpointName(r), fmt.Sprintf("%d", statusCode)).
 			Inc()
+		span.SetAttributes(
+			attribute.String("service.state", state),
+			attribute.Int("http.response.status_code", statusCode),
+		)
 
-		logh.Debug("health request completed",
-			"request_id", reqID,
+		requestLog(r).Debug("health request completed",
 			"method", r.Method,
 			"status", statusCode,
 			"duration_ms", int(duration*1000),
@@ -128,28 +664,237 @@ func HealthHandler(w http.ResponseWriter, r *http.Request, serviceCache *cache.S
 
 	setSecurityHeaders(w)
 
-	statusCode, state := serviceCache.GetStatus()
-
-	logh.Info("health request",
-		"request_id", reqID,
-		"client_ip", clientIP(r),
-		"state", state,
-		"status_code", statusCode,
-		"method", r.Method,
-	)
-
-	// Add warning header if cached data is stale
-	if serviceCache.IsStale(staleThreshold) {
-		staleness := time.Since(serviceCache.GetLastChecked())
+	// Data that was never checked is not stale but still initializing;
+	// otherwise add a warning header if cached data is stale, and replace
+	// the cached status when configured so load balancers that ignore
+	// Warning still route away from a node whose checker is stuck
+	if lastChecked.IsZero() {
+		w.Header().Set("Warning", initializingWarning)
+	} else if staleness := time.Since(lastChecked); staleness > staleThreshold {
 		w.Header().Set("Warning", fmt.Sprintf("199 - Stale health check data (age: %ds)",
 			int(staleness.Seconds())))
 
-		logh.Warn("serving stale health data",
-			"request_id", reqID,
+		requestLog(r).Warn("serving stale health data",
 			"staleness_seconds", int(staleness.Seconds()),
 			"state", state)
 
-		metrics.CacheStaleness.WithLabelValues("").Set(staleness.Seconds())
+		recordStaleness(services)
+
+		if staleStatusCode != 0 {
+			statusCode = staleStatusCode
+		}
+	}
+
+	if level, ok := accessLogLevel(); ok {
+		requestLog(r).Log(r.Context(), level, "health request",
+			"state", state,
+			"status_code", statusCode,
+			"method", r.Method,
+		)
+	}
+
+	// The body depends on Accept, so shared caches must key on it
+	if body.json != nil {
+		w.Header().Add("Vary", "Accept")
+	}
+
+	switch {
+	case r.Method == http.MethodHead:
+		w.WriteHeader(statusCode)
+
+	case body.json != nil && acceptsJSON(r.Header.Get("Accept")):
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(statusCode)
+		if err := json.NewEncoder(w).Encode(body.json()); err != nil {
+			requestLog(r).Error("error encoding health response",
+				"error", err.Error())
+		}
+
+	case body.reason != nil && statusCode != http.StatusOK && verbose(r):
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(statusCode)
+		fmt.Fprintln(w, body.reason())
+
+	case statusCode == http.StatusOK && healthBodyOK != "":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(statusCode)
+		fmt.Fprint(w, healthBodyOK)
+
+	case statusCode != http.StatusOK && healthBodyFail != "":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(statusCode)
+		fmt.Fprint(w, healthBodyFail)
+
+	default:
+		w.WriteHeader(statusCode)
+	}
+}
+
+// verbose reports whether the request asked for a reason body with
+// ?verbose=1 (or any other true value accepted by strconv.ParseBool).
+func verbose(r *http.Request) bool {
+	v, err := strconv.ParseBool(r.URL.Query().Get("verbose"))
+	return err == nil && v
+}
+
+// healthReason explains why a service is not healthy, e.g. "service nginx
+// is failed" or "cache stale (age 42s)". Returns "" when there is nothing
+// to report.
+func healthReason(serviceName string, serviceCache cache.StatusStore) string {
+	subject := "service"
+	if serviceName != "" {
+		subject = "service " + serviceName
+	}
+
+	var reasons []string
+	statusCode, state := serviceCache.GetStatus()
+
+	switch serviceCache.GetCacheState() {
+	case cache.StateUninitialized:
+		// Staleness is meaningless before the first check
+		return subject + " has not been checked yet"
+	case cache.StateError:
+		reasons = append(reasons, fmt.Sprintf("error checking %s (%s)", subject, state))
+	default:
+		if statusCode != http.StatusOK {
+			reasons = append(reasons, fmt.Sprintf("%s is %s%s", subject, state, activityNote(state, serviceCache)))
+		} else if dep, failed := serviceCache.FailedDependency(); failed {
+			reasons = append(reasons, fmt.Sprintf("%s dependency %s is %s", subject, dep.Unit, dep.State))
+		}
+	}
+
+	if serviceCache.IsStale(staleThreshold) {
+		reasons = append(reasons, fmt.Sprintf("cache stale (age %ds)",
+			int(serviceCache.GetStaleness().Seconds())))
+	}
+
+	return strings.Join(reasons, "; ")
+}
+
+// activityNote qualifies an inactive state as " (never started)" or
+// " (stopped, last active <time>)", which a bare "inactive" cannot tell
+// apart. Returns "" for other states or before SubState has been read.
+func activityNote(state string, serviceCache cache.StatusStore) string {
+	subState, lastActive := serviceCache.GetActivity()
+	if state != checker.StateInactive || subState == "" {
+		return ""
+	}
+	if lastActive.IsZero() {
+		return " (never started)"
+	}
+	return " (stopped, last active " + lastActive.UTC().Format(time.RFC3339) + ")"
+}
+
+// aggregateHealthReason lists the reason for every unhealthy service, one
+// per line in name order.
+func aggregateHealthReason(caches map[string]cache.StatusStore) string {
+	if len(caches) == 0 {
+		return "no services monitored"
+	}
+
+	names := make([]string, 0, len(caches))
+	for name := range caches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines []string
+	if aggregatePolicy.Mode != cache.PolicyAll {
+		agg := evaluateAggregate(caches, aggregatePolicy)
+		lines = append(lines, fmt.Sprintf("policy %s not met: %d of %d services healthy",
+			agg.Policy, agg.HealthyCount, len(agg.Services)))
+	}
+	for _, name := range names {
+		if reason := healthReason(name, caches[name]); reason != "" {
+			lines = append(lines, reason)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// acceptsJSON reports whether an Accept header explicitly lists
+// application/json with a non-zero quality. Wildcards such as */* do not
+// count so existing probes keep getting an empty body.
+func acceptsJSON(accept string) bool {
+	return acceptsMediaType(accept, "application/json")
+}
+
+// acceptsMediaType reports whether an Accept header explicitly lists
+// mediaType with a non-zero quality. Wildcards do not count.
+func acceptsMediaType(accept, want string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || mediaType != want {
+			continue
+		}
+		if q, ok := params["q"]; ok {
+			if v, err := strconv.ParseFloat(q, 64); err != nil || v == 0 {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// DrainingHealthHandler serves /health and /readyz while drain mode is on.
+// Returns 503 regardless of service state so load balancers stop routing to
+// this node before it is shut down.
+func DrainingHealthHandler(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, r, http.StatusServiceUnavailable, drainingState, time.Now(), nil, healthBody{
+		reason: func() string {
+			return "node is draining"
+		},
+	})
+}
+
+// -----------------------------------------------------------------------
+// Liveness Handler
+// -----------------------------------------------------------------------
+
+// CheckerLiveness reports whether background checkers have completed a
+// check within maxAge. *checker.CheckerHealth tracks a single checker.
+type CheckerLiveness interface {
+	IsHealthy(maxAge time.Duration) bool
+}
+
+// LivenessHandler serves /livez for process liveness probes. Returns 200 as
+// long as the checkers have updated within maxAge, regardless of the
+// monitored services' state, so orchestrators restart the process only when
+// it is wedged rather than when a monitored service is down.
+func LivenessHandler(
+	w http.ResponseWriter,
+	r *http.Request,
+	checkerHealth CheckerLiveness,
+	maxAge time.Duration,
+) {
+	reqID := requestID(r)
+	start := time.Now()
+	statusCode := http.StatusOK
+
+	defer func() {
+		duration := time.Since(start).Seconds()
+		observeDuration(r, reqID, duration)
+		metrics.RequestsTotal.WithLabelValues(endpointName(r), fmt.Sprintf("%d", statusCode)).Inc()
+
+		requestLog(r).Debug("liveness request completed",
+			"status", statusCode,
+			"duration_ms", int(duration*1000),
+		)
+	}()
+
+	if !validateMethod(w, r) {
+		statusCode = http.StatusMethodNotAllowed
+		return
+	}
+
+	setSecurityHeaders(w)
+
+	if !checkerHealth.IsHealthy(maxAge) {
+		statusCode = http.StatusServiceUnavailable
+		requestLog(r).Warn("liveness check failed: checker not responding",
+			"max_age", maxAge.String())
 	}
 
 	w.WriteHeader(statusCode)
@@ -172,6 +917,37 @@ type StatusResponse struct {
 	Healthy     bool      `json:"healthy"`
 	Stale       bool      `json:"stale"`
 	StalenessS  int       `json:"staleness_s"`
+	Restarts    uint32    `json:"restarts"`
+	DowntimeS   int       `json:"downtime_s"`
+
+	// SubState is systemd's SubState, e.g. "dead" or "exited". LastActive
+	// is when the unit last became active, or null if it has never run
+	// since boot: a never-started unit rather than a stopped one
+	SubState   string     `json:"sub_state,omitempty"`
+	LastActive *time.Time `json:"last_active"`
+
+	// ServiceActiveSince is when systemd reports the unit became active,
+	// i.e. the service's own uptime; null while it is not active
+	ServiceActiveSince *time.Time `json:"service_active_since"`
+
+	// StatsSince is when uptime and downtime started counting: the first
+	// check, or the last /admin/reset-stats
+	StatsSince time.Time `json:"stats_since"`
+
+	// Dependencies is only present when dependency checks are enabled
+	Dependencies []cache.Dependency `json:"dependencies,omitempty"`
+
+	// Aggregate is the aggregate policy result across all monitored
+	// services; only /api/status includes it
+	Aggregate *AggregateStatus `json:"aggregate,omitempty"`
+
+	// PollIntervalS is how often the dashboard should refresh; only
+	// /api/status includes it
+	PollIntervalS int `json:"poll_interval_s,omitempty"`
+
+	// Build and process metadata for the dashboard footer
+	Version        string `json:"version"`
+	ProcessUptimeS int    `json:"process_uptime_s"`
 }
 
 // -----------------------------------------------------------------------
@@ -183,35 +959,149 @@ type StatusResponse struct {
 // status information in the response body.
 //
 // Unlike /health which uses status codes, this endpoint provides structured
-// data for dashboards and programmatic clients.
+// data for dashboards and programmatic clients. The response describes
+// serviceCache; when caches is non-empty it also carries the aggregate
+// policy result across them. ?format=text or Accept: text/plain selects a
+// one-line plain text summary of the same data for use from a terminal.
 func StatusAPIHandler(
 	w http.ResponseWriter,
 	r *http.Request,
-	serviceCache *cache.ServiceCache,
+	serviceCache cache.StatusStore,
 	serviceName string,
+	caches map[string]cache.StatusStore,
 ) {
+	r, span := tracing.StartRequest(r, "StatusAPIHandler")
+	defer span.End()
+
 	reqID := requestID(r)
 	start := time.Now()
+	span.SetAttributes(
+		attribute.String("request_id", reqID),
+		attribute.String("systemd.unit", serviceName),
+	)
 
 	defer func() {
 		duration := time.Since(start).Seconds()
-		metrics.RequestDuration.Observe(duration)
-		metrics.RequestsTotal.WithLabelValues("200").Inc()
+		observeDuration(r, reqID, duration)
+		metrics.RequestsTotal.WithLabelValues(endpointName(r), "200").Inc()
 
-		logh.Debug("api status request completed",
-			"request_id", reqID,
+		requestLog(r).Debug("api status request completed",
 			"duration_ms", int(duration*1000),
 		)
 	}()
 
 	if !validateMethod(w, r) {
-		metrics.RequestsTotal.WithLabelValues("405").Inc()
+		metrics.RequestsTotal.WithLabelValues(endpointName(r), "405").Inc()
 		return
 	}
 
 	setSecurityHeaders(w)
 
-	statusCode, state := serviceCache.GetStatus()
+	response := statusResponse(serviceCache, serviceName)
+	isStale := response.Stale
+	if len(caches) > 0 {
+		agg := evaluateAggregate(caches, aggregatePolicy)
+		response.Aggregate = &agg
+	}
+	response.PollIntervalS = int(pollInterval.Load())
+
+	// The body depends on Accept, so shared caches must key on it
+	w.Header().Add("Vary", "Accept")
+
+	// Add CORS header for localhost development only
+	// Production deployments should use reverse proxy for CORS handling
+	origin := r.Header.Get("Origin")
+	if origin == "http://localhost:3000" || origin == "http://localhost:8080" {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Methods", allowedMethods)
+	}
+
+	if wantsText(r) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprint(w, statusText(response))
+		return
+	}
+
+	// Encode and send response
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		requestLog(r).Error("error encoding status response",
+			"error", err.Error())
+		return
+	}
+
+	requestLog(r).Debug("api status response sent",
+		"service", serviceName,
+		"status", response.Status,
+		"stale", isStale,
+	)
+}
+
+// wantsText reports whether /api/status should answer in plain text:
+// with ?format=text, or with an Accept header that lists text/plain but
+// not application/json. ?format=json forces JSON.
+func wantsText(r *http.Request) bool {
+	switch r.URL.Query().Get("format") {
+	case "text":
+		return true
+	case "json":
+		return false
+	}
+	accept := r.Header.Get("Accept")
+	return acceptsMediaType(accept, "text/plain") && !acceptsJSON(accept)
+}
+
+// statusText renders a status response as a compact summary for humans,
+// e.g. "nginx: healthy (active), checked 3s ago, uptime 99.2%", followed
+// by one line per service when an aggregate is included.
+func statusText(response StatusResponse) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s: %s (%s)", response.Service, response.Status, response.State)
+	if response.LastChecked.IsZero() {
+		b.WriteString(", not checked yet")
+	} else {
+		fmt.Fprintf(&b, ", checked %ds ago", response.StalenessS)
+	}
+	if response.Stale {
+		b.WriteString(" (stale)")
+	}
+	fmt.Fprintf(&b, ", uptime %.1f%%", response.Uptime)
+	if response.Restarts > 0 {
+		fmt.Fprintf(&b, ", %d restarts", response.Restarts)
+	}
+	for _, dep := range response.Dependencies {
+		if !dep.Healthy {
+			fmt.Fprintf(&b, ", dependency %s %s", dep.Unit, dep.State)
+		}
+	}
+	b.WriteString("\n")
+
+	if agg := response.Aggregate; agg != nil {
+		status := "healthy"
+		if !agg.Healthy {
+			status = "unhealthy"
+		}
+		fmt.Fprintf(&b, "aggregate: %s (%d/%d healthy, policy %s)\n",
+			status, agg.HealthyCount, len(agg.Services), agg.Policy)
+		for _, svc := range agg.Services {
+			status := "healthy"
+			if !svc.Healthy {
+				status = "unhealthy"
+			}
+			fmt.Fprintf(&b, "  %s: %s (%s)\n", svc.Service, status, svc.State)
+		}
+	}
+
+	return b.String()
+}
+
+// statusResponse builds the status payload for a single service. It is
+// shared by /api/status and the JSON form of /health.
+func statusResponse(serviceCache cache.StatusStore, serviceName string) StatusResponse {
+	// State is the unit's own; the status code folds in dependencies
+	_, state := serviceCache.GetStatus()
+	statusCode, _ := effectiveStatus(serviceCache)
 	lastChecked := serviceCache.GetLastChecked()
 	staleness := time.Since(lastChecked)
 	isStale := serviceCache.IsStale(staleThreshold)
@@ -225,6 +1115,23 @@ func StatusAPIHandler(
 		Healthy:     statusCode == http.StatusOK,
 		Stale:       isStale,
 		StalenessS:  int(staleness.Seconds()),
+		Restarts:    serviceCache.GetRestarts(),
+		DowntimeS:   int(serviceCache.GetDowntime().Seconds()),
+		StatsSince:  serviceCache.GetStatsSince(),
+
+		Dependencies: serviceCache.GetDependencies(),
+
+		Version:        version.Version,
+		ProcessUptimeS: int(time.Since(ProcessStart).Seconds()),
+	}
+
+	subState, lastActive := serviceCache.GetActivity()
+	response.SubState = subState
+	if !lastActive.IsZero() {
+		response.LastActive = &lastActive
+		if state == checker.StateActive {
+			response.ServiceActiveSince = &lastActive
+		}
 	}
 
 	// Map status code to human-readable status
@@ -239,32 +1146,427 @@ func StatusAPIHandler(
 		response.Status = "unknown"
 	}
 
-	response.Uptime = 99.9
+	// Flapping overrides the point-in-time status: the service may be up
+	// right now but is not stable
+	if serviceCache.IsFlapping() {
+		response.Status = "flapping"
+	}
+
+	response.Uptime = serviceCache.GetUptimePercent()
 
-	// Set response headers
+	return response
+}
+
+// -----------------------------------------------------------------------
+// History API Handler
+// -----------------------------------------------------------------------
+
+// HistoryResponse represents the JSON response for the history API endpoint.
+type HistoryResponse struct {
+	Service     string             `json:"service"`
+	Transitions []cache.Transition `json:"transitions"`
+}
+
+// HistoryAPIHandler serves the /api/history endpoint, returning the recorded
+// state transitions (oldest first) for the dashboard timeline.
+func HistoryAPIHandler(
+	w http.ResponseWriter,
+	r *http.Request,
+	serviceCache cache.StatusStore,
+	serviceName string,
+) {
+	reqID := requestID(r)
+	start := time.Now()
+
+	defer func() {
+		duration := time.Since(start).Seconds()
+		observeDuration(r, reqID, duration)
+
+		requestLog(r).Debug("api history request completed",
+			"duration_ms", int(duration*1000),
+		)
+	}()
+
+	if !validateMethod(w, r) {
+		metrics.RequestsTotal.WithLabelValues(endpointName(r), "405").Inc()
+		return
+	}
+
+	setSecurityHeaders(w)
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 
-	// Add CORS header for localhost development only
-	// Production deployments should use reverse proxy for CORS handling
-	origin := r.Header.Get("Origin")
-	if origin == "http://localhost:3000" || origin == "http://localhost:8080" {
-		w.Header().Set("Access-Control-Allow-Origin", origin)
-		w.Header().Set("Access-Control-Allow-Methods", allowedMethods)
+	response := HistoryResponse{
+		Service:     serviceName,
+		Transitions: serviceCache.GetHistory(),
 	}
 
-	// Encode and send response
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		logh.Error("error encoding status response",
-			"request_id", reqID,
-			"client_ip", clientIP(r),
+		requestLog(r).Error("error encoding history response",
 			"error", err.Error())
 		return
 	}
 
-	logh.Debug("api status response sent",
-		"request_id", reqID,
-		"service", serviceName,
-		"status", response.Status,
-		"stale", isStale,
-	)
+	metrics.RequestsTotal.WithLabelValues(endpointName(r), "200").Inc()
+}
+
+// -----------------------------------------------------------------------
+// Dashboard Handler
+// -----------------------------------------------------------------------
+
+// DashboardHandler serves the dashboard. When dir is set, files under it
+// are served by path and any other path gets dir's index.html so client-side
+// routes work. If dir is empty, or has no index.html, the embedded fallback
+// HTML is served instead, so a missing or half-deployed custom dashboard
+// never leaves the page blank.
+func DashboardHandler(w http.ResponseWriter, r *http.Request, dir string, fallback []byte) {
+	if dir != "" {
+		fsys := os.DirFS(dir)
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		if name == "" {
+			name = "index.html"
+		}
+
+		for _, candidate := range []string{name, "index.html"} {
+			if info, err := fs.Stat(fsys, candidate); err == nil && !info.IsDir() {
+				http.ServeFileFS(w, r, fsys, candidate)
+				return
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if _, err := w.Write(fallback); err != nil {
+		requestLog(r).Error("error writing dashboard", "err", err)
+	}
+}
+
+// -----------------------------------------------------------------------
+// Version Handler
+// -----------------------------------------------------------------------
+
+// VersionResponse represents the JSON response for the version endpoint.
+type VersionResponse struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+}
+
+// VersionHandler serves the /version endpoint, returning the build metadata
+// so operators can confirm which build is deployed without host access.
+func VersionHandler(w http.ResponseWriter, r *http.Request) {
+	if !validateMethod(w, r) {
+		metrics.RequestsTotal.WithLabelValues(endpointName(r), "405").Inc()
+		return
+	}
+
+	setSecurityHeaders(w)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	response := VersionResponse{
+		Version:   version.Version,
+		Commit:    version.Commit,
+		BuildDate: version.Date,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		requestLog(r).Error("error encoding version response",
+			"error", err.Error())
+		return
+	}
+
+	metrics.RequestsTotal.WithLabelValues(endpointName(r), "200").Inc()
+}
+
+// -----------------------------------------------------------------------
+// Drain Handlers
+// -----------------------------------------------------------------------
+
+// DrainResponse represents the JSON response for the drain endpoints.
+type DrainResponse struct {
+	Draining bool `json:"draining"`
+}
+
+// DrainHandler serves /admin/drain (drain=true) and /admin/undrain
+// (drain=false). Only POST is accepted since the request changes state.
+func DrainHandler(w http.ResponseWriter, r *http.Request, draining *atomic.Bool, drain bool) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		metrics.RequestsTotal.WithLabelValues(endpointName(r), "405").Inc()
+		return
+	}
+
+	if previous := draining.Swap(drain); previous != drain {
+		requestLog(r).Warn("drain mode changed",
+			"draining", drain)
+	}
+
+	setSecurityHeaders(w)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	if err := json.NewEncoder(w).Encode(DrainResponse{Draining: drain}); err != nil {
+		requestLog(r).Error("error encoding drain response",
+			"error", err.Error())
+		return
+	}
+
+	metrics.RequestsTotal.WithLabelValues(endpointName(r), "200").Inc()
+}
+
+// -----------------------------------------------------------------------
+// Logs API Handler
+// -----------------------------------------------------------------------
+
+// logsTimeout bounds how long journalctl may run for one request.
+const logsTimeout = 5 * time.Second
+
+// LogReader returns the most recent journal entries for service.
+type LogReader func(ctx context.Context, service string, lines int) ([]journal.Entry, error)
+
+// LogsResponse represents the JSON response for /api/logs.
+type LogsResponse struct {
+	Service string          `json:"service"`
+	Entries []journal.Entry `json:"entries"`
+}
+
+// LogsAPIHandler serves /api/logs with the last entries from the journal
+// for a monitored service. The service is selected like /admin/restart;
+// the "lines" query parameter may request fewer than maxLines entries but
+// never more. Returns 501 when journalctl is not installed on the host.
+func LogsAPIHandler(w http.ResponseWriter, r *http.Request, services []string, maxLines int, read LogReader) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		metrics.RequestsTotal.WithLabelValues(endpointName(r), "405").Inc()
+		return
+	}
+
+	service := r.URL.Query().Get("service")
+	if service == "" && len(services) > 0 {
+		service = services[0]
+	}
+	if !slices.Contains(services, service) {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		metrics.RequestsTotal.WithLabelValues(endpointName(r), "404").Inc()
+		return
+	}
+
+	if maxLines <= 0 {
+		maxLines = journal.DefaultLines
+	}
+	lines := maxLines
+	if v := r.URL.Query().Get("lines"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "Bad Request: lines must be a positive integer", http.StatusBadRequest)
+			metrics.RequestsTotal.WithLabelValues(endpointName(r), "400").Inc()
+			return
+		}
+		lines = min(n, maxLines)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), logsTimeout)
+	defer cancel()
+
+	entries, err := read(ctx, service, lines)
+	if errors.Is(err, journal.ErrUnavailable) {
+		http.Error(w, "Not Implemented: journalctl is not available on this host", http.StatusNotImplemented)
+		metrics.RequestsTotal.WithLabelValues(endpointName(r), "501").Inc()
+		return
+	}
+	if err != nil {
+		requestLog(r).Error("error reading journal",
+			"service", service,
+			"error", err.Error())
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		metrics.RequestsTotal.WithLabelValues(endpointName(r), "500").Inc()
+		return
+	}
+
+	// Encode an empty list rather than null when the unit has no entries
+	if entries == nil {
+		entries = []journal.Entry{}
+	}
+
+	setSecurityHeaders(w)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	if err := json.NewEncoder(w).Encode(LogsResponse{Service: service, Entries: entries}); err != nil {
+		requestLog(r).Error("error encoding logs response",
+			"error", err.Error())
+		return
+	}
+
+	metrics.RequestsTotal.WithLabelValues(endpointName(r), "200").Inc()
+}
+
+// -----------------------------------------------------------------------
+// Restart Handler
+// -----------------------------------------------------------------------
+
+// restartTimeout bounds how long a restart request waits for the systemd
+// job. It stays below the default request timeout (8s) so the 202 is sent.
+const restartTimeout = 7 * time.Second
+
+// RestartFunc restarts the named service and returns the job result.
+type RestartFunc func(ctx context.Context, service string) (string, error)
+
+// RestartResponse represents the JSON response for /admin/restart.
+type RestartResponse struct {
+	Service string `json:"service"`
+	Result  string `json:"result"`
+}
+
+// RestartHandler serves /admin/restart. Only POST is accepted. The service
+// is taken from the "service" query parameter, defaulting to the first of
+// services (the primary), and must be one of the monitored services so the
+// endpoint cannot restart arbitrary units.
+//
+// Returns 200 when the job finished with "done", 202 when it is still
+// running after restartTimeout, and 500 for any other job result.
+func RestartHandler(w http.ResponseWriter, r *http.Request, services []string, restart RestartFunc) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		metrics.RequestsTotal.WithLabelValues(endpointName(r), "405").Inc()
+		return
+	}
+
+	service := r.URL.Query().Get("service")
+	if service == "" && len(services) > 0 {
+		service = services[0]
+	}
+	if !slices.Contains(services, service) {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		metrics.RequestsTotal.WithLabelValues(endpointName(r), "404").Inc()
+		return
+	}
+
+	requestLog(r).Warn("service restart requested",
+		"service", service)
+
+	ctx, cancel := context.WithTimeout(r.Context(), restartTimeout)
+	defer cancel()
+
+	result, err := restart(ctx, service)
+	if err != nil {
+		requestLog(r).Error("service restart failed",
+			"service", service,
+			"error", err.Error())
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		metrics.RequestsTotal.WithLabelValues(endpointName(r), "500").Inc()
+		return
+	}
+
+	requestLog(r).Warn("service restart completed",
+		"service", service,
+		"result", result)
+
+	statusCode := http.StatusInternalServerError
+	switch result {
+	case "done":
+		statusCode = http.StatusOK
+	case checker.JobPending:
+		statusCode = http.StatusAccepted
+	}
+
+	setSecurityHeaders(w)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(RestartResponse{Service: service, Result: result}); err != nil {
+		requestLog(r).Error("error encoding restart response",
+			"error", err.Error())
+		return
+	}
+
+	metrics.RequestsTotal.WithLabelValues(endpointName(r), fmt.Sprintf("%d", statusCode)).Inc()
+}
+
+// -----------------------------------------------------------------------
+// Reset Stats Handler
+// -----------------------------------------------------------------------
+
+// ResetStatsResponse represents the JSON response for /admin/reset-stats.
+type ResetStatsResponse struct {
+	Service    string    `json:"service"`
+	StatsSince time.Time `json:"stats_since"`
+}
+
+// ResetStatsHandler serves /admin/reset-stats, which zeroes a service's
+// uptime and downtime counters and clears its transition history without
+// changing its status. Only POST is accepted. The service is selected like
+// /admin/restart, defaulting to primary.
+func ResetStatsHandler(w http.ResponseWriter, r *http.Request, primary string, caches map[string]cache.StatusStore) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		metrics.RequestsTotal.WithLabelValues(endpointName(r), "405").Inc()
+		return
+	}
+
+	service := r.URL.Query().Get("service")
+	if service == "" {
+		service = primary
+	}
+	serviceCache, ok := caches[service]
+	if !ok {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		metrics.RequestsTotal.WithLabelValues(endpointName(r), "404").Inc()
+		return
+	}
+
+	serviceCache.ResetStats()
+	statsSince := serviceCache.GetStatsSince()
+
+	requestLog(r).Warn("service stats reset",
+		"service", service,
+		"stats_since", statsSince)
+
+	setSecurityHeaders(w)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	if err := json.NewEncoder(w).Encode(ResetStatsResponse{Service: service, StatsSince: statsSince}); err != nil {
+		requestLog(r).Error("error encoding reset stats response",
+			"error", err.Error())
+		return
+	}
+
+	metrics.RequestsTotal.WithLabelValues(endpointName(r), "200").Inc()
+}
+
+// -----------------------------------------------------------------------
+// Rate Limit Stats Handler
+// -----------------------------------------------------------------------
+
+// RateLimitResponse represents the JSON response for /admin/ratelimit,
+// keyed by endpoint group (health, dashboard, metrics).
+type RateLimitResponse struct {
+	Limiters map[string]map[string]interface{} `json:"limiters"`
+}
+
+// RateLimitStatsHandler serves /admin/ratelimit, reporting each limiter's
+// tracked IP count and configured limits so operators can see whether
+// clients are being throttled.
+func RateLimitStatsHandler(w http.ResponseWriter, r *http.Request, limiters map[string]*ratelimit.Manager) {
+	if !validateMethod(w, r) {
+		metrics.RequestsTotal.WithLabelValues(endpointName(r), "405").Inc()
+		return
+	}
+
+	response := RateLimitResponse{Limiters: make(map[string]map[string]interface{}, len(limiters))}
+	for endpoint, limiter := range limiters {
+		response.Limiters[endpoint] = limiter.Stats()
+	}
+
+	setSecurityHeaders(w)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		requestLog(r).Error("error encoding rate limit response",
+			"error", err.Error())
+		return
+	}
+
+	metrics.RequestsTotal.WithLabelValues(endpointName(r), "200").Inc()
 }
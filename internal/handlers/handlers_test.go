@@ -14,13 +14,30 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/afreidah/health-check-service/internal/cache"
+	"github.com/afreidah/health-check-service/internal/checker"
+	"github.com/afreidah/health-check-service/internal/journal"
+	"github.com/afreidah/health-check-service/internal/metrics"
+	"github.com/afreidah/health-check-service/internal/ratelimit"
+	"github.com/afreidah/health-check-service/internal/version"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 )
 
 // -----------------------------------------------------------------------
@@ -36,7 +53,7 @@ func TestHealthHandlerReturnsOK(t *testing.T) {
 	req := httptest.NewRequest("GET", "/health", nil)
 	w := httptest.NewRecorder()
 
-	HealthHandler(w, req, c)
+	HealthHandler(w, req, c, "nginx")
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
@@ -53,7 +70,7 @@ func TestHealthHandlerReturnsServiceUnavailable(t *testing.T) {
 	req := httptest.NewRequest("GET", "/health", nil)
 	w := httptest.NewRecorder()
 
-	HealthHandler(w, req, c)
+	HealthHandler(w, req, c, "nginx")
 
 	if w.Code != http.StatusServiceUnavailable {
 		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
@@ -70,7 +87,7 @@ func TestHealthHandlerReturnsInternalServerError(t *testing.T) {
 	req := httptest.NewRequest("GET", "/health", nil)
 	w := httptest.NewRecorder()
 
-	HealthHandler(w, req, c)
+	HealthHandler(w, req, c, "nginx")
 
 	if w.Code != http.StatusInternalServerError {
 		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
@@ -105,7 +122,7 @@ func TestHealthHandlerMultipleStatusCodes(t *testing.T) {
 			req := httptest.NewRequest("GET", "/health", nil)
 			w := httptest.NewRecorder()
 
-			HealthHandler(w, req, c)
+			HealthHandler(w, req, c, "nginx")
 
 			if w.Code != tt.expectedStatus {
 				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
@@ -132,7 +149,7 @@ func TestHealthHandlerWithDifferentHTTPMethods(t *testing.T) {
 			req := httptest.NewRequest(method, "/health", nil)
 			w := httptest.NewRecorder()
 
-			HealthHandler(w, req, c)
+			HealthHandler(w, req, c, "nginx")
 
 			if w.Code != http.StatusOK {
 				t.Errorf("Method %s: expected status %d, got %d", method, http.StatusOK, w.Code)
@@ -154,7 +171,7 @@ func TestHealthHandlerRejectsUnsupportedMethods(t *testing.T) {
 			req := httptest.NewRequest(method, "/health", nil)
 			w := httptest.NewRecorder()
 
-			HealthHandler(w, req, c)
+			HealthHandler(w, req, c, "nginx")
 
 			if w.Code != http.StatusMethodNotAllowed {
 				t.Errorf("Method %s: expected status %d, got %d",
@@ -190,7 +207,7 @@ func TestHealthHandlerConcurrentRequests(t *testing.T) {
 			req := httptest.NewRequest("GET", "/health", nil)
 			w := httptest.NewRecorder()
 
-			HealthHandler(w, req, c)
+			HealthHandler(w, req, c, "nginx")
 
 			if w.Code != http.StatusOK {
 				t.Errorf("Concurrent request failed with status %d", w.Code)
@@ -223,7 +240,7 @@ func TestHealthHandlerStaleDataWarning(t *testing.T) {
 	req := httptest.NewRequest("GET", "/health", nil)
 	w := httptest.NewRecorder()
 
-	HealthHandler(w, req, c)
+	HealthHandler(w, req, c, "nginx")
 
 	warning := w.Header().Get("Warning")
 	if warning == "" {
@@ -246,6 +263,186 @@ func TestHealthHandlerStaleDataWarning(t *testing.T) {
 	}
 }
 
+// TestHealthHandlerConfiguredBodies verifies configured bodies are sent
+// for healthy and failing responses with the right status, that HEAD
+// stays empty, and that JSON and verbose reasons take precedence.
+func TestHealthHandlerConfiguredBodies(t *testing.T) {
+	ConfigureHealthBodies("OK", "DOWN")
+	t.Cleanup(func() { ConfigureHealthBodies("", "") })
+
+	healthy := cache.New()
+	healthy.UpdateStatus(http.StatusOK, "active")
+	failed := cache.New()
+	failed.UpdateStatus(http.StatusServiceUnavailable, "failed")
+
+	tests := []struct {
+		name     string
+		cache    *cache.ServiceCache
+		method   string
+		target   string
+		wantCode int
+		wantBody string
+	}{
+		{"healthy", healthy, "GET", "/health", http.StatusOK, "OK"},
+		{"failed", failed, "GET", "/health", http.StatusServiceUnavailable, "DOWN"},
+		{"head", healthy, "HEAD", "/health", http.StatusOK, ""},
+		{"verbose", failed, "GET", "/health?verbose=1", http.StatusServiceUnavailable, "service nginx is failed\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			HealthHandler(w, req, tt.cache, "nginx")
+
+			if w.Code != tt.wantCode {
+				t.Errorf("Expected status %d, got %d", tt.wantCode, w.Code)
+			}
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("Expected body %q, got %q", tt.wantBody, got)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/health", nil)
+	req.Header.Set("Accept", "application/json")
+	HealthHandler(w, req, healthy, "nginx")
+	if !strings.HasPrefix(w.Body.String(), "{") {
+		t.Errorf("Expected JSON to take precedence, got %q", w.Body.String())
+	}
+}
+
+// TestHealthHandlerExemplars verifies request durations carry the request
+// ID as an exemplar when enabled, and that an ID too long for an exemplar
+// is observed without one instead of panicking.
+func TestHealthHandlerExemplars(t *testing.T) {
+	ConfigureExemplars(true)
+	t.Cleanup(func() { ConfigureExemplars(false) })
+
+	c := cache.New()
+	c.UpdateStatus(http.StatusOK, "active")
+
+	exemplarIDs := func(endpoint string) []string {
+		var m dto.Metric
+		if err := metrics.RequestDuration.WithLabelValues(endpoint).(prometheus.Metric).Write(&m); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		var ids []string
+		for _, bucket := range m.GetHistogram().GetBucket() {
+			for _, label := range bucket.GetExemplar().GetLabel() {
+				ids = append(ids, label.GetValue())
+			}
+		}
+		return ids
+	}
+
+	req := WithEndpoint(httptest.NewRequest("GET", "/health", nil), "health_exemplar")
+	req.Header.Set("X-Request-ID", "req-123")
+	HealthHandler(httptest.NewRecorder(), req, c, "nginx")
+
+	if ids := exemplarIDs("health_exemplar"); !slices.Contains(ids, "req-123") {
+		t.Errorf("Expected an exemplar for req-123, got %v", ids)
+	}
+
+	req = WithEndpoint(httptest.NewRequest("GET", "/health", nil), "health_exemplar_long")
+	req.Header.Set("X-Request-ID", strings.Repeat("x", prometheus.ExemplarMaxRunes))
+	HealthHandler(httptest.NewRecorder(), req, c, "nginx")
+
+	if ids := exemplarIDs("health_exemplar_long"); len(ids) != 0 {
+		t.Errorf("Expected no exemplar for an oversized ID, got %d", len(ids))
+	}
+}
+
+// TestHealthHandlerStaleStatusCode verifies a configured stale status code
+// replaces the cached status only while the data is stale, alongside the
+// Warning header.
+func TestHealthHandlerStaleStatusCode(t *testing.T) {
+	ConfigureStaleStatusCode(http.StatusServiceUnavailable)
+	t.Cleanup(func() { ConfigureStaleStatusCode(0) })
+
+	c := cache.New()
+	c.UpdateStatus(http.StatusOK, "active")
+
+	w := httptest.NewRecorder()
+	HealthHandler(w, httptest.NewRequest("GET", "/health", nil), c, "nginx")
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected fresh data to keep status 200, got %d", w.Code)
+	}
+
+	c.SetLastChecked(time.Now().Add(-35 * time.Second))
+	w = httptest.NewRecorder()
+	HealthHandler(w, httptest.NewRequest("GET", "/health", nil), c, "nginx")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected stale data to return 503, got %d", w.Code)
+	}
+	if w.Header().Get("Warning") == "" {
+		t.Error("Expected Warning header alongside the stale status code")
+	}
+}
+
+// TestHealthHandlerStalenessLabel verifies stale data is recorded under
+// the service's own label, the one alerts select on, and never under an
+// empty one.
+func TestHealthHandlerStalenessLabel(t *testing.T) {
+	c := cache.New()
+	c.UpdateStatus(http.StatusOK, "active")
+	c.SetLastChecked(time.Now().Add(-35 * time.Second))
+
+	t.Cleanup(func() { metrics.CacheStaleness.Reset() })
+	metrics.CacheStaleness.Reset()
+
+	HealthHandler(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil), c, "nginx")
+
+	if got := testutil.ToFloat64(metrics.CacheStaleness.WithLabelValues("nginx")); got < 35 {
+		t.Errorf("Expected staleness of at least 35s for nginx, got %g", got)
+	}
+	if n := testutil.CollectAndCount(metrics.CacheStaleness); n != 1 {
+		t.Errorf("Expected only the nginx series, got %d series", n)
+	}
+}
+
+// TestAggregateHealthHandlerStalenessPerService verifies each stale
+// service's staleness is recorded under its own label, so services do not
+// overwrite one another's series.
+func TestAggregateHealthHandlerStalenessPerService(t *testing.T) {
+	nginx := cache.New()
+	nginx.UpdateStatus(http.StatusOK, "active")
+	nginx.SetLastChecked(time.Now().Add(-40 * time.Second))
+
+	redis := cache.New()
+	redis.UpdateStatus(http.StatusOK, "active")
+	redis.SetLastChecked(time.Now().Add(-90 * time.Second))
+
+	fresh := cache.New()
+	fresh.UpdateStatus(http.StatusOK, "active")
+
+	t.Cleanup(func() { metrics.CacheStaleness.Reset() })
+	metrics.CacheStaleness.Reset()
+
+	caches := map[string]cache.StatusStore{"nginx": nginx, "redis": redis, "postgresql": fresh}
+	AggregateHealthHandler(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil), caches)
+
+	tests := []struct {
+		service  string
+		min, max float64
+	}{
+		{"nginx", 40, 45},
+		{"redis", 90, 95},
+	}
+	for _, tt := range tests {
+		got := testutil.ToFloat64(metrics.CacheStaleness.WithLabelValues(tt.service))
+		if got < tt.min || got > tt.max {
+			t.Errorf("Expected %s staleness around %gs, got %g", tt.service, tt.min, got)
+		}
+	}
+
+	// Only the stale services and no empty label
+	if n := testutil.CollectAndCount(metrics.CacheStaleness); n != 2 {
+		t.Errorf("Expected 2 staleness series, got %d", n)
+	}
+}
+
 // TestHealthHandlerFreshDataNoWarning verifies that fresh data does NOT
 // trigger a Warning header.
 func TestHealthHandlerFreshDataNoWarning(t *testing.T) {
@@ -256,7 +453,7 @@ func TestHealthHandlerFreshDataNoWarning(t *testing.T) {
 	req := httptest.NewRequest("GET", "/health", nil)
 	w := httptest.NewRecorder()
 
-	HealthHandler(w, req, c)
+	HealthHandler(w, req, c, "nginx")
 
 	// Should NOT have Warning header
 	warning := w.Header().Get("Warning")
@@ -269,3 +466,1356 @@ func TestHealthHandlerFreshDataNoWarning(t *testing.T) {
 		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
 	}
 }
+
+// errorStore is a StatusStore whose status is fixed, standing in for a
+// backend other than the in-memory cache.
+type errorStore struct {
+	cache.StatusStore
+}
+
+func (errorStore) GetStatus() (int, string) {
+	return http.StatusInternalServerError, "backend_unreachable"
+}
+
+// TestHealthHandlerAcceptsStatusStore verifies handlers work against any
+// StatusStore, not just the in-memory cache.
+func TestHealthHandlerAcceptsStatusStore(t *testing.T) {
+	c := cache.New()
+	c.UpdateStatus(http.StatusOK, "active")
+	store := errorStore{StatusStore: c}
+
+	w := httptest.NewRecorder()
+	HealthHandler(w, httptest.NewRequest("GET", "/health", nil), store, "nginx")
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
+
+// TestHealthHandlerErrorState verifies a checker that lost systemd after
+// a healthy check returns 500 with fresh data, not its last good status.
+func TestHealthHandlerErrorState(t *testing.T) {
+	c := cache.New()
+	c.UpdateStatus(http.StatusOK, "active")
+	c.UpdateStatus(http.StatusInternalServerError, "error")
+	if !c.IsError() {
+		t.Fatal("cache should be in the error state")
+	}
+
+	w := httptest.NewRecorder()
+	HealthHandler(w, httptest.NewRequest("GET", "/health", nil), c, "nginx")
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d for the error state, got %d", http.StatusInternalServerError, w.Code)
+	}
+	if warning := w.Header().Get("Warning"); warning != "" {
+		t.Errorf("Expected no Warning header for fresh data, got %q", warning)
+	}
+}
+
+// TestHealthHandlerUninitialized verifies a service that has not been
+// checked yet returns 503 with the initializing Warning header rather than
+// a stale data warning.
+func TestHealthHandlerUninitialized(t *testing.T) {
+	ConfigureStaleStatusCode(http.StatusInternalServerError)
+	t.Cleanup(func() { ConfigureStaleStatusCode(0) })
+
+	w := httptest.NewRecorder()
+	HealthHandler(w, httptest.NewRequest("GET", "/health", nil), cache.New(), "nginx")
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d while initializing, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	if warning := w.Header().Get("Warning"); warning != initializingWarning {
+		t.Errorf("Expected Warning %q, got %q", initializingWarning, warning)
+	}
+}
+
+// -----------------------------------------------------------------------
+// Per-Service and Aggregate Tests
+// -----------------------------------------------------------------------
+
+// TestServiceHealthHandler verifies /health/{service} returns the named
+// service's status and 404 for services that are not monitored. Each load
+// balancer probe depends on getting only its own service's status.
+func TestServiceHealthHandler(t *testing.T) {
+	nginx := cache.New()
+	nginx.UpdateStatus(http.StatusOK, "active")
+	redis := cache.New()
+	redis.UpdateStatus(http.StatusServiceUnavailable, "failed")
+
+	caches := map[string]cache.StatusStore{"nginx": nginx, "redis": redis}
+
+	tests := []struct {
+		path           string
+		expectedStatus int
+	}{
+		{"/health/nginx", http.StatusOK},
+		{"/health/redis", http.StatusServiceUnavailable},
+		{"/health/postgres", http.StatusNotFound},
+		{"/health/", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+
+			ServiceHealthHandler(w, req, caches)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+// TestAggregateHealthHandler verifies /health returns 200 only when every
+// monitored service is healthy. A single down service must fail the aggregate
+// so load balancers stop routing to a partially broken node.
+func TestAggregateHealthHandler(t *testing.T) {
+	nginx := cache.New()
+	nginx.UpdateStatus(http.StatusOK, "active")
+	redis := cache.New()
+	redis.UpdateStatus(http.StatusOK, "active")
+
+	caches := map[string]cache.StatusStore{"nginx": nginx, "redis": redis}
+
+	req := httptest.NewRequest("GET", "/health", nil)
+	w := httptest.NewRecorder()
+	AggregateHealthHandler(w, req, caches)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("All healthy: expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	redis.UpdateStatus(http.StatusServiceUnavailable, "failed")
+
+	w = httptest.NewRecorder()
+	AggregateHealthHandler(w, req, caches)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("One failed: expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
+
+// TestAggregateHealthHandlerLifecycleState verifies /health applies each
+// cache's lifecycle state: a checker in the error state fails the aggregate
+// with 500, and a service not checked yet with 503 and the initializing
+// Warning header.
+func TestAggregateHealthHandlerLifecycleState(t *testing.T) {
+	nginx := cache.New()
+	nginx.UpdateStatus(http.StatusOK, "active")
+	redis := cache.New()
+	redis.UpdateStatus(http.StatusOK, "active")
+	redis.UpdateStatus(http.StatusInternalServerError, "error")
+
+	caches := map[string]cache.StatusStore{"nginx": nginx, "redis": redis}
+
+	w := httptest.NewRecorder()
+	AggregateHealthHandler(w, httptest.NewRequest("GET", "/health", nil), caches)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Error state: expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+	if _, state, _ := aggregateStatus(caches); state != "redis:error" {
+		t.Errorf("Expected the erroring service as state, got %q", state)
+	}
+
+	caches["redis"] = cache.New()
+
+	w = httptest.NewRecorder()
+	AggregateHealthHandler(w, httptest.NewRequest("GET", "/health", nil), caches)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Uninitialized: expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	if warning := w.Header().Get("Warning"); warning != initializingWarning {
+		t.Errorf("Expected Warning %q, got %q", initializingWarning, warning)
+	}
+}
+
+// TestAggregateHealthHandlerFoldsDependencies verifies an active service
+// with a failed dependency makes /health fail and names the dependency.
+func TestAggregateHealthHandlerFoldsDependencies(t *testing.T) {
+	app := cache.New()
+	app.UpdateStatus(http.StatusOK, "active")
+	app.SetDependencies([]cache.Dependency{
+		{Unit: "data.mount", State: "failed", Healthy: false},
+		{Unit: "network-online.target", State: "active", Healthy: true},
+	})
+
+	caches := map[string]cache.StatusStore{"app": app}
+
+	w := httptest.NewRecorder()
+	AggregateHealthHandler(w, httptest.NewRequest("GET", "/health", nil), caches)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	if _, state, _ := aggregateStatus(caches); state != "data.mount:failed" {
+		t.Errorf("Expected failed dependency as state, got %q", state)
+	}
+}
+
+// TestAggregateHealthHandlerPolicy verifies the aggregate policy decides
+// how many services may be down before /health fails, and that a met
+// policy with services down is reported as degraded.
+func TestAggregateHealthHandlerPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     cache.AggregatePolicy
+		failed     int
+		wantStatus int
+		wantState  string
+	}{
+		{"all healthy", cache.AggregatePolicy{}, 0, http.StatusOK, "active"},
+		{"all with one down", cache.AggregatePolicy{}, 1, http.StatusServiceUnavailable, "redis-1:failed"},
+		{"any with two down", cache.AggregatePolicy{Mode: cache.PolicyAny}, 2, http.StatusOK, "degraded"},
+		{"any with all down", cache.AggregatePolicy{Mode: cache.PolicyAny}, 3, http.StatusServiceUnavailable, "redis-1:failed"},
+		{"quorum met", cache.AggregatePolicy{Mode: cache.PolicyQuorum, Quorum: 2}, 1, http.StatusOK, "degraded"},
+		{"quorum lost", cache.AggregatePolicy{Mode: cache.PolicyQuorum, Quorum: 2}, 2, http.StatusServiceUnavailable, "redis-1:failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ConfigureAggregatePolicy(tt.policy)
+			t.Cleanup(func() { ConfigureAggregatePolicy(cache.AggregatePolicy{}) })
+
+			caches := make(map[string]cache.StatusStore)
+			for i, name := range []string{"redis-1", "redis-2", "redis-3"} {
+				c := cache.New()
+				if i < tt.failed {
+					c.UpdateStatus(http.StatusServiceUnavailable, "failed")
+				} else {
+					c.UpdateStatus(http.StatusOK, "active")
+				}
+				caches[name] = c
+			}
+
+			w := httptest.NewRecorder()
+			AggregateHealthHandler(w, httptest.NewRequest("GET", "/health", nil), caches)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if _, state, _ := aggregateStatus(caches); state != tt.wantState {
+				t.Errorf("Expected state %q, got %q", tt.wantState, state)
+			}
+		})
+	}
+}
+
+// TestStatusAPIHandlerReportsAggregate verifies /api/status carries the
+// policy and the per-service breakdown behind it.
+func TestStatusAPIHandlerReportsAggregate(t *testing.T) {
+	ConfigureAggregatePolicy(cache.AggregatePolicy{Mode: cache.PolicyQuorum, Quorum: 1})
+	t.Cleanup(func() { ConfigureAggregatePolicy(cache.AggregatePolicy{}) })
+
+	nginx := cache.New()
+	nginx.UpdateStatus(http.StatusOK, "active")
+	redis := cache.New()
+	redis.UpdateStatus(http.StatusServiceUnavailable, "failed")
+	caches := map[string]cache.StatusStore{"nginx": nginx, "redis": redis}
+
+	w := httptest.NewRecorder()
+	StatusAPIHandler(w, httptest.NewRequest("GET", "/api/status", nil), nginx, "nginx", caches)
+
+	var resp StatusResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	agg := resp.Aggregate
+	if agg == nil {
+		t.Fatal("Expected aggregate in /api/status")
+	}
+	if agg.Policy != "quorum:1" || !agg.Healthy || agg.HealthyCount != 1 {
+		t.Errorf("Unexpected aggregate: %+v", agg)
+	}
+	want := []ServiceHealth{
+		{Service: "nginx", Healthy: true, StatusCode: http.StatusOK, State: "active"},
+		{Service: "redis", Healthy: false, StatusCode: http.StatusServiceUnavailable, State: "failed"},
+	}
+	if !reflect.DeepEqual(agg.Services, want) {
+		t.Errorf("Expected services %+v, got %+v", want, agg.Services)
+	}
+}
+
+// -----------------------------------------------------------------------
+// Request Metric Tests
+// -----------------------------------------------------------------------
+
+// TestRequestsTotalLabelsEndpoint verifies requests are counted under the
+// endpoint set by WithEndpoint, so the same handler behind /health and
+// /readyz is reported separately.
+func TestRequestsTotalLabelsEndpoint(t *testing.T) {
+	c := cache.New()
+	c.UpdateStatus(http.StatusOK, "active")
+	caches := map[string]cache.StatusStore{"nginx": c}
+
+	before := testutil.ToFloat64(metrics.RequestsTotal.WithLabelValues("readyz", "200"))
+
+	req := WithEndpoint(httptest.NewRequest("GET", "/readyz", nil), "readyz")
+	AggregateHealthHandler(httptest.NewRecorder(), req, caches)
+
+	after := testutil.ToFloat64(metrics.RequestsTotal.WithLabelValues("readyz", "200"))
+	if after != before+1 {
+		t.Errorf("Expected readyz/200 to increase by 1, got %v -> %v", before, after)
+	}
+
+	if got := endpointName(httptest.NewRequest("GET", "/health", nil)); got != "unknown" {
+		t.Errorf("Expected unknown endpoint without WithEndpoint, got %q", got)
+	}
+}
+
+// -----------------------------------------------------------------------
+// Access Log Tests
+// -----------------------------------------------------------------------
+
+// TestAccessLogLevel verifies disabling the access log demotes it to Debug
+// and that sampling keeps one in every N requests.
+func TestAccessLogLevel(t *testing.T) {
+	t.Cleanup(func() { ConfigureAccessLog(true, 1) })
+
+	ConfigureAccessLog(true, 1)
+	if level, ok := accessLogLevel(); !ok || level != slog.LevelInfo {
+		t.Errorf("Default: expected Info, got %v (logged %v)", level, ok)
+	}
+
+	ConfigureAccessLog(false, 1)
+	if level, ok := accessLogLevel(); !ok || level != slog.LevelDebug {
+		t.Errorf("Disabled: expected Debug, got %v (logged %v)", level, ok)
+	}
+
+	ConfigureAccessLog(true, 4)
+	logged := 0
+	for range 20 {
+		if _, ok := accessLogLevel(); ok {
+			logged++
+		}
+	}
+	if logged != 5 {
+		t.Errorf("Sample 4: expected 5 of 20 requests logged, got %d", logged)
+	}
+}
+
+// TestWithRequestLogger verifies the request ID is echoed in X-Request-ID,
+// stays the same for the whole request, and tags the request's log lines
+// along with the client IP and endpoint.
+func TestWithRequestLogger(t *testing.T) {
+	var buf strings.Builder
+	saved := logh
+	logh = slog.New(slog.NewJSONHandler(&buf, nil))
+	t.Cleanup(func() { logh = saved })
+	ConfigureAccessLog(true, 1)
+
+	c := cache.New()
+	c.UpdateStatus(http.StatusOK, "active")
+
+	req := WithEndpoint(httptest.NewRequest("GET", "/health", nil), "health")
+	req.Header.Set("X-Request-ID", "req-abc")
+	w := httptest.NewRecorder()
+	req = WithRequestLogger(w, req)
+	HealthHandler(w, req, c, "nginx")
+
+	if got := w.Header().Get("X-Request-ID"); got != "req-abc" {
+		t.Errorf("Expected X-Request-ID req-abc echoed, got %q", got)
+	}
+
+	var line map[string]any
+	if err := json.Unmarshal([]byte(strings.SplitN(buf.String(), "\n", 2)[0]), &line); err != nil {
+		t.Fatalf("Expected a JSON log line, got %q: %v", buf.String(), err)
+	}
+	if line["request_id"] != "req-abc" || line["endpoint"] != "health" || line["client_ip"] != "192.0.2.1" {
+		t.Errorf("Expected request_id, endpoint and client_ip on the log line, got %v", line)
+	}
+
+	// A generated ID is reused for the rest of the request
+	req = WithEndpoint(httptest.NewRequest("GET", "/health", nil), "health")
+	w = httptest.NewRecorder()
+	req = WithRequestLogger(w, req)
+	if id := w.Header().Get("X-Request-ID"); id == "" || requestID(req) != id {
+		t.Errorf("Expected generated ID %q to be reused, got %q", id, requestID(req))
+	}
+}
+
+// TestRequestIDHeaders verifies configured headers are checked in order,
+// a traceparent contributes its trace ID, and a malformed traceparent or no
+// matching header falls back to a random ID.
+func TestRequestIDHeaders(t *testing.T) {
+	t.Cleanup(func() { ConfigureRequestIDHeaders([]string{"X-Request-ID"}) })
+	ConfigureRequestIDHeaders([]string{"X-Amzn-Trace-Id", " traceparent", "X-Request-ID"})
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+	}{
+		{"first header wins", map[string]string{"X-Amzn-Trace-Id": "Root=1-abc", "X-Request-ID": "req-1"}, "Root=1-abc"},
+		{"traceparent trace ID", map[string]string{"traceparent": traceparent, "X-Request-ID": "req-1"}, "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"malformed traceparent skipped", map[string]string{"traceparent": "00-xyz-01", "X-Request-ID": "req-1"}, "req-1"},
+		{"zero trace ID skipped", map[string]string{"traceparent": "00-" + strings.Repeat("0", 32) + "-00f067aa0ba902b7-01"}, ""},
+		{"uppercase trace ID skipped", map[string]string{"traceparent": strings.ToUpper(traceparent)}, ""},
+		{"no header", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/health", nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+
+			got := newRequestID(req)
+			if tt.want == "" {
+				if len(got) != 24 {
+					t.Errorf("Expected a generated 24-character ID, got %q", got)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// -----------------------------------------------------------------------
+// JSON Health Tests
+// -----------------------------------------------------------------------
+
+// TestHealthHandlerJSON verifies Accept: application/json returns the
+// status payload while keeping the health status code, and that other
+// Accept values and HEAD requests keep the empty body.
+func TestHealthHandlerJSON(t *testing.T) {
+	c := cache.New()
+	c.UpdateStatus(http.StatusServiceUnavailable, "failed")
+
+	tests := []struct {
+		name     string
+		method   string
+		accept   string
+		wantJSON bool
+	}{
+		{"json", "GET", "application/json", true},
+		{"json among others", "GET", "text/html, application/json;q=0.9", true},
+		{"json refused", "GET", "application/json;q=0", false},
+		{"plain text", "GET", "text/plain", false},
+		{"wildcard", "GET", "*/*", false},
+		{"no accept", "GET", "", false},
+		{"head", "HEAD", "application/json", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/health", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			HealthHandler(w, req, c, "nginx")
+
+			if w.Code != http.StatusServiceUnavailable {
+				t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+			}
+			if !tt.wantJSON {
+				if w.Body.Len() != 0 {
+					t.Errorf("Expected empty body, got %q", w.Body.String())
+				}
+				return
+			}
+
+			if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+				t.Errorf("Expected JSON content type, got %q", ct)
+			}
+			var resp StatusResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if resp.State != "failed" || resp.StatusCode != http.StatusServiceUnavailable || resp.Healthy {
+				t.Errorf("Unexpected response: %+v", resp)
+			}
+		})
+	}
+}
+
+// TestServiceHealthHandlerJSONNamesService verifies /health/{service}
+// includes the service name in its JSON body.
+func TestServiceHealthHandlerJSONNamesService(t *testing.T) {
+	nginx := cache.New()
+	nginx.UpdateStatus(http.StatusOK, "active")
+
+	req := httptest.NewRequest("GET", "/health/nginx", nil)
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+
+	ServiceHealthHandler(w, req, map[string]cache.StatusStore{"nginx": nginx})
+
+	var resp StatusResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Service != "nginx" || resp.Status != "healthy" {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+}
+
+// TestAggregateHealthHandlerJSON verifies the aggregate JSON body lists
+// every service in name order alongside the folded status.
+func TestAggregateHealthHandlerJSON(t *testing.T) {
+	nginx := cache.New()
+	nginx.UpdateStatus(http.StatusOK, "active")
+	redis := cache.New()
+	redis.UpdateStatus(http.StatusServiceUnavailable, "failed")
+
+	req := httptest.NewRequest("GET", "/health", nil)
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+
+	AggregateHealthHandler(w, req, map[string]cache.StatusStore{"redis": redis, "nginx": nginx})
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	var resp AggregateHealthResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Healthy || resp.State != "redis:failed" {
+		t.Errorf("Unexpected aggregate: %+v", resp)
+	}
+	if len(resp.Services) != 2 || resp.Services[0].Service != "nginx" || resp.Services[1].Service != "redis" {
+		t.Errorf("Expected services in name order, got %+v", resp.Services)
+	}
+}
+
+// -----------------------------------------------------------------------
+// Verbose Health Tests
+// -----------------------------------------------------------------------
+
+// TestHealthHandlerVerboseReason verifies ?verbose=1 explains a failing
+// status in plain text and leaves healthy, non-verbose and HEAD responses
+// without a body.
+func TestHealthHandlerVerboseReason(t *testing.T) {
+	failed := cache.New()
+	failed.UpdateStatus(http.StatusServiceUnavailable, "failed")
+
+	stale := cache.New()
+	stale.UpdateStatus(http.StatusServiceUnavailable, "inactive")
+	stale.SetLastChecked(time.Now().Add(-42 * time.Second))
+
+	healthy := cache.New()
+	healthy.UpdateStatus(http.StatusOK, "active")
+
+	neverStarted := cache.New()
+	neverStarted.UpdateStatus(http.StatusServiceUnavailable, "inactive")
+	neverStarted.SetActivity("dead", time.Time{})
+
+	stopped := cache.New()
+	stopped.UpdateStatus(http.StatusServiceUnavailable, "inactive")
+	stopped.SetActivity("dead", time.Date(2025, 10, 15, 12, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name   string
+		cache  *cache.ServiceCache
+		method string
+		target string
+		want   string
+	}{
+		{"failed", failed, "GET", "/health/nginx?verbose=1", "service nginx is failed\n"},
+		{"stale", stale, "GET", "/health/nginx?verbose=1", "service nginx is inactive; cache stale (age 42s)\n"},
+		{"uninitialized", cache.New(), "GET", "/health/nginx?verbose=1", "service nginx has not been checked yet\n"},
+		{"never started", neverStarted, "GET", "/health/nginx?verbose=1", "service nginx is inactive (never started)\n"},
+		{"stopped", stopped, "GET", "/health/nginx?verbose=1", "service nginx is inactive (stopped, last active 2025-10-15T12:00:00Z)\n"},
+		{"healthy", healthy, "GET", "/health/nginx?verbose=1", ""},
+		{"not verbose", failed, "GET", "/health/nginx", ""},
+		{"head", failed, "HEAD", "/health/nginx?verbose=1", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			caches := map[string]cache.StatusStore{"nginx": tt.cache}
+
+			ServiceHealthHandler(w, httptest.NewRequest(tt.method, tt.target, nil), caches)
+
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("Expected body %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestAggregateHealthHandlerVerboseReason verifies the aggregate reason
+// lists each unhealthy service on its own line.
+func TestAggregateHealthHandlerVerboseReason(t *testing.T) {
+	nginx := cache.New()
+	nginx.UpdateStatus(http.StatusOK, "active")
+	redis := cache.New()
+	redis.UpdateStatus(http.StatusServiceUnavailable, "failed")
+	app := cache.New()
+	app.UpdateStatus(http.StatusOK, "active")
+	app.SetDependencies([]cache.Dependency{{Unit: "data.mount", State: "failed"}})
+
+	caches := map[string]cache.StatusStore{"nginx": nginx, "redis": redis, "app": app}
+
+	w := httptest.NewRecorder()
+	AggregateHealthHandler(w, httptest.NewRequest("GET", "/readyz?verbose=1", nil), caches)
+
+	want := "service app dependency data.mount is failed\nservice redis is failed\n"
+	if got := w.Body.String(); got != want {
+		t.Errorf("Expected body %q, got %q", want, got)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Expected plain text content type, got %q", ct)
+	}
+}
+
+// -----------------------------------------------------------------------
+// Liveness Tests
+// -----------------------------------------------------------------------
+
+// TestLivenessHandlerIgnoresServiceState verifies /livez returns 200 while
+// the checker is responsive, even when the monitored service is down. A
+// failing liveness probe restarts the pod, which cannot fix the service.
+func TestLivenessHandlerIgnoresServiceState(t *testing.T) {
+	checkerHealth := checker.NewCheckerHealth()
+
+	req := httptest.NewRequest("GET", "/livez", nil)
+	w := httptest.NewRecorder()
+
+	LivenessHandler(w, req, checkerHealth, 20*time.Second)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+// TestLivenessHandlerDetectsStuckChecker verifies /livez returns 503 once
+// the checker has not reported within maxAge.
+func TestLivenessHandlerDetectsStuckChecker(t *testing.T) {
+	checkerHealth := checker.NewCheckerHealth()
+	time.Sleep(5 * time.Millisecond)
+
+	req := httptest.NewRequest("GET", "/livez", nil)
+	w := httptest.NewRecorder()
+
+	LivenessHandler(w, req, checkerHealth, time.Millisecond)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
+
+// -----------------------------------------------------------------------
+// Status API Tests
+// -----------------------------------------------------------------------
+
+// TestStatusAPIHandlerReportsVersionAndUptime verifies /api/status includes
+// the build version and time since process start.
+func TestStatusAPIHandlerReportsVersionAndUptime(t *testing.T) {
+	prev := ProcessStart
+	ProcessStart = time.Now().Add(-90 * time.Second)
+	t.Cleanup(func() { ProcessStart = prev })
+
+	c := cache.New()
+	c.UpdateStatus(http.StatusOK, "active")
+
+	req := httptest.NewRequest("GET", "/api/status", nil)
+	w := httptest.NewRecorder()
+
+	StatusAPIHandler(w, req, c, "nginx", nil)
+
+	var resp StatusResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if resp.Version != version.Version {
+		t.Errorf("Expected version %q, got %q", version.Version, resp.Version)
+	}
+	if resp.ProcessUptimeS < 90 || resp.ProcessUptimeS > 95 {
+		t.Errorf("Expected process uptime around 90s, got %d", resp.ProcessUptimeS)
+	}
+}
+
+// TestStatusAPIHandlerReportsRestarts verifies the NRestarts count is
+// exposed so a crash-looping but currently active service is visible.
+func TestStatusAPIHandlerReportsRestarts(t *testing.T) {
+	c := cache.New()
+	c.UpdateStatus(http.StatusOK, "active")
+	c.SetRestarts(40)
+
+	w := httptest.NewRecorder()
+	StatusAPIHandler(w, httptest.NewRequest("GET", "/api/status", nil), c, "nginx", nil)
+
+	var resp StatusResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Restarts != 40 || !resp.Healthy {
+		t.Errorf("Expected healthy with 40 restarts, got healthy=%v restarts=%d", resp.Healthy, resp.Restarts)
+	}
+}
+
+// TestStatusAPIHandlerReportsPollInterval verifies /api/status tells the
+// dashboard how often to refresh, and omits it when unconfigured.
+func TestStatusAPIHandlerReportsPollInterval(t *testing.T) {
+	c := cache.New()
+	c.UpdateStatus(http.StatusOK, "active")
+
+	w := httptest.NewRecorder()
+	StatusAPIHandler(w, httptest.NewRequest("GET", "/api/status", nil), c, "nginx", nil)
+	if strings.Contains(w.Body.String(), "poll_interval_s") {
+		t.Errorf("Expected no poll_interval_s when unconfigured, got %s", w.Body.String())
+	}
+
+	ConfigurePollInterval(60)
+	t.Cleanup(func() { ConfigurePollInterval(0) })
+
+	w = httptest.NewRecorder()
+	StatusAPIHandler(w, httptest.NewRequest("GET", "/api/status", nil), c, "nginx", nil)
+
+	var resp StatusResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.PollIntervalS != 60 {
+		t.Errorf("Expected poll_interval_s 60, got %d", resp.PollIntervalS)
+	}
+}
+
+// TestStatusAPIHandlerTextFormat verifies ?format=text and an Accept
+// header preferring text/plain select the text summary, and that JSON
+// stays the default.
+func TestStatusAPIHandlerTextFormat(t *testing.T) {
+	c := cache.New()
+	c.UpdateStatus(http.StatusOK, "active")
+
+	tests := []struct {
+		name     string
+		target   string
+		accept   string
+		wantText bool
+	}{
+		{"default", "/api/status", "", false},
+		{"curl", "/api/status", "*/*", false},
+		{"format=text", "/api/status?format=text", "", true},
+		{"accept text", "/api/status", "text/plain", true},
+		{"accept both", "/api/status", "application/json, text/plain", false},
+		{"format=json wins", "/api/status?format=json", "text/plain", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			StatusAPIHandler(w, req, c, "nginx", nil)
+
+			contentType := w.Header().Get("Content-Type")
+			if got := strings.HasPrefix(contentType, "text/plain"); got != tt.wantText {
+				t.Fatalf("Content-Type = %q, wantText %v", contentType, tt.wantText)
+			}
+			if !tt.wantText {
+				return
+			}
+			body := w.Body.String()
+			if !strings.HasPrefix(body, "nginx: healthy (active), checked 0s ago") || !strings.Contains(body, "uptime") {
+				t.Errorf("Unexpected text body %q", body)
+			}
+		})
+	}
+}
+
+// TestStatusText verifies the text summary mentions failed dependencies
+// and lists each service of the aggregate.
+func TestStatusText(t *testing.T) {
+	got := statusText(StatusResponse{
+		Service:      "nginx",
+		Status:       "unhealthy",
+		State:        "active",
+		LastChecked:  time.Now(),
+		StalenessS:   3,
+		Uptime:       99.25,
+		Dependencies: []cache.Dependency{{Unit: "data.mount", State: "failed"}},
+		Aggregate: &AggregateStatus{
+			Policy:       "all",
+			HealthyCount: 1,
+			Services: []ServiceHealth{
+				{Service: "nginx", State: "active"},
+				{Service: "redis", Healthy: true, State: "active"},
+			},
+		},
+	})
+
+	want := "nginx: unhealthy (active), checked 3s ago, uptime 99.2%, dependency data.mount failed\n" +
+		"aggregate: unhealthy (1/2 healthy, policy all)\n" +
+		"  nginx: unhealthy (active)\n" +
+		"  redis: healthy (active)\n"
+	if got != want {
+		t.Errorf("statusText() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// TestStatusAPIHandlerReportsLastActive verifies last_active is null for a
+// unit that has never run and set for one that ran and stopped, so a
+// freshly provisioned host can be told from a crashed one, and that
+// service_active_since is only set while the unit is active.
+func TestStatusAPIHandlerReportsLastActive(t *testing.T) {
+	lastActive := time.Date(2025, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		code        int
+		state       string
+		subState    string
+		lastActive  time.Time
+		wantLast    string
+		wantRunning string
+	}{
+		{"never started", http.StatusServiceUnavailable, "inactive", "dead", time.Time{},
+			`"last_active":null`, `"service_active_since":null`},
+		{"ran and stopped", http.StatusServiceUnavailable, "inactive", "dead", lastActive,
+			`"last_active":"2025-10-15T12:00:00Z"`, `"service_active_since":null`},
+		{"running", http.StatusOK, "active", "running", lastActive,
+			`"last_active":"2025-10-15T12:00:00Z"`, `"service_active_since":"2025-10-15T12:00:00Z"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cache.New()
+			c.UpdateStatus(tt.code, tt.state)
+			c.SetActivity(tt.subState, tt.lastActive)
+
+			w := httptest.NewRecorder()
+			StatusAPIHandler(w, httptest.NewRequest("GET", "/api/status", nil), c, "backup", nil)
+
+			body := w.Body.String()
+			for _, want := range []string{tt.wantLast, tt.wantRunning, `"sub_state":"` + tt.subState + `"`} {
+				if !strings.Contains(body, want) {
+					t.Errorf("Expected %s in %s", want, body)
+				}
+			}
+		})
+	}
+}
+
+// TestStatusAPIHandlerReportsFlapping verifies a flapping service is
+// classified as such even while it is momentarily active.
+func TestStatusAPIHandlerReportsFlapping(t *testing.T) {
+	c := cache.New()
+	c.UpdateStatus(http.StatusOK, "active")
+	c.SetFlapping(true)
+
+	w := httptest.NewRecorder()
+	StatusAPIHandler(w, httptest.NewRequest("GET", "/api/status", nil), c, "nginx", nil)
+
+	var resp StatusResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Status != "flapping" {
+		t.Errorf("Expected status flapping, got %q", resp.Status)
+	}
+}
+
+// TestStatusAPIHandlerReportsDependencies verifies per-dependency states
+// are listed and a failed dependency marks the service unhealthy while its
+// own state is still reported.
+func TestStatusAPIHandlerReportsDependencies(t *testing.T) {
+	c := cache.New()
+	c.UpdateStatus(http.StatusOK, "active")
+	c.SetDependencies([]cache.Dependency{{Unit: "data.mount", State: "failed"}})
+
+	w := httptest.NewRecorder()
+	StatusAPIHandler(w, httptest.NewRequest("GET", "/api/status", nil), c, "app", nil)
+
+	var resp StatusResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Healthy || resp.State != "active" || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected unhealthy active service with 503, got %+v", resp)
+	}
+	if len(resp.Dependencies) != 1 || resp.Dependencies[0].Unit != "data.mount" {
+		t.Errorf("Unexpected dependencies: %+v", resp.Dependencies)
+	}
+}
+
+// -----------------------------------------------------------------------
+// History API Tests
+// -----------------------------------------------------------------------
+
+// TestHistoryAPIHandler verifies /api/history returns recorded transitions
+// as JSON for the dashboard timeline.
+func TestHistoryAPIHandler(t *testing.T) {
+	c := cache.New()
+	c.UpdateStatus(http.StatusOK, "active")
+	c.UpdateStatus(http.StatusServiceUnavailable, "failed")
+
+	req := httptest.NewRequest("GET", "/api/history", nil)
+	w := httptest.NewRecorder()
+
+	HistoryAPIHandler(w, req, c, "nginx")
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var resp HistoryResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if resp.Service != "nginx" {
+		t.Errorf("Expected service nginx, got %q", resp.Service)
+	}
+	if len(resp.Transitions) != 2 {
+		t.Errorf("Expected 2 transitions, got %d", len(resp.Transitions))
+	}
+}
+
+// -----------------------------------------------------------------------
+// Dashboard Tests
+// -----------------------------------------------------------------------
+
+// TestDashboardHandlerServesDirectory verifies files are served from the
+// configured directory, with index.html for unknown paths.
+func TestDashboardHandlerServesDirectory(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("custom dashboard"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "app.js"), []byte("console.log(1)"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/", "custom dashboard"},
+		{"/app.js", "console.log(1)"},
+		{"/services/nginx", "custom dashboard"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.URL.Path = tt.path
+		w := httptest.NewRecorder()
+
+		DashboardHandler(w, req, dir, []byte("embedded"))
+
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected status %d, got %d", tt.path, http.StatusOK, w.Code)
+		}
+		if got := w.Body.String(); got != tt.want {
+			t.Errorf("%s: expected body %q, got %q", tt.path, tt.want, got)
+		}
+	}
+}
+
+// TestDashboardHandlerRejectsTraversal verifies files outside the dashboard
+// directory are never served.
+func TestDashboardHandlerRejectsTraversal(t *testing.T) {
+	base := t.TempDir()
+	dir := filepath.Join(base, "site")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(base, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.URL.Path = "/../secret.txt"
+	w := httptest.NewRecorder()
+
+	DashboardHandler(w, req, dir, []byte("embedded"))
+
+	if strings.Contains(w.Body.String(), "secret") {
+		t.Error("Served a file outside the dashboard directory")
+	}
+}
+
+// TestDashboardHandlerFallsBackToEmbedded verifies the embedded HTML is
+// served when no directory is configured or it has no index.html.
+func TestDashboardHandlerFallsBackToEmbedded(t *testing.T) {
+	for _, dir := range []string{"", t.TempDir()} {
+		req := httptest.NewRequest("GET", "/", nil)
+		w := httptest.NewRecorder()
+
+		DashboardHandler(w, req, dir, []byte("embedded"))
+
+		if got := w.Body.String(); got != "embedded" {
+			t.Errorf("dir %q: expected embedded dashboard, got %q", dir, got)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+			t.Errorf("dir %q: unexpected Content-Type %q", dir, ct)
+		}
+	}
+}
+
+// -----------------------------------------------------------------------
+// Version Tests
+// -----------------------------------------------------------------------
+
+// TestVersionHandler verifies /version reports the linked build metadata.
+// A wrong value would mislead operators confirming which build is deployed.
+func TestVersionHandler(t *testing.T) {
+	req := httptest.NewRequest("GET", "/version", nil)
+	w := httptest.NewRecorder()
+
+	VersionHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var resp VersionResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if resp.Version != version.Version || resp.Commit != version.Commit || resp.BuildDate != version.Date {
+		t.Errorf("Unexpected version response: %+v", resp)
+	}
+}
+
+// -----------------------------------------------------------------------
+// Drain Tests
+// -----------------------------------------------------------------------
+
+// TestDrainHandlerTogglesFlag verifies POST drain/undrain flips the shared
+// flag and reports the new state.
+func TestDrainHandlerTogglesFlag(t *testing.T) {
+	var draining atomic.Bool
+
+	w := httptest.NewRecorder()
+	DrainHandler(w, httptest.NewRequest("POST", "/admin/drain", nil), &draining, true)
+
+	if w.Code != http.StatusOK || !draining.Load() {
+		t.Fatalf("Expected 200 and draining=true, got %d and %v", w.Code, draining.Load())
+	}
+
+	var resp DrainResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || !resp.Draining {
+		t.Errorf("Expected draining=true in response, got %+v (err=%v)", resp, err)
+	}
+
+	w = httptest.NewRecorder()
+	DrainHandler(w, httptest.NewRequest("POST", "/admin/undrain", nil), &draining, false)
+
+	if w.Code != http.StatusOK || draining.Load() {
+		t.Errorf("Expected 200 and draining=false, got %d and %v", w.Code, draining.Load())
+	}
+}
+
+// TestDrainHandlerRequiresPost verifies GET cannot change drain state, so a
+// crawler or a mistyped probe URL cannot take the node out of rotation.
+func TestDrainHandlerRequiresPost(t *testing.T) {
+	var draining atomic.Bool
+
+	w := httptest.NewRecorder()
+	DrainHandler(w, httptest.NewRequest("GET", "/admin/drain", nil), &draining, true)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+	if draining.Load() {
+		t.Error("GET should not enable drain mode")
+	}
+}
+
+// TestDrainingHealthHandler verifies drain mode reports 503 even though the
+// service itself may be healthy.
+func TestDrainingHealthHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	DrainingHealthHandler(w, httptest.NewRequest("GET", "/health", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	DrainingHealthHandler(w, httptest.NewRequest("GET", "/readyz?verbose=1", nil))
+
+	if got := w.Body.String(); got != "node is draining\n" {
+		t.Errorf("Expected draining reason, got %q", got)
+	}
+}
+
+// -----------------------------------------------------------------------
+// Logs API Tests
+// -----------------------------------------------------------------------
+
+// TestLogsAPIHandler verifies entries are returned for the selected service
+// and the requested line count is capped at the configured maximum.
+func TestLogsAPIHandler(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		wantUnit  string
+		wantLines int
+	}{
+		{"defaults", "/api/logs", "nginx", 50},
+		{"fewer lines", "/api/logs?lines=10", "nginx", 10},
+		{"capped", "/api/logs?lines=5000", "nginx", 50},
+		{"named service", "/api/logs?service=redis", "redis", 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotUnit string
+			var gotLines int
+			read := func(_ context.Context, service string, lines int) ([]journal.Entry, error) {
+				gotUnit, gotLines = service, lines
+				return []journal.Entry{{Message: "started"}}, nil
+			}
+
+			w := httptest.NewRecorder()
+			LogsAPIHandler(w, httptest.NewRequest("GET", tt.target, nil), []string{"nginx", "redis"}, 50, read)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+			}
+			if gotUnit != tt.wantUnit || gotLines != tt.wantLines {
+				t.Errorf("Expected %s with %d lines, got %s with %d", tt.wantUnit, tt.wantLines, gotUnit, gotLines)
+			}
+
+			var resp LogsResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if resp.Service != tt.wantUnit || len(resp.Entries) != 1 {
+				t.Errorf("Unexpected response: %+v", resp)
+			}
+		})
+	}
+}
+
+// TestLogsAPIHandlerErrors verifies invalid input and missing journalctl
+// map to distinct status codes.
+func TestLogsAPIHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		err        error
+		wantStatus int
+	}{
+		{"unmonitored service", "/api/logs?service=sshd", nil, http.StatusNotFound},
+		{"invalid lines", "/api/logs?lines=abc", nil, http.StatusBadRequest},
+		{"no journalctl", "/api/logs", journal.ErrUnavailable, http.StatusNotImplemented},
+		{"journalctl failed", "/api/logs", errors.New("exit status 1"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			read := func(context.Context, string, int) ([]journal.Entry, error) {
+				return nil, tt.err
+			}
+
+			w := httptest.NewRecorder()
+			LogsAPIHandler(w, httptest.NewRequest("GET", tt.target, nil), []string{"nginx"}, 50, read)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+}
+
+// -----------------------------------------------------------------------
+// Reset Stats Tests
+// -----------------------------------------------------------------------
+
+// TestResetStatsHandler verifies the selected service's counters are reset
+// while its status and the other services are left alone.
+func TestResetStatsHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		wantStatus int
+		wantReset  string
+	}{
+		{"primary by default", "POST", "/admin/reset-stats", http.StatusOK, "nginx"},
+		{"named service", "POST", "/admin/reset-stats?service=redis", http.StatusOK, "redis"},
+		{"unmonitored service", "POST", "/admin/reset-stats?service=sshd", http.StatusNotFound, ""},
+		{"GET rejected", "GET", "/admin/reset-stats", http.StatusMethodNotAllowed, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caches := map[string]cache.StatusStore{"nginx": cache.New(), "redis": cache.New()}
+			for _, c := range caches {
+				c.UpdateStatus(http.StatusServiceUnavailable, "failed")
+			}
+
+			w := httptest.NewRecorder()
+			ResetStatsHandler(w, httptest.NewRequest(tt.method, tt.target, nil), "nginx", caches)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+
+			for name, c := range caches {
+				reset := len(c.GetHistory()) == 0
+				if reset != (name == tt.wantReset) {
+					t.Errorf("%s: expected reset=%v, got %v", name, name == tt.wantReset, reset)
+				}
+				if _, state := c.GetStatus(); state != "failed" {
+					t.Errorf("%s: expected status to be kept, got %q", name, state)
+				}
+			}
+
+			if tt.wantReset != "" {
+				var resp ResetStatsResponse
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatalf("Failed to decode response: %v", err)
+				}
+				if resp.Service != tt.wantReset || resp.StatsSince.IsZero() {
+					t.Errorf("Unexpected response: %+v", resp)
+				}
+			}
+		})
+	}
+}
+
+// -----------------------------------------------------------------------
+// Restart Tests
+// -----------------------------------------------------------------------
+
+// TestRestartHandler verifies the job result is mapped to a status code and
+// the primary service is restarted when none is named.
+func TestRestartHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		result     string
+		wantStatus int
+		wantUnit   string
+	}{
+		{"primary by default", "/admin/restart", "done", http.StatusOK, "nginx"},
+		{"named service", "/admin/restart?service=redis", "done", http.StatusOK, "redis"},
+		{"job still running", "/admin/restart", checker.JobPending, http.StatusAccepted, "nginx"},
+		{"job failed", "/admin/restart", "failed", http.StatusInternalServerError, "nginx"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var restarted string
+			restart := func(_ context.Context, service string) (string, error) {
+				restarted = service
+				return tt.result, nil
+			}
+
+			w := httptest.NewRecorder()
+			RestartHandler(w, httptest.NewRequest("POST", tt.target, nil), []string{"nginx", "redis"}, restart)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if restarted != tt.wantUnit {
+				t.Errorf("Expected %q restarted, got %q", tt.wantUnit, restarted)
+			}
+
+			var resp RestartResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if resp.Service != tt.wantUnit || resp.Result != tt.result {
+				t.Errorf("Unexpected response: %+v", resp)
+			}
+		})
+	}
+}
+
+// TestRestartHandlerRejectsUnmonitoredService verifies the endpoint cannot
+// be used to restart arbitrary units on the host.
+func TestRestartHandlerRejectsUnmonitoredService(t *testing.T) {
+	restart := func(context.Context, string) (string, error) {
+		t.Fatal("restart must not be called for an unmonitored service")
+		return "", nil
+	}
+
+	w := httptest.NewRecorder()
+	RestartHandler(w, httptest.NewRequest("POST", "/admin/restart?service=sshd", nil), []string{"nginx"}, restart)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestRestartHandlerRequiresPost verifies a GET (e.g. a crawler or a
+// prefetching browser) cannot trigger a restart.
+func TestRestartHandlerRequiresPost(t *testing.T) {
+	restart := func(context.Context, string) (string, error) {
+		t.Fatal("restart must not be called for GET")
+		return "", nil
+	}
+
+	w := httptest.NewRecorder()
+	RestartHandler(w, httptest.NewRequest("GET", "/admin/restart", nil), []string{"nginx"}, restart)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
+
+// TestRestartHandlerReportsErrors verifies a D-Bus failure returns 500.
+func TestRestartHandlerReportsErrors(t *testing.T) {
+	restart := func(context.Context, string) (string, error) {
+		return "", errors.New("connection refused")
+	}
+
+	w := httptest.NewRecorder()
+	RestartHandler(w, httptest.NewRequest("POST", "/admin/restart", nil), []string{"nginx"}, restart)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
+
+// -----------------------------------------------------------------------
+// Rate Limit Stats Tests
+// -----------------------------------------------------------------------
+
+// TestRateLimitStatsHandler verifies each limiter's stats are reported
+// under its endpoint label.
+func TestRateLimitStatsHandler(t *testing.T) {
+	health := ratelimit.New(100, 200)
+	health.Allow("10.0.0.1")
+	health.Allow("10.0.0.2")
+
+	limiters := map[string]*ratelimit.Manager{
+		"health":  health,
+		"metrics": ratelimit.New(2, 10),
+	}
+
+	w := httptest.NewRecorder()
+	RateLimitStatsHandler(w, httptest.NewRequest("GET", "/admin/ratelimit", nil), limiters)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var resp RateLimitResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if got := resp.Limiters["health"]["active_ips"]; got != 2.0 {
+		t.Errorf("Expected 2 active IPs for health, got %v", got)
+	}
+	if got := resp.Limiters["metrics"]["burst"]; got != 10.0 {
+		t.Errorf("Expected metrics burst 10, got %v", got)
+	}
+}
@@ -0,0 +1,167 @@
+// -----------------------------------------------------------------------
+// Journal Log Reader
+// -----------------------------------------------------------------------
+//
+// Package journal reads recent journald entries for a systemd unit by
+// running journalctl with JSON output. journalctl is used rather than the
+// sd-journal C API so the binary stays pure Go; hosts without journalctl
+// (containers, non-systemd logging) get ErrUnavailable.
+//
+// -----------------------------------------------------------------------
+
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// DefaultLines is the number of entries returned when none is configured.
+const DefaultLines = 50
+
+// MaxLines caps the number of entries a single query may request.
+const MaxLines = 1000
+
+// ErrUnavailable is returned when journalctl is not installed.
+var ErrUnavailable = errors.New("journalctl not available")
+
+// binary is the journalctl executable, overridden in tests.
+var binary = "journalctl"
+
+// -----------------------------------------------------------------------
+// Types
+// -----------------------------------------------------------------------
+
+// Query selects the journal entries to read.
+type Query struct {
+	// Unit is the full unit name, e.g. "nginx.service".
+	Unit string
+
+	// Lines is the number of most recent entries to return.
+	Lines int
+
+	// User reads the user journal (--user-unit) for units monitored on the
+	// session bus.
+	User bool
+}
+
+// Entry is a single journal record.
+type Entry struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Priority   int       `json:"priority"`
+	Identifier string    `json:"identifier,omitempty"`
+	PID        int       `json:"pid,omitempty"`
+	Message    string    `json:"message"`
+}
+
+// -----------------------------------------------------------------------
+// Reading
+// -----------------------------------------------------------------------
+
+// Read returns the most recent entries for q.Unit, oldest first.
+func Read(ctx context.Context, q Query) ([]Entry, error) {
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return nil, ErrUnavailable
+	}
+
+	unitFlag := "--unit"
+	if q.User {
+		unitFlag = "--user-unit"
+	}
+
+	// Arguments are passed directly, never through a shell; the unit name
+	// comes from configuration, not from the request
+	cmd := exec.CommandContext(ctx, path,
+		unitFlag, q.Unit,
+		"--lines", strconv.Itoa(q.Lines),
+		"--no-pager",
+		"--output", "json",
+	)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("journalctl: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	return Parse(out)
+}
+
+// Parse decodes journalctl's JSON output, one object per line.
+func Parse(out []byte) ([]Entry, error) {
+	var entries []Entry
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(line, &raw); err != nil {
+			return nil, fmt.Errorf("decode journal entry: %w", err)
+		}
+		entries = append(entries, parseEntry(raw))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read journal output: %w", err)
+	}
+
+	return entries, nil
+}
+
+// parseEntry extracts the fields of interest. journald serializes every
+// field as a string, except fields with non-printable content which are
+// emitted as arrays of byte values.
+func parseEntry(raw map[string]json.RawMessage) Entry {
+	var e Entry
+
+	if usec, err := strconv.ParseInt(field(raw, "__REALTIME_TIMESTAMP"), 10, 64); err == nil {
+		e.Timestamp = time.UnixMicro(usec).UTC()
+	}
+	if priority, err := strconv.Atoi(field(raw, "PRIORITY")); err == nil {
+		e.Priority = priority
+	}
+	if pid, err := strconv.Atoi(field(raw, "_PID")); err == nil {
+		e.PID = pid
+	}
+	e.Identifier = field(raw, "SYSLOG_IDENTIFIER")
+	e.Message = field(raw, "MESSAGE")
+
+	return e
+}
+
+// field returns a journal field as a string, decoding the byte array form.
+func field(raw map[string]json.RawMessage, name string) string {
+	value, ok := raw[name]
+	if !ok {
+		return ""
+	}
+
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return s
+	}
+
+	var ints []int
+	if err := json.Unmarshal(value, &ints); err == nil {
+		b := make([]byte, len(ints))
+		for i, v := range ints {
+			b[i] = byte(v)
+		}
+		return string(b)
+	}
+
+	return ""
+}
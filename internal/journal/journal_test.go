@@ -0,0 +1,67 @@
+// -----------------------------------------------------------------------
+// Journal Log Reader - Tests
+// -----------------------------------------------------------------------
+//
+// Validates decoding of journalctl's JSON output. The tests do not depend
+// on journalctl being installed.
+//
+// -----------------------------------------------------------------------
+
+package journal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestParse verifies journalctl JSON lines are decoded into entries,
+// including messages serialized as byte arrays.
+func TestParse(t *testing.T) {
+	out := []byte(`{"__REALTIME_TIMESTAMP":"1760531696000000","PRIORITY":"3","_PID":"1234","SYSLOG_IDENTIFIER":"nginx","MESSAGE":"bind() failed"}
+{"__REALTIME_TIMESTAMP":"1760531697000000","PRIORITY":"6","MESSAGE":[104,105,27]}
+
+`)
+
+	entries, err := Parse(out)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+
+	first := entries[0]
+	if first.Message != "bind() failed" || first.Priority != 3 || first.PID != 1234 || first.Identifier != "nginx" {
+		t.Errorf("Unexpected first entry: %+v", first)
+	}
+	if !first.Timestamp.Equal(time.UnixMicro(1760531696000000)) {
+		t.Errorf("Unexpected timestamp: %v", first.Timestamp)
+	}
+
+	if entries[1].Message != "hi\x1b" {
+		t.Errorf("Expected byte array message decoded, got %q", entries[1].Message)
+	}
+}
+
+// TestParseRejectsInvalidJSON verifies malformed output is an error rather
+// than a silently truncated result.
+func TestParseRejectsInvalidJSON(t *testing.T) {
+	if _, err := Parse([]byte("-- No entries --")); err == nil {
+		t.Error("Expected error for non-JSON output")
+	}
+}
+
+// TestReadWithoutJournalctl verifies a missing binary is reported as
+// ErrUnavailable so callers can distinguish it from a failed query.
+func TestReadWithoutJournalctl(t *testing.T) {
+	original := binary
+	binary = "journalctl-does-not-exist"
+	t.Cleanup(func() { binary = original })
+
+	_, err := Read(context.Background(), Query{Unit: "nginx.service", Lines: 10})
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Expected ErrUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+// -----------------------------------------------------------------------
+// Repeated Error Suppression
+// -----------------------------------------------------------------------
+//
+// A Deduper keeps a persistent failure, such as D-Bus being unreachable,
+// from logging an identical line every check interval. The first
+// occurrence is logged as usual; repeats are counted and reported as a
+// single "still failing" entry once per summary interval.
+//
+// -----------------------------------------------------------------------
+
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultSummaryInterval is how often a repeating entry is summarized when
+// no interval is configured.
+const DefaultSummaryInterval = 60 * time.Second
+
+// Deduper suppresses repeats of identical log entries. Entries are
+// identified by group and message; attributes such as attempt counters or
+// error text may differ between occurrences, and the summary carries the
+// latest ones. Safe for concurrent use.
+type Deduper struct {
+	mu       sync.Mutex
+	interval time.Duration
+	now      func() time.Time
+	groups   map[string]map[string]*repeat
+}
+
+// repeat tracks one suppressed message.
+type repeat struct {
+	lastLogged time.Time
+	suppressed int
+}
+
+// NewDeduper creates a Deduper that summarizes repeats every interval. An
+// interval of zero or less selects DefaultSummaryInterval.
+func NewDeduper(interval time.Duration) *Deduper {
+	if interval <= 0 {
+		interval = DefaultSummaryInterval
+	}
+	return &Deduper{
+		interval: interval,
+		now:      time.Now,
+		groups:   make(map[string]map[string]*repeat),
+	}
+}
+
+// Log writes msg to logger at level the first time it is seen in group.
+// Later occurrences are counted, and once the summary interval has passed
+// since the last entry was written, one summary is logged with the number
+// of occurrences since then.
+func (d *Deduper) Log(ctx context.Context, logger *slog.Logger, level slog.Level, group, msg string, args ...any) {
+	d.mu.Lock()
+	now := d.now()
+	msgs, ok := d.groups[group]
+	if !ok {
+		msgs = make(map[string]*repeat)
+		d.groups[group] = msgs
+	}
+
+	r, seen := msgs[msg]
+	if !seen {
+		msgs[msg] = &repeat{lastLogged: now}
+		d.mu.Unlock()
+		logger.Log(ctx, level, msg, args...)
+		return
+	}
+
+	r.suppressed++
+	if now.Sub(r.lastLogged) < d.interval {
+		d.mu.Unlock()
+		return
+	}
+
+	occurrences := r.suppressed
+	r.suppressed = 0
+	r.lastLogged = now
+	d.mu.Unlock()
+
+	logger.Log(ctx, level, msg+": still failing",
+		append(args, "occurrences", occurrences)...)
+}
+
+// Reset forgets every message in group so the next failure is logged
+// immediately. Call it when the failing operation recovers.
+func (d *Deduper) Reset(group string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.groups, group)
+}
@@ -0,0 +1,68 @@
+// -----------------------------------------------------------------------
+// Repeated Error Suppression - Tests
+// -----------------------------------------------------------------------
+//
+// Validates that repeats are counted rather than logged and that the
+// summary and reset behavior is correct. A fake clock avoids sleeping.
+//
+// -----------------------------------------------------------------------
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDeduperSummarizesRepeats verifies the first occurrence is logged,
+// repeats within the interval are suppressed, and a summary with the
+// occurrence count follows once the interval has passed.
+func TestDeduperSummarizesRepeats(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	now := time.Unix(0, 0)
+	d := NewDeduper(time.Minute)
+	d.now = func() time.Time { return now }
+
+	for range 5 {
+		d.Log(context.Background(), logger, slog.LevelError, "nginx", "dbus down")
+		now = now.Add(10 * time.Second)
+	}
+	if got := strings.Count(buf.String(), "\n"); got != 1 {
+		t.Fatalf("Expected 1 line before the interval, got %d:\n%s", got, buf.String())
+	}
+
+	now = now.Add(time.Minute)
+	d.Log(context.Background(), logger, slog.LevelError, "nginx", "dbus down")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected a summary line, got:\n%s", buf.String())
+	}
+	if !strings.Contains(lines[1], "still failing") || !strings.Contains(lines[1], "occurrences=5") {
+		t.Errorf("Unexpected summary: %s", lines[1])
+	}
+}
+
+// TestDeduperReset verifies a reset group logs its next failure in full,
+// and that groups are tracked independently.
+func TestDeduperReset(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	d := NewDeduper(time.Minute)
+
+	d.Log(context.Background(), logger, slog.LevelError, "nginx", "dbus down")
+	d.Log(context.Background(), logger, slog.LevelError, "redis", "dbus down")
+	d.Log(context.Background(), logger, slog.LevelError, "nginx", "dbus down")
+	d.Reset("nginx")
+	d.Log(context.Background(), logger, slog.LevelError, "nginx", "dbus down")
+
+	if got := strings.Count(buf.String(), "\n"); got != 3 {
+		t.Errorf("Expected 3 lines, got %d:\n%s", got, buf.String())
+	}
+}
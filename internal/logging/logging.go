@@ -3,13 +3,16 @@
 // -----------------------------------------------------------------------
 //
 // Package logging provides centralized structured logging configuration via
-// slog. All components use a single logger instance configured at startup and
-// set as the default. Supports JSON output for log aggregation systems or text
-// for local development.
+// slog. It is the only logging setup in the service: all components use a
+// single logger instance configured at startup and set as the default, and
+// write to the one sink selected by LOG_OUTPUT. Supports JSON output for log
+// aggregation systems or text for local development.
 //
 // Configuration via environment variables:
 //   - LOG_LEVEL: debug|info|warn|error (default: info)
 //   - LOG_FORMAT: json|text (default: json)
+//   - LOG_OUTPUT: stdout|stderr|syslog|/path/to/file (default: stdout)
+//   - LOG_SYSLOG_FACILITY, LOG_SYSLOG_TAG: syslog facility and tag
 //   - LOG_SOURCE: true|1 to include file:line (default: false)
 //   - LOG_TAGS: comma-separated key=value pairs added to all logs
 //
@@ -19,9 +22,12 @@ package logging
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"strings"
+	"sync"
 )
 
 // -----------------------------------------------------------------------
@@ -32,8 +38,13 @@ import (
 type Options struct {
 	Level     slog.Level        // Log level threshold (debug, info, warn, error)
 	Format    string            // "json" (default) or "text"
+	Output    string            // "stdout" (default), "stderr", "syslog", or a file path
 	Tags      map[string]string // Static tags added to all log entries
 	AddSource bool              // Include file:line in each log entry
+
+	// Syslog settings, used when Output is "syslog"
+	SyslogFacility string // "daemon" (default), "user", "local0"-"local7", ...
+	SyslogTag      string // Program name in each message (default: "health-checker")
 }
 
 // -----------------------------------------------------------------------
@@ -41,22 +52,29 @@ type Options struct {
 // -----------------------------------------------------------------------
 
 // Init creates and installs a logger with the given configuration.
-// The logger is set as the default via slog.SetDefault.
+// The logger is set as the default via slog.SetDefault. An output that
+// cannot be opened falls back to stderr so startup errors stay visible.
 func Init(opts Options) *slog.Logger {
+	out, openErr := setOutput(opts)
+
 	var h slog.Handler
 	switch strings.ToLower(opts.Format) {
 	case "text":
-		h = slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		h = slog.NewTextHandler(out, &slog.HandlerOptions{
 			Level:     opts.Level,
 			AddSource: opts.AddSource,
 		})
 	default:
-		h = slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		h = slog.NewJSONHandler(out, &slog.HandlerOptions{
 			Level:     opts.Level,
 			AddSource: opts.AddSource,
 		})
 	}
 
+	if lo, ok := out.(leveledOutput); ok {
+		h = levelHandler{Handler: h, out: lo}
+	}
+
 	attrs := make([]any, 0, len(opts.Tags)*2)
 	for k, v := range opts.Tags {
 		attrs = append(attrs, k, v)
@@ -64,6 +82,11 @@ func Init(opts Options) *slog.Logger {
 
 	logger := slog.New(h).With(attrs...)
 	slog.SetDefault(logger)
+
+	if openErr != nil {
+		logger.Error("cannot open log output; logging to stderr",
+			"output", opts.Output, "err", openErr)
+	}
 	return logger
 }
 
@@ -74,6 +97,9 @@ func Init(opts Options) *slog.Logger {
 // Environment Variables:
 //   - LOG_LEVEL: debug|info|warn|error (default: info)
 //   - LOG_FORMAT: json|text (default: json)
+//   - LOG_OUTPUT: stdout|stderr|syslog|/path/to/file (default: stdout)
+//   - LOG_SYSLOG_FACILITY: syslog facility (default: daemon)
+//   - LOG_SYSLOG_TAG: syslog program tag (default: health-checker)
 //   - LOG_SOURCE: true|1 to include file:line (default: false)
 //   - LOG_TAGS: comma-separated key=value pairs (example: "env=prod,team=platform")
 func InitFromEnv(extraTags map[string]string) *slog.Logger {
@@ -99,11 +125,173 @@ func InitFromEnv(extraTags map[string]string) *slog.Logger {
 	return Init(Options{
 		Level:     lvl,
 		Format:    format,
+		Output:    os.Getenv("LOG_OUTPUT"),
 		Tags:      tags,
 		AddSource: addSource,
+
+		SyslogFacility: os.Getenv("LOG_SYSLOG_FACILITY"),
+		SyslogTag:      os.Getenv("LOG_SYSLOG_TAG"),
+	})
+}
+
+// -----------------------------------------------------------------------
+// Output Destination
+// -----------------------------------------------------------------------
+
+// closableOutput is a sink opened by Init that must be closed when a later
+// Init replaces it.
+type closableOutput interface {
+	io.Writer
+	Close() error
+}
+
+// leveledOutput is a sink that records each entry at its own severity,
+// such as syslog. write formats one entry while the sink knows its level.
+type leveledOutput interface {
+	io.Writer
+	writeLevel(level slog.Level, write func() error) error
+}
+
+// current is the sink opened by the last Init and currentKey identifies
+// its settings; both are unset when logging to stdout or stderr.
+var (
+	currentMu  sync.Mutex
+	current    closableOutput
+	currentKey string
+)
+
+// logFile is an append-mode log file that can be reopened after rotation.
+// Writes and reopening are serialized so no entry is split across files.
+type logFile struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+}
+
+// openLogFile opens path for appending, creating it if needed.
+func openLogFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+}
+
+// Write implements io.Writer.
+func (l *logFile) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Write(p)
+}
+
+// Close releases the file handle.
+func (l *logFile) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
+
+// reopen swaps in a fresh handle for the path. On failure the old handle
+// is kept so logging continues to the rotated file.
+func (l *logFile) reopen() error {
+	f, err := openLogFile(l.path)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	old := l.f
+	l.f = f
+	l.mu.Unlock()
+
+	_ = old.Close()
+	return nil
+}
+
+// setOutput resolves opts.Output to a writer and installs it as the
+// current sink. Re-initializing with the same settings keeps the open
+// sink, so loggers derived from the earlier Init keep working; any other
+// sink is closed. On an open error it returns stderr along with the error.
+func setOutput(opts Options) (io.Writer, error) {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+
+	output := opts.Output
+	key := output
+	if strings.EqualFold(output, "syslog") {
+		key = "syslog:" + opts.SyslogFacility + ":" + opts.SyslogTag
+	}
+
+	if current != nil {
+		if currentKey == key {
+			return current, nil
+		}
+		_ = current.Close()
+		current, currentKey = nil, ""
+	}
+
+	var (
+		out closableOutput
+		err error
+	)
+	switch strings.ToLower(output) {
+	case "", "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	case "syslog":
+		out, err = openSyslog(opts.SyslogFacility, opts.SyslogTag)
+	default:
+		var f *os.File
+		if f, err = openLogFile(output); err == nil {
+			out = &logFile{path: output, f: f}
+		}
+	}
+	if err != nil {
+		return os.Stderr, err
+	}
+
+	current, currentKey = out, key
+	return out, nil
+}
+
+// Reopen reopens the log file so entries go to a new file after logrotate
+// has moved the old one. It is a no-op unless logging to a file. Called on
+// SIGHUP.
+func Reopen() error {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+
+	f, ok := current.(*logFile)
+	if !ok {
+		return nil
+	}
+	if err := f.reopen(); err != nil {
+		return fmt.Errorf("reopen log file %s: %w", f.path, err)
+	}
+	return nil
+}
+
+// levelHandler passes each record's level to a leveledOutput so the
+// formatted line is written at the matching severity.
+type levelHandler struct {
+	slog.Handler
+	out leveledOutput
+}
+
+// Handle implements slog.Handler.
+func (h levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.out.writeLevel(r.Level, func() error {
+		return h.Handler.Handle(ctx, r)
 	})
 }
 
+// WithAttrs implements slog.Handler.
+func (h levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return levelHandler{Handler: h.Handler.WithAttrs(attrs), out: h.out}
+}
+
+// WithGroup implements slog.Handler.
+func (h levelHandler) WithGroup(name string) slog.Handler {
+	return levelHandler{Handler: h.Handler.WithGroup(name), out: h.out}
+}
+
 // -----------------------------------------------------------------------
 // Helper Functions
 // -----------------------------------------------------------------------
@@ -0,0 +1,105 @@
+// -----------------------------------------------------------------------
+// Structured Logging Configuration - Tests
+// -----------------------------------------------------------------------
+//
+// Validates output selection: file output survives re-initialization and
+// rotation, and leveled outputs see each entry's level.
+//
+// -----------------------------------------------------------------------
+
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// resetOutput restores stdout logging after a test changes the output.
+func resetOutput(t *testing.T) {
+	t.Cleanup(func() { Init(Options{}) })
+}
+
+// TestFileOutputReopen verifies re-initializing with the same file keeps
+// appending, and that Reopen moves logging to a new file after rotation.
+func TestFileOutputReopen(t *testing.T) {
+	resetOutput(t)
+	path := filepath.Join(t.TempDir(), "health.log")
+
+	Init(Options{Output: path}).Info("first")
+	Init(Options{Output: path}).Info("second")
+
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Reopen(); err != nil {
+		t.Fatalf("Reopen() error = %v", err)
+	}
+	slog.Info("third")
+
+	rotated, _ := os.ReadFile(path + ".1")
+	fresh, _ := os.ReadFile(path)
+	if !strings.Contains(string(rotated), "first") || !strings.Contains(string(rotated), "second") {
+		t.Errorf("Expected first entries in rotated file, got %q", rotated)
+	}
+	if !strings.Contains(string(fresh), "third") || strings.Contains(string(fresh), "first") {
+		t.Errorf("Expected only later entries in new file, got %q", fresh)
+	}
+}
+
+// TestReopenWithoutFile verifies Reopen is a no-op for stdout.
+func TestReopenWithoutFile(t *testing.T) {
+	resetOutput(t)
+	Init(Options{Output: "stdout"})
+
+	if err := Reopen(); err != nil {
+		t.Errorf("Reopen() error = %v", err)
+	}
+}
+
+// recordingOutput is a leveledOutput that records the level of each line.
+type recordingOutput struct {
+	buf    bytes.Buffer
+	level  slog.Level
+	levels []slog.Level
+}
+
+func (r *recordingOutput) writeLevel(level slog.Level, write func() error) error {
+	r.level = level
+	return write()
+}
+
+func (r *recordingOutput) Write(p []byte) (int, error) {
+	r.levels = append(r.levels, r.level)
+	return r.buf.Write(p)
+}
+
+// TestLevelHandler verifies each entry reaches a leveled output with its
+// own level, including through loggers derived with With.
+func TestLevelHandler(t *testing.T) {
+	out := &recordingOutput{}
+	logger := slog.New(levelHandler{
+		Handler: slog.NewTextHandler(out, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		out:     out,
+	}).With("component", "test")
+
+	logger.Debug("d")
+	logger.Warn("w")
+	logger.Error("e")
+
+	want := []slog.Level{slog.LevelDebug, slog.LevelWarn, slog.LevelError}
+	if len(out.levels) != len(want) {
+		t.Fatalf("Expected %d writes, got %d", len(want), len(out.levels))
+	}
+	for i, level := range want {
+		if out.levels[i] != level {
+			t.Errorf("Write %d: expected level %v, got %v", i, level, out.levels[i])
+		}
+	}
+	if !strings.Contains(out.buf.String(), "component=test") {
+		t.Errorf("Expected derived attributes in output, got %q", out.buf.String())
+	}
+}
@@ -0,0 +1,18 @@
+// -----------------------------------------------------------------------
+// Syslog Output (unsupported platforms)
+// -----------------------------------------------------------------------
+
+//go:build windows || plan9
+
+package logging
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// openSyslog reports that syslog output is not available; Init then falls
+// back to stderr and logs the error.
+func openSyslog(_, _ string) (closableOutput, error) {
+	return nil, fmt.Errorf("LOG_OUTPUT=syslog is not supported on %s", runtime.GOOS)
+}
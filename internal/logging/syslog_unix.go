@@ -0,0 +1,113 @@
+// -----------------------------------------------------------------------
+// Syslog Output
+// -----------------------------------------------------------------------
+//
+// LOG_OUTPUT=syslog sends each formatted JSON or text line to the local
+// syslog daemon, at the severity matching the entry's slog level.
+//
+// -----------------------------------------------------------------------
+
+//go:build !windows && !plan9
+
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"log/syslog"
+	"strings"
+	"sync"
+)
+
+// defaultSyslogTag is the program name used when none is configured.
+const defaultSyslogTag = "health-checker"
+
+// syslogFacilities maps LOG_SYSLOG_FACILITY names to facilities.
+var syslogFacilities = map[string]syslog.Priority{
+	"kern":     syslog.LOG_KERN,
+	"user":     syslog.LOG_USER,
+	"mail":     syslog.LOG_MAIL,
+	"daemon":   syslog.LOG_DAEMON,
+	"auth":     syslog.LOG_AUTH,
+	"syslog":   syslog.LOG_SYSLOG,
+	"lpr":      syslog.LOG_LPR,
+	"news":     syslog.LOG_NEWS,
+	"uucp":     syslog.LOG_UUCP,
+	"cron":     syslog.LOG_CRON,
+	"authpriv": syslog.LOG_AUTHPRIV,
+	"ftp":      syslog.LOG_FTP,
+	"local0":   syslog.LOG_LOCAL0,
+	"local1":   syslog.LOG_LOCAL1,
+	"local2":   syslog.LOG_LOCAL2,
+	"local3":   syslog.LOG_LOCAL3,
+	"local4":   syslog.LOG_LOCAL4,
+	"local5":   syslog.LOG_LOCAL5,
+	"local6":   syslog.LOG_LOCAL6,
+	"local7":   syslog.LOG_LOCAL7,
+}
+
+// syslogOutput writes each entry to syslog at the severity set by
+// writeLevel.
+type syslogOutput struct {
+	mu    sync.Mutex
+	w     *syslog.Writer
+	level slog.Level
+}
+
+// openSyslog connects to the local syslog daemon. An empty facility
+// selects daemon and an empty tag selects "health-checker".
+func openSyslog(facility, tag string) (closableOutput, error) {
+	if facility == "" {
+		facility = "daemon"
+	}
+	priority, ok := syslogFacilities[strings.ToLower(facility)]
+	if !ok {
+		return nil, fmt.Errorf("unknown syslog facility %q", facility)
+	}
+	if tag == "" {
+		tag = defaultSyslogTag
+	}
+
+	w, err := syslog.New(priority|syslog.LOG_INFO, tag)
+	if err != nil {
+		return nil, fmt.Errorf("connect to syslog: %w", err)
+	}
+	return &syslogOutput{w: w, level: slog.LevelInfo}, nil
+}
+
+// writeLevel runs write with level as the severity of the lines it writes.
+func (s *syslogOutput) writeLevel(level slog.Level, write func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.level = level
+	return write()
+}
+
+// Write implements io.Writer. Callers outside writeLevel log at the level
+// of the previous entry, which is Info until the first entry.
+func (s *syslogOutput) Write(p []byte) (int, error) {
+	msg := string(bytes.TrimRight(p, "\n"))
+
+	var err error
+	switch {
+	case s.level >= slog.LevelError:
+		err = s.w.Err(msg)
+	case s.level >= slog.LevelWarn:
+		err = s.w.Warning(msg)
+	case s.level >= slog.LevelInfo:
+		err = s.w.Info(msg)
+	default:
+		err = s.w.Debug(msg)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close disconnects from syslog.
+func (s *syslogOutput) Close() error {
+	return s.w.Close()
+}
@@ -0,0 +1,17 @@
+// -----------------------------------------------------------------------
+// Syslog Output - Tests
+// -----------------------------------------------------------------------
+
+//go:build !windows && !plan9
+
+package logging
+
+import "testing"
+
+// TestOpenSyslogRejectsUnknownFacility verifies a typo in the facility is
+// reported instead of silently logging to the wrong facility.
+func TestOpenSyslogRejectsUnknownFacility(t *testing.T) {
+	if _, err := openSyslog("local9", ""); err == nil {
+		t.Error("Expected error for unknown facility")
+	}
+}
@@ -12,22 +12,40 @@
 package metrics
 
 import (
+	"errors"
+
+	"github.com/afreidah/health-check-service/internal/version"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 )
 
 // -----------------------------------------------------------------------
 // Request and Service Health Metrics
 // -----------------------------------------------------------------------
 
+// RequestDurationBuckets are the RequestDuration histogram buckets in
+// seconds, from 100µs to 100ms.
+var RequestDurationBuckets = []float64{
+	0.0001, 0.00025, 0.0005,
+	0.001, 0.0025, 0.005,
+	0.01, 0.025, 0.05,
+	0.1,
+}
+
 var (
-	// RequestsTotal counts all health check requests by status code.
-	// Enables tracking of request volume, error rates, and success rates.
+	// RequestsTotal counts all requests by endpoint and status code,
+	// including those rejected with 429 by the rate limiter. Enables
+	// tracking of request volume, error rates, and success rates per route.
+	//
+	// Labels:
+	//   - endpoint: Route name (health, readyz, api_status, metrics, ...)
+	//   - status_code: HTTP status code of the response
 	RequestsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "health_check_requests_total",
-			Help: "Total number of health check requests by HTTP status code",
+			Help: "Total number of requests by endpoint and HTTP status code",
 		},
-		[]string{"status_code"},
+		[]string{"endpoint", "status_code"},
 	)
 
 	// ServiceStatus tracks the current health of the monitored systemd service.
@@ -45,15 +63,144 @@ var (
 		[]string{"service", "state"},
 	)
 
-	// RequestDuration measures the latency of health check requests using a
-	// histogram with Prometheus default buckets. Enables percentile calculations
+	// ServiceRestarts exposes systemd's NRestarts for each monitored unit.
+	// A rising value while monitored_service_status is 1 indicates a crash
+	// loop that the point-in-time status misses. Despite the _total suffix
+	// this is a gauge: systemd resets NRestarts when the unit is restarted
+	// manually.
+	//
+	// Labels:
+	//   - service: Name of the monitored systemd service
+	ServiceRestarts = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "monitored_service_restarts_total",
+			Help: "Automatic restarts of the monitored systemd service (systemd NRestarts)",
+		},
+		[]string{"service"},
+	)
+
+	// ServiceActiveSince is the Unix time at which systemd reports the
+	// service entered the active state (ActiveEnterTimestamp), so
+	// time() - monitored_service_active_since_seconds is the service's own
+	// uptime. Unlike the checker's computed uptime it survives checker
+	// restarts. The series is removed while the service is not active.
+	//
+	// Labels:
+	//   - service: Name of the monitored systemd service
+	ServiceActiveSince = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "monitored_service_active_since_seconds",
+			Help: "Unix time the monitored systemd service entered the active state (systemd ActiveEnterTimestamp)",
+		},
+		[]string{"service"},
+	)
+
+	// ServiceDowntime accumulates the seconds each monitored service has
+	// spent in a non-active state since the checker started. Each interval
+	// between checks is attributed to the state observed at its start.
+	//
+	// Labels:
+	//   - service: Name of the monitored systemd service
+	ServiceDowntime = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "monitored_service_downtime_seconds_total",
+			Help: "Cumulative seconds the monitored systemd service has not been active",
+		},
+		[]string{"service"},
+	)
+
+	// ServiceRecoveries counts transitions of a monitored service from an
+	// unhealthy state back to a healthy one, marking the end of an outage.
+	//
+	// Labels:
+	//   - service: Name of the monitored systemd service
+	ServiceRecoveries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "monitored_service_recoveries_total",
+			Help: "Total times the monitored service recovered from an unhealthy state",
+		},
+		[]string{"service"},
+	)
+
+	// ServiceFlapping is 1 while a service repeatedly enters and leaves
+	// the active state within the flap detection window, distinguishing
+	// an unstable service from one that is cleanly down.
+	//
+	// Labels:
+	//   - service: Name of the monitored systemd service
+	ServiceFlapping = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "health_check_service_flapping",
+			Help: "Whether the monitored service is flapping between active and non-active (1=flapping)",
+		},
+		[]string{"service"},
+	)
+
+	// RequestDuration measures the latency of health check requests. Requests
+	// are served from cache in microseconds, so the buckets span 100µs to
+	// 100ms rather than the Prometheus defaults (which start at 5ms and would
+	// put every request in the first bucket). Enables percentile calculations
 	// (p50, p95, p99) for SLA monitoring and detects performance degradation.
-	RequestDuration = prometheus.NewHistogram(
+	// Split by endpoint so JSON-encoding routes such as /api/status are not
+	// blurred together with the status-code-only /health path.
+	//
+	// Labels:
+	//   - endpoint: Route name (health, readyz, api_status, ...)
+	RequestDuration = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "health_check_request_duration_seconds",
-			Help:    "Duration of health check requests in seconds",
-			Buckets: prometheus.DefBuckets,
+			Help:    "Duration of requests in seconds by endpoint",
+			Buckets: RequestDurationBuckets,
 		},
+		[]string{"endpoint"},
+	)
+)
+
+// -----------------------------------------------------------------------
+// Rate Limiting Metrics
+// -----------------------------------------------------------------------
+
+var (
+	// RateLimitRejected counts requests rejected with 429 by the rate
+	// limiter. A rising count means a monitoring tool is being throttled.
+	//
+	// Labels:
+	//   - endpoint: Rate-limited endpoint category (health, dashboard, metrics, ...)
+	RateLimitRejected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "health_check_ratelimit_rejected_total",
+			Help: "Total number of requests rejected by rate limiting by endpoint",
+		},
+		[]string{"endpoint"},
+	)
+
+	// RateLimitTrackedIPs reports how many client IPs each rate limiter is
+	// tracking. Updated when an IP is first seen and on every cleanup pass,
+	// so a flood of distinct IPs shows up before entries are evicted.
+	//
+	// Labels:
+	//   - endpoint: Limiter endpoint group (health, dashboard, metrics)
+	RateLimitTrackedIPs = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "health_check_ratelimit_tracked_ips",
+			Help: "Number of client IPs currently tracked by each rate limiter",
+		},
+		[]string{"endpoint"},
+	)
+
+	// RateLimitOverflow counts requests from IPs that arrived while a rate
+	// limiter was at its tracked-IP cap and were limited by the shared
+	// overflow bucket. Any increase suggests a flood of distinct (possibly
+	// spoofed) client IPs.
+	//
+	// Labels:
+	//   - endpoint: Limiter endpoint group (health, dashboard, metrics)
+	RateLimitOverflow = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "health_check_ratelimit_overflow_total",
+			Help: "Total number of requests limited by the shared overflow bucket because the tracked-IP cap was reached",
+		},
+		[]string{"endpoint"},
 	)
 )
 
@@ -64,11 +211,12 @@ var (
 var (
 	// CheckFailures counts failed health check attempts by error category.
 	// Distinguishes infrastructure failures (dbus_error) from code issues
-	// (type_error).
+	// (type_error), from units that were removed or masked (unit_missing),
+	// and from failed TCP/HTTP probes (probe_failed).
 	//
 	// Labels:
 	//   - service: Name of the monitored systemd service
-	//   - error_type: Category of failure (dbus_error, type_error)
+	//   - error_type: Category of failure (dbus_error, type_error, unit_missing, probe_failed)
 	CheckFailures = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "health_check_failures_total",
@@ -92,6 +240,62 @@ var (
 		[]string{"service"},
 	)
 
+	// DBusQueryDuration measures the latency of individual D-Bus property
+	// queries. Buckets span 1ms to ~4s so rising p99 is visible well before
+	// the 5s check timeout fires and the watchdog reports the checker stuck.
+	//
+	// Labels:
+	//   - service: Name of the monitored systemd service
+	DBusQueryDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "health_check_dbus_query_duration_seconds",
+			Help:    "Duration of D-Bus unit property queries in seconds",
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 13),
+		},
+		[]string{"service"},
+	)
+
+	// DBusReconnects counts D-Bus reconnection attempts made after a failed
+	// check. Graphed against DBusReconnectSuccess, shows connection churn that
+	// correlates with D-Bus daemon restarts.
+	//
+	// Labels:
+	//   - service: Name of the monitored systemd service
+	DBusReconnects = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "health_check_dbus_reconnects_total",
+			Help: "Total number of D-Bus reconnection attempts",
+		},
+		[]string{"service"},
+	)
+
+	// DBusReconnectSuccess counts reconnection attempts that produced a
+	// working connection (connected and passed a verification check).
+	//
+	// Labels:
+	//   - service: Name of the monitored systemd service
+	DBusReconnectSuccess = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "health_check_dbus_reconnect_success_total",
+			Help: "Total number of successful D-Bus reconnections",
+		},
+		[]string{"service"},
+	)
+
+	// CheckEffectiveInterval reports the current wait between checks in
+	// seconds. Equals the configured interval unless adaptive backoff has
+	// slowed polling of a persistently down service.
+	//
+	// Labels:
+	//   - service: Name of the monitored systemd service
+	CheckEffectiveInterval = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "health_check_effective_interval_seconds",
+			Help: "Current interval between health checks in seconds (after adaptive backoff)",
+		},
+		[]string{"service"},
+	)
+
 	// CheckerHealthy provides a simple boolean signal: is the checker responding?
 	// Set by the watchdog goroutine that monitors checker responsiveness.
 	// Set to 1 when checker has updated health information within the expected
@@ -99,8 +303,22 @@ var (
 	CheckerHealthy = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "health_checker_healthy",
-			Help: "Whether the background checker goroutine is responding (1=yes, 0=stuck)",
+			Help: "Whether every background checker goroutine is responding (1=yes, 0=any stuck)",
+		},
+	)
+
+	// CheckerServiceHealthy is CheckerHealthy for each service's checker
+	// goroutine, so one stuck checker among many is identifiable.
+	// CheckerHealthy is 1 only while every checker is responding.
+	//
+	// Labels:
+	//   - service: Name of the monitored service
+	CheckerServiceHealthy = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "health_checker_service_healthy",
+			Help: "Whether the service's checker goroutine is responding (1=yes, 0=stuck)",
 		},
+		[]string{"service"},
 	)
 
 	// CheckerLastCheckTimestamp records the Unix timestamp of the most recent
@@ -114,6 +332,107 @@ var (
 	)
 )
 
+// -----------------------------------------------------------------------
+// Configuration Metrics
+// -----------------------------------------------------------------------
+
+var (
+	// ConfigReloads counts SIGHUP configuration reloads. A failure means
+	// the new configuration was invalid and the previous one is still in
+	// use.
+	//
+	// Labels:
+	//   - result: success or failure
+	ConfigReloads = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "health_check_config_reloads_total",
+			Help: "Total number of configuration reloads by result",
+		},
+		[]string{"result"},
+	)
+
+	// ConfigLastReload is the Unix time of the last successful reload,
+	// zero until the first one.
+	ConfigLastReload = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "health_check_config_last_reload_timestamp_seconds",
+			Help: "Unix timestamp of the last successful configuration reload",
+		},
+	)
+)
+
+// -----------------------------------------------------------------------
+// TLS Metrics
+// -----------------------------------------------------------------------
+
+var (
+	// TLSCertExpiry is the NotAfter time of the certificate being served,
+	// refreshed daily and whenever a manual certificate is reloaded. Zero
+	// when TLS is disabled or autocert has not issued a certificate yet.
+	TLSCertExpiry = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "health_check_tls_cert_expiry_timestamp_seconds",
+			Help: "Unix time the served TLS certificate expires (NotAfter)",
+		},
+	)
+)
+
+// -----------------------------------------------------------------------
+// Notification Metrics
+// -----------------------------------------------------------------------
+
+var (
+	// WebhookFailures counts state change notifications that were not
+	// delivered. Events are never retried, so every increment is a lost
+	// notification.
+	//
+	// Labels:
+	//   - notifier: webhook or slack
+	//   - reason: queue_full, encode_error, request_error, bad_status
+	WebhookFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "health_check_webhook_failures_total",
+			Help: "Total number of state change notifications that failed to deliver",
+		},
+		[]string{"notifier", "reason"},
+	)
+
+	// EventLogFailures counts state change events that were not appended
+	// to the event log file.
+	//
+	// Labels:
+	//   - reason: encode_error, write_error
+	EventLogFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "health_check_event_log_failures_total",
+			Help: "Total number of state change events that failed to be written to the event log",
+		},
+		[]string{"reason"},
+	)
+)
+
+// -----------------------------------------------------------------------
+// Build Metadata
+// -----------------------------------------------------------------------
+
+var (
+	// BuildInfo is a constant gauge set to 1 whose labels carry the build
+	// metadata, following the Prometheus build-info convention. Join on it in
+	// queries to annotate dashboards with deploys.
+	//
+	// Labels:
+	//   - version: Release version (git describe)
+	//   - commit: Short git commit hash
+	//   - build_date: UTC build timestamp
+	BuildInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "health_check_build_info",
+			Help: "Build metadata for the running binary (always 1)",
+		},
+		[]string{"version", "commit", "build_date"},
+	)
+)
+
 // -----------------------------------------------------------------------
 // Metric Registration
 // -----------------------------------------------------------------------
@@ -125,9 +444,59 @@ var (
 func init() {
 	prometheus.MustRegister(RequestsTotal)
 	prometheus.MustRegister(ServiceStatus)
+	prometheus.MustRegister(ServiceRestarts)
+	prometheus.MustRegister(ServiceActiveSince)
+	prometheus.MustRegister(ServiceDowntime)
+	prometheus.MustRegister(ServiceRecoveries)
+	prometheus.MustRegister(ServiceFlapping)
 	prometheus.MustRegister(RequestDuration)
+	prometheus.MustRegister(RateLimitRejected)
+	prometheus.MustRegister(RateLimitTrackedIPs)
+	prometheus.MustRegister(RateLimitOverflow)
 	prometheus.MustRegister(CheckFailures)
 	prometheus.MustRegister(CacheStaleness)
+	prometheus.MustRegister(DBusQueryDuration)
+	prometheus.MustRegister(DBusReconnects)
+	prometheus.MustRegister(DBusReconnectSuccess)
+	prometheus.MustRegister(CheckEffectiveInterval)
+	prometheus.MustRegister(WebhookFailures)
+	prometheus.MustRegister(EventLogFailures)
+	prometheus.MustRegister(ConfigReloads)
+	prometheus.MustRegister(ConfigLastReload)
+	prometheus.MustRegister(TLSCertExpiry)
 	prometheus.MustRegister(CheckerHealthy)
+	prometheus.MustRegister(CheckerServiceHealthy)
 	prometheus.MustRegister(CheckerLastCheckTimestamp)
+	prometheus.MustRegister(BuildInfo)
+
+	if err := registerRuntimeCollectors(prometheus.DefaultRegisterer); err != nil {
+		panic(err)
+	}
+
+	// Linker flags are applied before init runs, so the injected values are
+	// already in place here
+	BuildInfo.WithLabelValues(version.Version, version.Commit, version.Date).Set(1)
+
+	// Export both reload results from the start so increase() sees the
+	// first failure
+	ConfigReloads.WithLabelValues("success")
+	ConfigReloads.WithLabelValues("failure")
+}
+
+// registerRuntimeCollectors registers the Go runtime (go_goroutines,
+// go_memstats_*) and process (process_cpu_seconds_total, process_open_fds,
+// ...) collectors. client_golang's default registry already includes them,
+// so an already registered collector is not an error; registering
+// explicitly keeps them exposed should /metrics move to its own registry.
+func registerRuntimeCollectors(reg prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	} {
+		var already prometheus.AlreadyRegisteredError
+		if err := reg.Register(c); err != nil && !errors.As(err, &already) {
+			return err
+		}
+	}
+	return nil
 }
@@ -11,9 +11,13 @@
 package metrics
 
 import (
+	"runtime"
 	"testing"
 
+	"github.com/afreidah/health-check-service/internal/version"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 )
 
 // -----------------------------------------------------------------------
@@ -35,6 +39,15 @@ func TestMetricsRegistration(t *testing.T) {
 	if RequestDuration == nil {
 		t.Error("RequestDuration metric is nil")
 	}
+	if DBusQueryDuration == nil {
+		t.Error("DBusQueryDuration metric is nil")
+	}
+	if DBusReconnects == nil || DBusReconnectSuccess == nil {
+		t.Error("D-Bus reconnect metrics are nil")
+	}
+	if CheckEffectiveInterval == nil {
+		t.Error("CheckEffectiveInterval metric is nil")
+	}
 }
 
 // -----------------------------------------------------------------------
@@ -46,13 +59,13 @@ func TestMetricsRegistration(t *testing.T) {
 // monitoring systems from calculating rates like requests per second.
 func TestRequestsTotalIncrement(t *testing.T) {
 	// Get baseline value (may not be zero due to other tests)
-	before := testutil.ToFloat64(RequestsTotal.WithLabelValues("200"))
+	before := testutil.ToFloat64(RequestsTotal.WithLabelValues("health", "200"))
 
 	// Increment the counter
-	RequestsTotal.WithLabelValues("200").Inc()
+	RequestsTotal.WithLabelValues("health", "200").Inc()
 
 	// Verify it increased by at least 1
-	after := testutil.ToFloat64(RequestsTotal.WithLabelValues("200"))
+	after := testutil.ToFloat64(RequestsTotal.WithLabelValues("health", "200"))
 
 	if after <= before {
 		t.Errorf("Counter did not increment: before=%f, after=%f", before, after)
@@ -74,10 +87,10 @@ func TestMultipleLabelValues(t *testing.T) {
 	for _, tt := range tests {
 		t.Run("status_"+tt.statusCode, func(t *testing.T) {
 			// Increment counter with this label
-			RequestsTotal.WithLabelValues(tt.statusCode).Inc()
+			RequestsTotal.WithLabelValues("health", tt.statusCode).Inc()
 
 			// Verify it's tracked separately
-			value := testutil.ToFloat64(RequestsTotal.WithLabelValues(tt.statusCode))
+			value := testutil.ToFloat64(RequestsTotal.WithLabelValues("health", tt.statusCode))
 			if value <= 0 {
 				t.Errorf("Counter for status %s should be > 0", tt.statusCode)
 			}
@@ -150,13 +163,99 @@ func TestServiceStatusMultipleServices(t *testing.T) {
 // prevent latency analysis and performance detection.
 func TestRequestDurationObserve(t *testing.T) {
 	// Record some sample latencies (in seconds)
-	RequestDuration.Observe(0.1) // 100ms - fast
-	RequestDuration.Observe(0.5) // 500ms - moderate
-	RequestDuration.Observe(1.0) // 1s - slow
+	RequestDuration.WithLabelValues("health").Observe(0.1)     // 100ms - fast
+	RequestDuration.WithLabelValues("health").Observe(0.5)     // 500ms - moderate
+	RequestDuration.WithLabelValues("api_status").Observe(1.0) // 1s - slow
 
 	// Verify histogram is collecting observations
 	count := testutil.CollectAndCount(RequestDuration)
-	if count == 0 {
-		t.Error("Histogram reported 0 metrics collected")
+	if count < 2 {
+		t.Errorf("Expected a series per endpoint, got %d", count)
+	}
+}
+
+// TestRequestDurationBuckets verifies a sub-millisecond request lands in a
+// sub-millisecond bucket, so percentiles reflect cache-served latency.
+func TestRequestDurationBuckets(t *testing.T) {
+	h := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "test_request_duration_seconds",
+		Buckets: RequestDurationBuckets,
+	})
+	h.Observe(0.0002)
+
+	var m dto.Metric
+	if err := h.Write(&m); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	for _, b := range m.GetHistogram().GetBucket() {
+		if b.GetUpperBound() <= 0.0001 && b.GetCumulativeCount() != 0 {
+			t.Errorf("200µs observation counted in the %gs bucket", b.GetUpperBound())
+		}
+		if b.GetUpperBound() == 0.00025 && b.GetCumulativeCount() != 1 {
+			t.Errorf("Expected 200µs observation in the 250µs bucket")
+		}
+	}
+}
+
+// TestDBusQueryDurationObserve verifies the D-Bus latency histogram records
+// observations per service. Without it, slow D-Bus responses are invisible
+// until the check timeout fires.
+func TestDBusQueryDurationObserve(t *testing.T) {
+	DBusQueryDuration.WithLabelValues("nginx").Observe(0.002)
+	DBusQueryDuration.WithLabelValues("redis").Observe(0.5)
+
+	if count := testutil.CollectAndCount(DBusQueryDuration); count < 2 {
+		t.Errorf("Expected at least 2 series, got %d", count)
+	}
+}
+
+// TestBuildInfo verifies the build-info gauge is set to 1 with the linked
+// version labels. Queries that join on it to annotate deploys depend on the
+// value being exactly 1.
+func TestBuildInfo(t *testing.T) {
+	got := testutil.ToFloat64(BuildInfo.WithLabelValues(version.Version, version.Commit, version.Date))
+	if got != 1 {
+		t.Errorf("Expected build info gauge 1, got %f", got)
+	}
+}
+
+// -----------------------------------------------------------------------
+// Runtime Collector Tests
+// -----------------------------------------------------------------------
+
+// TestRuntimeCollectorsExposed verifies Go runtime and process metrics are
+// served alongside the custom metrics.
+func TestRuntimeCollectorsExposed(t *testing.T) {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+
+	names := make(map[string]bool, len(families))
+	for _, f := range families {
+		names[f.GetName()] = true
+	}
+
+	want := []string{"go_goroutines", "go_memstats_alloc_bytes"}
+	if runtime.GOOS == "linux" {
+		want = append(want, "process_cpu_seconds_total", "process_open_fds")
+	}
+	for _, name := range want {
+		if !names[name] {
+			t.Errorf("Expected %s on /metrics", name)
+		}
+	}
+}
+
+// TestRegisterRuntimeCollectorsTwice verifies registering the collectors
+// again, as the default registry already does, is not an error.
+func TestRegisterRuntimeCollectorsTwice(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	for i := range 2 {
+		if err := registerRuntimeCollectors(reg); err != nil {
+			t.Fatalf("Registration %d: unexpected error %v", i+1, err)
+		}
 	}
 }
@@ -0,0 +1,138 @@
+// -----------------------------------------------------------------------
+// Event Log File
+// -----------------------------------------------------------------------
+//
+// EventLog appends service_up and service_down events to a file as
+// newline-delimited JSON, for log shippers such as Filebeat that tail a
+// file rather than scrape metrics. Like Slack, only transitions into or
+// out of "active" are written, and the checker's "error" state is not
+// taken for an outage. The file is opened in append mode and can
+// be reopened after logrotate has moved it.
+//
+// -----------------------------------------------------------------------
+
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/afreidah/health-check-service/internal/metrics"
+)
+
+// Event names written to the event log.
+const (
+	EventServiceUp   = "service_up"
+	EventServiceDown = "service_down"
+)
+
+// EventLog writes transitions to an append-only NDJSON file. Writes and
+// reopening are serialized so no line is split across files.
+type EventLog struct {
+	mu    sync.Mutex
+	path  string
+	f     *os.File
+	known knownStates
+}
+
+// eventRecord is one line of the event log.
+type eventRecord struct {
+	Event     string    `json:"event"`
+	Service   string    `json:"service"`
+	PrevState string    `json:"prev_state"`
+	State     string    `json:"state"`
+	Timestamp time.Time `json:"ts"`
+	Downtime  float64   `json:"downtime_s"`
+}
+
+// NewEventLog opens path for appending, creating it if needed.
+func NewEventLog(path string) (*EventLog, error) {
+	f, err := openEventLog(path)
+	if err != nil {
+		return nil, err
+	}
+	return &EventLog{path: path, f: f}, nil
+}
+
+// openEventLog opens path for appending, creating it if needed.
+func openEventLog(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open event log %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Notify writes event if it enters or leaves the active state, looking
+// through checker errors like Slack. A single small append to a local file
+// does not block the check loop; a failed write is logged and counted, and
+// the event is lost.
+func (l *EventLog) Notify(event Event) {
+	event, ok := l.known.resolve(event)
+	if !ok {
+		return
+	}
+
+	var name string
+	switch {
+	case event.From != activeState && event.To == activeState:
+		name = EventServiceUp
+	case event.From == activeState && event.To != activeState:
+		name = EventServiceDown
+	default:
+		return
+	}
+
+	line, err := json.Marshal(eventRecord{
+		Event:     name,
+		Service:   event.Service,
+		PrevState: event.From,
+		State:     event.To,
+		Timestamp: event.Timestamp.UTC(),
+		Downtime:  event.Downtime.Seconds(),
+	})
+	if err != nil {
+		metrics.EventLogFailures.WithLabelValues("encode_error").Inc()
+		logn.Warn("failed to encode event", "service", event.Service, "err", err)
+		return
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.f.Write(line); err != nil {
+		metrics.EventLogFailures.WithLabelValues("write_error").Inc()
+		logn.Warn("failed to write event log",
+			"path", l.path,
+			"service", event.Service,
+			"event", name,
+			"err", err)
+	}
+}
+
+// Reopen swaps in a fresh handle for the path so events go to a new file
+// after rotation. On failure the old handle is kept. Called on SIGHUP.
+func (l *EventLog) Reopen() error {
+	f, err := openEventLog(l.path)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	old := l.f
+	l.f = f
+	l.mu.Unlock()
+
+	_ = old.Close()
+	return nil
+}
+
+// Close releases the file handle.
+func (l *EventLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
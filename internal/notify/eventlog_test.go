@@ -0,0 +1,146 @@
+// -----------------------------------------------------------------------
+// Event Log File - Tests
+// -----------------------------------------------------------------------
+//
+// Validates the NDJSON line format, the active-state filter, that
+// reopening after rotation writes to a new file at the original path, and
+// that failed writes are counted.
+//
+// -----------------------------------------------------------------------
+
+package notify
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/afreidah/health-check-service/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// readEventLog decodes every line of the event log at path.
+func readEventLog(t *testing.T, path string) []eventRecord {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open event log: %v", err)
+	}
+	defer f.Close()
+
+	var records []eventRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r eventRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("Line is not JSON: %q: %v", scanner.Text(), err)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+// TestEventLog_WritesUpAndDown verifies outages and recoveries are written
+// as one JSON line each with the documented fields, and transitions
+// between non-active states are skipped.
+func TestEventLog_WritesUpAndDown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	l, err := NewEventLog(path)
+	if err != nil {
+		t.Fatalf("NewEventLog: %v", err)
+	}
+	defer l.Close()
+
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	l.Notify(Event{Service: "nginx", From: "active", To: "failed", Timestamp: at})
+	l.Notify(Event{Service: "nginx", From: "failed", To: "activating", Timestamp: at.Add(time.Minute)})
+	l.Notify(Event{Service: "nginx", From: "activating", To: "active", Timestamp: at.Add(2 * time.Minute),
+		Downtime: 2 * time.Minute})
+
+	records := readEventLog(t, path)
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 events, got %d: %+v", len(records), records)
+	}
+
+	down := records[0]
+	if down.Event != EventServiceDown || down.Service != "nginx" || down.PrevState != "active" ||
+		down.State != "failed" || !down.Timestamp.Equal(at) || down.Downtime != 0 {
+		t.Errorf("Unexpected down event: %+v", down)
+	}
+
+	up := records[1]
+	if up.Event != EventServiceUp || up.PrevState != "activating" || up.State != "active" || up.Downtime != 120 {
+		t.Errorf("Unexpected up event: %+v", up)
+	}
+}
+
+// TestEventLog_LooksThroughCheckerErrors verifies a D-Bus error on a
+// healthy unit writes no events.
+func TestEventLog_LooksThroughCheckerErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	l, err := NewEventLog(path)
+	if err != nil {
+		t.Fatalf("NewEventLog: %v", err)
+	}
+	defer l.Close()
+
+	at := time.Now()
+	l.Notify(Event{Service: "nginx", From: "active", To: "error", Timestamp: at})
+	l.Notify(Event{Service: "nginx", From: "error", To: "active", Timestamp: at.Add(time.Minute)})
+
+	if records := readEventLog(t, path); len(records) != 0 {
+		t.Errorf("Expected no events for active -> error -> active, got %+v", records)
+	}
+}
+
+// TestEventLog_Reopen verifies events go to a new file at the configured
+// path after the old one has been moved away.
+func TestEventLog_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.ndjson")
+	l, err := NewEventLog(path)
+	if err != nil {
+		t.Fatalf("NewEventLog: %v", err)
+	}
+	defer l.Close()
+
+	l.Notify(Event{Service: "nginx", From: "active", To: "failed", Timestamp: time.Now()})
+
+	rotated := filepath.Join(dir, "events.ndjson.1")
+	if err := os.Rename(path, rotated); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	if err := l.Reopen(); err != nil {
+		t.Fatalf("Reopen: %v", err)
+	}
+
+	l.Notify(Event{Service: "nginx", From: "failed", To: "active", Timestamp: time.Now()})
+
+	if got := readEventLog(t, rotated); len(got) != 1 || got[0].Event != EventServiceDown {
+		t.Errorf("Rotated file: expected the down event, got %+v", got)
+	}
+	if got := readEventLog(t, path); len(got) != 1 || got[0].Event != EventServiceUp {
+		t.Errorf("New file: expected the up event, got %+v", got)
+	}
+}
+
+// TestEventLog_CountsWriteFailures verifies a failed write is counted
+// under the event log's own counter rather than as a webhook failure.
+func TestEventLog_CountsWriteFailures(t *testing.T) {
+	l, err := NewEventLog(filepath.Join(t.TempDir(), "events.ndjson"))
+	if err != nil {
+		t.Fatalf("NewEventLog: %v", err)
+	}
+	l.Close()
+
+	before := testutil.ToFloat64(metrics.EventLogFailures.WithLabelValues("write_error"))
+	l.Notify(Event{Service: "nginx", From: "active", To: "failed", Timestamp: time.Now()})
+
+	if got := testutil.ToFloat64(metrics.EventLogFailures.WithLabelValues("write_error")); got != before+1 {
+		t.Errorf("Expected write_error count %v, got %v", before+1, got)
+	}
+}
@@ -0,0 +1,240 @@
+// -----------------------------------------------------------------------
+// State Change Notifications
+// -----------------------------------------------------------------------
+//
+// Package notify delivers service state transitions to external endpoints.
+// Delivery is asynchronous: the checker enqueues events on a bounded queue
+// and a single worker sends them, so a slow or unreachable endpoint can
+// never block the check loop. When the queue is full, events are dropped
+// and counted as failures. Requests use a short timeout and are not retried.
+//
+// -----------------------------------------------------------------------
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/afreidah/health-check-service/internal/metrics"
+)
+
+var logn = slog.Default().With("component", "notify")
+
+const (
+	// DefaultTimeout bounds each delivery attempt.
+	DefaultTimeout = 5 * time.Second
+
+	// DefaultQueueSize is the number of pending events buffered before new
+	// events are dropped.
+	DefaultQueueSize = 100
+)
+
+// -----------------------------------------------------------------------
+// Types
+// -----------------------------------------------------------------------
+
+// Event describes a change in a monitored service's systemd state.
+type Event struct {
+	Service   string    `json:"service"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Timestamp time.Time `json:"timestamp"`
+
+	// Downtime is the length of the outage a recovery ends, zero for
+	// other transitions. Only the event log reports it.
+	Downtime time.Duration `json:"-"`
+}
+
+// Notifier accepts state change events. Implementations must not block.
+type Notifier interface {
+	Notify(Event)
+}
+
+// Multi fans each event out to several notifiers.
+type Multi []Notifier
+
+// Notify forwards event to every notifier.
+func (m Multi) Notify(event Event) {
+	for _, n := range m {
+		n.Notify(event)
+	}
+}
+
+// -----------------------------------------------------------------------
+// Checker Errors
+// -----------------------------------------------------------------------
+
+// errorState is the state the checker records when it cannot read a unit,
+// such as on a D-Bus error (cache.ErrorSystemdState). It says nothing
+// about the service.
+const errorState = "error"
+
+// knownStates remembers the last state read from systemd per service, so
+// notifiers that report outages can look through the checker's error
+// state. The zero value is ready to use.
+type knownStates struct {
+	mu   sync.Mutex
+	last map[string]string
+}
+
+// resolve returns event with an error From replaced by the last state read
+// before the error. It reports false for events that say nothing about the
+// service: a move into the error state, leaving it for the state the
+// service was already in, or leaving it with no state known before.
+func (k *knownStates) resolve(event Event) (Event, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.last == nil {
+		k.last = make(map[string]string)
+	}
+
+	if event.To == errorState {
+		if event.From != errorState {
+			k.last[event.Service] = event.From
+		}
+		return event, false
+	}
+
+	if event.From == errorState {
+		known, ok := k.last[event.Service]
+		if !ok || known == event.To {
+			k.last[event.Service] = event.To
+			return event, false
+		}
+		event.From = known
+	}
+	k.last[event.Service] = event.To
+	return event, true
+}
+
+// -----------------------------------------------------------------------
+// Bounded Delivery Queue
+// -----------------------------------------------------------------------
+
+// sendFunc performs one delivery attempt. On failure it returns the reason
+// label recorded in the failure metric along with the error.
+type sendFunc func(ctx context.Context, event Event) (reason string, err error)
+
+// queue buffers events and delivers them on a single worker goroutine so
+// delivery latency never reaches the caller.
+type queue struct {
+	notifier string
+	events   chan Event
+	send     sendFunc
+}
+
+func newQueue(notifier string, send sendFunc) *queue {
+	return &queue{
+		notifier: notifier,
+		events:   make(chan Event, DefaultQueueSize),
+		send:     send,
+	}
+}
+
+// start launches the delivery worker. It exits when ctx is cancelled;
+// events still queued at that point are discarded.
+func (q *queue) start(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case event := <-q.events:
+				if reason, err := q.send(ctx, event); err != nil {
+					metrics.WebhookFailures.WithLabelValues(q.notifier, reason).Inc()
+					logn.Warn("notification delivery failed",
+						"notifier", q.notifier,
+						"service", event.Service,
+						"from", event.From,
+						"to", event.To,
+						"err", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// enqueue adds event without blocking. If the queue is full the event is
+// dropped and counted as a failure.
+func (q *queue) enqueue(event Event) {
+	select {
+	case q.events <- event:
+	default:
+		metrics.WebhookFailures.WithLabelValues(q.notifier, "queue_full").Inc()
+		logn.Warn("notification queue full; dropping event",
+			"notifier", q.notifier,
+			"service", event.Service,
+			"from", event.From,
+			"to", event.To)
+	}
+}
+
+// postJSON sends payload as a JSON POST and classifies any failure.
+func postJSON(ctx context.Context, client *http.Client, url string, payload any) (string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "encode_error", fmt.Errorf("encoding payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "request_error", fmt.Errorf("building request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "request_error", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "bad_status", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return "", nil
+}
+
+// -----------------------------------------------------------------------
+// Generic Webhook
+// -----------------------------------------------------------------------
+
+// Webhook POSTs each event as JSON to a fixed URL.
+type Webhook struct {
+	url    string
+	client *http.Client
+	queue  *queue
+}
+
+// NewWebhook creates a webhook notifier for url. Call Start to begin
+// delivering events.
+func NewWebhook(url string) *Webhook {
+	w := &Webhook{
+		url:    url,
+		client: &http.Client{Timeout: DefaultTimeout},
+	}
+	w.queue = newQueue("webhook", w.send)
+	return w
+}
+
+// Start launches the delivery worker; it exits when ctx is cancelled.
+func (w *Webhook) Start(ctx context.Context) {
+	w.queue.start(ctx)
+}
+
+// Notify enqueues event for delivery without blocking.
+func (w *Webhook) Notify(event Event) {
+	w.queue.enqueue(event)
+}
+
+// send posts the event itself as the JSON body.
+func (w *Webhook) send(ctx context.Context, event Event) (string, error) {
+	return postJSON(ctx, w.client, w.url, event)
+}
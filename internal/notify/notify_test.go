@@ -0,0 +1,98 @@
+// -----------------------------------------------------------------------
+// State Change Notifications - Tests
+// -----------------------------------------------------------------------
+//
+// Validates webhook payload format and failure accounting. Notifications are
+// fire-and-forget, so the failure metric is the only signal that events are
+// being lost; these tests make sure each failure path is counted.
+//
+// -----------------------------------------------------------------------
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/afreidah/health-check-service/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestWebhook_DeliversJSON verifies the POST body carries the documented
+// {service, from, to, timestamp} fields.
+func TestWebhook_DeliversJSON(t *testing.T) {
+	received := make(chan Event, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Expected Content-Type application/json, got %q", ct)
+		}
+		var e Event
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			t.Errorf("Failed to decode body: %v", err)
+		}
+		received <- e
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := NewWebhook(srv.URL)
+	w.Start(ctx)
+	w.Notify(Event{Service: "nginx", From: "active", To: "failed", Timestamp: time.Now()})
+
+	select {
+	case e := <-received:
+		if e.Service != "nginx" || e.From != "active" || e.To != "failed" {
+			t.Errorf("Unexpected event: %+v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Webhook was not delivered")
+	}
+}
+
+// TestWebhook_BadStatusCounted verifies non-2xx responses are recorded as
+// failures since the event is not retried.
+func TestWebhook_BadStatusCounted(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	before := testutil.ToFloat64(metrics.WebhookFailures.WithLabelValues("webhook", "bad_status"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := NewWebhook(srv.URL)
+	w.Start(ctx)
+	w.Notify(Event{Service: "nginx"})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for testutil.ToFloat64(metrics.WebhookFailures.WithLabelValues("webhook", "bad_status")) == before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if got := testutil.ToFloat64(metrics.WebhookFailures.WithLabelValues("webhook", "bad_status")); got != before+1 {
+		t.Errorf("Expected bad_status failures %v, got %v", before+1, got)
+	}
+}
+
+// TestWebhook_QueueFullDrops verifies Notify never blocks: with no worker
+// running, events beyond the queue size are dropped and counted.
+func TestWebhook_QueueFullDrops(t *testing.T) {
+	before := testutil.ToFloat64(metrics.WebhookFailures.WithLabelValues("webhook", "queue_full"))
+
+	w := NewWebhook("http://127.0.0.1:0")
+	for i := 0; i < DefaultQueueSize+3; i++ {
+		w.Notify(Event{Service: "nginx"})
+	}
+
+	if got := testutil.ToFloat64(metrics.WebhookFailures.WithLabelValues("webhook", "queue_full")); got != before+3 {
+		t.Errorf("Expected queue_full failures %v, got %v", before+3, got)
+	}
+}
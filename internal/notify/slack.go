@@ -0,0 +1,96 @@
+// -----------------------------------------------------------------------
+// Slack Notifications
+// -----------------------------------------------------------------------
+//
+// Slack formats transitions as incoming-webhook messages with a colored
+// attachment: red when a service leaves the active state, green when it
+// recovers. Only transitions into or out of "active" are sent; churn
+// between non-active states (e.g. failed -> activating) is suppressed to
+// keep channels quiet. The checker's "error" state, recorded when it
+// cannot reach systemd, is not an outage: moves into it are dropped, and a
+// move out of it is reported from the state read before the error.
+//
+// -----------------------------------------------------------------------
+
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	slackColorDown     = "#d50200"
+	slackColorRecovery = "#2eb886"
+
+	// activeState is the systemd ActiveState considered healthy.
+	activeState = "active"
+)
+
+// Slack posts formatted transition messages to a Slack incoming webhook.
+type Slack struct {
+	url    string
+	client *http.Client
+	queue  *queue
+	known  knownStates
+}
+
+// slackMessage is the incoming-webhook payload.
+type slackMessage struct {
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+type slackAttachment struct {
+	Color    string `json:"color"`
+	Text     string `json:"text"`
+	Fallback string `json:"fallback"`
+}
+
+// NewSlack creates a Slack notifier for an incoming webhook URL. Call Start
+// to begin delivering events.
+func NewSlack(url string) *Slack {
+	s := &Slack{
+		url:    url,
+		client: &http.Client{Timeout: DefaultTimeout},
+	}
+	s.queue = newQueue("slack", s.send)
+	return s
+}
+
+// Start launches the delivery worker; it exits when ctx is cancelled.
+func (s *Slack) Start(ctx context.Context) {
+	s.queue.start(ctx)
+}
+
+// Notify enqueues event if it enters or leaves the active state, looking
+// through checker errors.
+func (s *Slack) Notify(event Event) {
+	event, ok := s.known.resolve(event)
+	if !ok || (event.From != activeState && event.To != activeState) {
+		return
+	}
+	s.queue.enqueue(event)
+}
+
+// send posts the formatted message for event.
+func (s *Slack) send(ctx context.Context, event Event) (string, error) {
+	return postJSON(ctx, s.client, s.url, formatSlackMessage(event))
+}
+
+// formatSlackMessage renders event as a single colored attachment.
+func formatSlackMessage(event Event) slackMessage {
+	icon, color := "⚠️", slackColorDown
+	if event.To == activeState {
+		icon, color = "✅", slackColorRecovery
+	}
+
+	text := fmt.Sprintf("%s %s went from %s → %s at %s",
+		icon, event.Service, event.From, event.To,
+		event.Timestamp.UTC().Format(time.RFC3339))
+
+	return slackMessage{
+		Attachments: []slackAttachment{{Color: color, Text: text, Fallback: text}},
+	}
+}
@@ -0,0 +1,74 @@
+// -----------------------------------------------------------------------
+// Slack Notifications - Tests
+// -----------------------------------------------------------------------
+//
+// Validates Slack message formatting and the active-state filter that keeps
+// channels from being flooded by transitions between non-active states or
+// through the checker's error state.
+//
+// -----------------------------------------------------------------------
+
+package notify
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestFormatSlackMessage verifies outages are red and recoveries green, and
+// that the text names the service and both states.
+func TestFormatSlackMessage(t *testing.T) {
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	down := formatSlackMessage(Event{Service: "nginx", From: "active", To: "failed", Timestamp: at})
+	if got := down.Attachments[0].Color; got != slackColorDown {
+		t.Errorf("Down color: expected %s, got %s", slackColorDown, got)
+	}
+	if text := down.Attachments[0].Text; !strings.Contains(text, "nginx went from active → failed at 2025-03-01T12:00:00Z") {
+		t.Errorf("Unexpected text: %q", text)
+	}
+
+	up := formatSlackMessage(Event{Service: "nginx", From: "failed", To: "active", Timestamp: at})
+	if got := up.Attachments[0].Color; got != slackColorRecovery {
+		t.Errorf("Recovery color: expected %s, got %s", slackColorRecovery, got)
+	}
+}
+
+// TestSlack_FiltersNonActiveTransitions verifies only transitions into or
+// out of "active" are queued.
+func TestSlack_FiltersNonActiveTransitions(t *testing.T) {
+	s := NewSlack("http://127.0.0.1:0")
+
+	s.Notify(Event{Service: "nginx", From: "failed", To: "activating"})
+	s.Notify(Event{Service: "nginx", From: "activating", To: "active"})
+	s.Notify(Event{Service: "nginx", From: "active", To: "deactivating"})
+
+	if got := len(s.queue.events); got != 2 {
+		t.Errorf("Expected 2 queued events, got %d", got)
+	}
+}
+
+// TestSlack_LooksThroughCheckerErrors verifies a D-Bus error on a healthy
+// unit posts nothing, while an outage interrupted by one is reported from
+// the state read before the error.
+func TestSlack_LooksThroughCheckerErrors(t *testing.T) {
+	s := NewSlack("http://127.0.0.1:0")
+
+	s.Notify(Event{Service: "nginx", From: "active", To: "error"})
+	s.Notify(Event{Service: "nginx", From: "error", To: "active"})
+	if got := len(s.queue.events); got != 0 {
+		t.Fatalf("Expected no events for active -> error -> active, got %d", got)
+	}
+
+	s.Notify(Event{Service: "nginx", From: "active", To: "failed"})
+	s.Notify(Event{Service: "nginx", From: "failed", To: "error"})
+	s.Notify(Event{Service: "nginx", From: "error", To: "active"})
+	if got := len(s.queue.events); got != 2 {
+		t.Fatalf("Expected the outage and its recovery, got %d events", got)
+	}
+	<-s.queue.events
+	if recovery := <-s.queue.events; recovery.From != "failed" || recovery.To != "active" {
+		t.Errorf("Expected recovery from failed, got %s -> %s", recovery.From, recovery.To)
+	}
+}
@@ -4,7 +4,11 @@
 //
 // Package ratelimit provides per-IP rate limiting using token bucket algorithm.
 // Different rate limits are applied to different endpoints. Stale IP entries are
-// cleaned up periodically to prevent memory leaks.
+// cleaned up periodically to prevent memory leaks. An optional global bucket
+// caps the total rate across all IPs, so many distinct clients cannot
+// overwhelm an endpoint by each staying under the per-IP limit. An optional
+// cap on tracked IPs bounds memory under a flood of spoofed addresses:
+// once reached, new IPs share a single overflow bucket.
 //
 // -----------------------------------------------------------------------
 
@@ -13,11 +17,13 @@ package ratelimit
 import (
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/afreidah/health-check-service/internal/metrics"
 	"golang.org/x/time/rate"
 )
 
@@ -41,8 +47,51 @@ type Manager struct {
 	burstSize        int     // max burst tokens
 	cleanupInterval  time.Duration
 	cleanupIdleAfter time.Duration
+
+	// endpoint labels the tracked-IPs gauge.
+	endpoint string
+
+	// global caps the combined rate of all IPs; nil when disabled.
+	// Set once at construction, so it is read without the lock.
+	global *rate.Limiter
+
+	// maxTrackedIPs caps the number of per-IP limiters (0 = unlimited).
+	// IPs seen while at the cap share overflow; capped records whether
+	// the cap was hit since the last cleanup, so it is logged once.
+	maxTrackedIPs int
+	overflow      *rate.Limiter
+	capped        bool
 }
 
+// Options tunes a Manager beyond its per-IP limit. Zero values select the
+// defaults.
+type Options struct {
+	// Endpoint labels health_check_ratelimit_tracked_ips (default "default").
+	Endpoint string
+
+	// GlobalRate and GlobalBurst configure a bucket shared by all IPs.
+	// A non-positive GlobalRate disables it.
+	GlobalRate  float64
+	GlobalBurst int
+
+	// CleanupInterval is how often idle IP entries are swept (default 5m);
+	// CleanupIdleAfter is how long an IP must be idle to be removed
+	// (default 10m). Lower both to bound memory under a flood of IPs.
+	CleanupInterval  time.Duration
+	CleanupIdleAfter time.Duration
+
+	// MaxTrackedIPs caps how many IPs get their own bucket (0 =
+	// unlimited). Further IPs share one overflow bucket with the per-IP
+	// rate until cleanup frees entries.
+	MaxTrackedIPs int
+}
+
+// Cleanup defaults, used when the corresponding Options fields are zero.
+const (
+	DefaultCleanupInterval  = 5 * time.Minute
+	DefaultCleanupIdleAfter = 10 * time.Minute
+)
+
 // -----------------------------------------------------------------------
 // Constructor
 // -----------------------------------------------------------------------
@@ -55,12 +104,46 @@ type Manager struct {
 //
 // Example: New(50, 100) = 50 requests/sec, burst of 100
 func New(requestsPerSec float64, burstSize int) *Manager {
+	return NewWithOptions(requestsPerSec, burstSize, Options{})
+}
+
+// NewWithGlobal creates a rate limit manager with a per-IP limit and a
+// global limit shared by all IPs. A non-positive globalRate disables the
+// global bucket, making this equivalent to New.
+//
+// Example: NewWithGlobal(10, 20, 500, 1000) = 10 req/sec per IP (burst 20),
+// and at most 500 req/sec in total (burst 1000)
+func NewWithGlobal(perIPRate float64, perIPBurst int, globalRate float64, globalBurst int) *Manager {
+	return NewWithOptions(perIPRate, perIPBurst, Options{GlobalRate: globalRate, GlobalBurst: globalBurst})
+}
+
+// NewWithOptions creates a rate limit manager with a per-IP limit and the
+// given tuning options.
+func NewWithOptions(perIPRate float64, perIPBurst int, opts Options) *Manager {
+	if opts.Endpoint == "" {
+		opts.Endpoint = "default"
+	}
+	if opts.CleanupInterval <= 0 {
+		opts.CleanupInterval = DefaultCleanupInterval
+	}
+	if opts.CleanupIdleAfter <= 0 {
+		opts.CleanupIdleAfter = DefaultCleanupIdleAfter
+	}
+
 	m := &Manager{
 		limiters:         make(map[string]*ipLimiter),
-		requestsPerSec:   requestsPerSec,
-		burstSize:        burstSize,
-		cleanupInterval:  5 * time.Minute,
-		cleanupIdleAfter: 10 * time.Minute,
+		requestsPerSec:   perIPRate,
+		burstSize:        perIPBurst,
+		cleanupInterval:  opts.CleanupInterval,
+		cleanupIdleAfter: opts.CleanupIdleAfter,
+		endpoint:         opts.Endpoint,
+		maxTrackedIPs:    opts.MaxTrackedIPs,
+	}
+	if opts.MaxTrackedIPs > 0 {
+		m.overflow = rate.NewLimiter(rate.Limit(perIPRate), perIPBurst)
+	}
+	if opts.GlobalRate > 0 {
+		m.global = rate.NewLimiter(rate.Limit(opts.GlobalRate), opts.GlobalBurst)
 	}
 
 	// Start background cleanup goroutine
@@ -75,9 +158,37 @@ func New(requestsPerSec float64, burstSize int) *Manager {
 
 // Allow checks if a request from the given IP is allowed.
 // Returns true if the request is within rate limit, false otherwise.
+// The global token is reserved first and handed back if the IP's own limit
+// rejects the request, so a client hammering past its limit cannot drain
+// the global bucket for everyone else. When the global bucket is exhausted
+// the request is rejected without spending the IP's own tokens.
 func (m *Manager) Allow(ip string) bool {
+	// Cancelling only returns tokens for a reservation not yet due, so
+	// the reservation and any cancellation share one timestamp
+	now := time.Now()
+	var global *rate.Reservation
+	if m.global != nil {
+		global = m.global.ReserveN(now, 1)
+		if !global.OK() {
+			return false
+		}
+		if global.DelayFrom(now) > 0 {
+			global.CancelAt(now)
+			return false
+		}
+	}
+
 	limiter := m.getLimiter(ip)
-	return limiter.Allow()
+	if limiter == m.overflow {
+		metrics.RateLimitOverflow.WithLabelValues(m.endpoint).Inc()
+	}
+	if !limiter.AllowN(now, 1) {
+		if global != nil {
+			global.CancelAt(now)
+		}
+		return false
+	}
+	return true
 }
 
 // Reserve attempts to reserve a token and returns how long to wait.
@@ -96,8 +207,12 @@ func (m *Manager) Reserve(ip string) time.Duration {
 // -----------------------------------------------------------------------
 
 // getLimiter returns the limiter for the given IP, creating one if it doesn't exist.
-// Also updates the lastSeen timestamp for cleanup tracking.
+// Also updates the lastSeen timestamp for cleanup tracking. The IP is
+// normalized first so equivalent spellings share one bucket. At the
+// tracked-IP cap, an unknown IP gets the shared overflow limiter instead.
 func (m *Manager) getLimiter(ip string) *rate.Limiter {
+	ip = NormalizeIP(ip)
+
 	m.mu.RLock()
 	if limiter, exists := m.limiters[ip]; exists {
 		limiter.lastSeen = time.Now()
@@ -106,24 +221,43 @@ func (m *Manager) getLimiter(ip string) *rate.Limiter {
 	}
 	m.mu.RUnlock()
 
-	// Create new limiter
+	// Create and store the limiter under the write lock so it picks up the
+	// current rate even if SetLimits runs concurrently
+	m.mu.Lock()
+	if limiter, exists := m.limiters[ip]; exists {
+		limiter.lastSeen = time.Now()
+		m.mu.Unlock()
+		return limiter.limiter
+	}
+	if m.maxTrackedIPs > 0 && len(m.limiters) >= m.maxTrackedIPs {
+		warn := !m.capped
+		m.capped = true
+		m.mu.Unlock()
+
+		if warn {
+			logr.Warn("rate limiter tracked-IP cap reached; new IPs share an overflow limit",
+				"endpoint", m.endpoint,
+				"max_tracked_ips", m.maxTrackedIPs,
+			)
+		}
+		return m.overflow
+	}
 	newLimiter := rate.NewLimiter(
 		rate.Limit(m.requestsPerSec),
 		m.burstSize,
 	)
-
-	// Store it
-	m.mu.Lock()
 	m.limiters[ip] = &ipLimiter{
 		limiter:  newLimiter,
 		lastSeen: time.Now(),
 	}
+	requestsPerSec, burstSize := m.requestsPerSec, m.burstSize
+	metrics.RateLimitTrackedIPs.WithLabelValues(m.endpoint).Set(float64(len(m.limiters)))
 	m.mu.Unlock()
 
 	logr.Debug("created limiter for IP",
 		"ip", ip,
-		"rate", fmt.Sprintf("%.0f/sec", m.requestsPerSec),
-		"burst", m.burstSize,
+		"rate", fmt.Sprintf("%.0f/sec", requestsPerSec),
+		"burst", burstSize,
 	)
 
 	return newLimiter
@@ -137,9 +271,29 @@ func (m *Manager) GetTokens(ip string) float64 {
 
 // GetRate returns the configured requests per second rate.
 func (m *Manager) GetRate() float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return m.requestsPerSec
 }
 
+// SetLimits changes the rate and burst size at runtime. Existing per-IP
+// limiters are updated in place so clients keep their current token count.
+func (m *Manager) SetLimits(requestsPerSec float64, burstSize int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requestsPerSec = requestsPerSec
+	m.burstSize = burstSize
+	if m.overflow != nil {
+		m.overflow.SetLimit(rate.Limit(requestsPerSec))
+		m.overflow.SetBurst(burstSize)
+	}
+	for _, entry := range m.limiters {
+		entry.limiter.SetLimit(rate.Limit(requestsPerSec))
+		entry.limiter.SetBurst(burstSize)
+	}
+}
+
 // -----------------------------------------------------------------------
 // Cleanup
 // -----------------------------------------------------------------------
@@ -169,6 +323,12 @@ func (m *Manager) cleanup() {
 			removed++
 		}
 	}
+	metrics.RateLimitTrackedIPs.WithLabelValues(m.endpoint).Set(float64(len(m.limiters)))
+
+	// Warn again the next time the cap is reached
+	if m.capped && len(m.limiters) < m.maxTrackedIPs {
+		m.capped = false
+	}
 
 	if removed > 0 {
 		logr.Debug("cleanup: removed stale IP entries",
@@ -183,11 +343,21 @@ func (m *Manager) Stats() map[string]interface{} {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	return map[string]interface{}{
+	stats := map[string]interface{}{
 		"active_ips": len(m.limiters),
 		"rate":       m.requestsPerSec,
 		"burst":      m.burstSize,
 	}
+	if m.maxTrackedIPs > 0 {
+		stats["max_tracked_ips"] = m.maxTrackedIPs
+		stats["overflow_tokens"] = m.overflow.Tokens()
+	}
+	if m.global != nil {
+		stats["global_rate"] = float64(m.global.Limit())
+		stats["global_burst"] = m.global.Burst()
+		stats["global_tokens"] = m.global.Tokens()
+	}
+	return stats
 }
 
 // -----------------------------------------------------------------------
@@ -195,7 +365,8 @@ func (m *Manager) Stats() map[string]interface{} {
 // -----------------------------------------------------------------------
 
 // Middleware returns an HTTP middleware that applies rate limiting per IP.
-// Returns 429 Too Many Requests if limit exceeded.
+// Returns 429 Too Many Requests if limit exceeded. Rejections are counted
+// under the "default" endpoint label.
 func (m *Manager) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ip := GetIP(r)
@@ -211,6 +382,7 @@ func (m *Manager) Middleware(next http.Handler) http.Handler {
 				"method", r.Method,
 				"path", r.URL.Path,
 			)
+			metrics.RateLimitRejected.WithLabelValues("default").Inc()
 
 			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
 			return
@@ -242,6 +414,7 @@ func (m *Manager) EndpointMiddleware(endpoint string, next http.Handler) http.Ha
 				"method", r.Method,
 				"path", r.URL.Path,
 			)
+			metrics.RateLimitRejected.WithLabelValues(endpoint).Inc()
 
 			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
 			return
@@ -259,13 +432,110 @@ func (m *Manager) EndpointMiddleware(endpoint string, next http.Handler) http.Ha
 // IP Extraction
 // -----------------------------------------------------------------------
 
+// Allowlist is a set of networks whose clients bypass rate limiting.
+type Allowlist []*net.IPNet
+
+// ParseAllowlist parses IPv4 and IPv6 CIDRs such as "10.0.0.0/8" or
+// "fd00::/8". Returns an error naming the first invalid entry.
+func ParseAllowlist(cidrs []string) (Allowlist, error) {
+	nets := make(Allowlist, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// Contains reports whether ip, as returned by GetIP (with or without a
+// port), falls within any allowlisted network.
+func (a Allowlist) Contains(ip string) bool {
+	if len(a) == 0 {
+		return false
+	}
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range a {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// trustedProxies are the networks allowed to report the client IP via
+// X-Forwarded-For or X-Real-IP. Empty means headers are trusted from any
+// peer, which is the legacy behavior.
+var trustedProxies Allowlist
+
+// untrustedHeaderWarning ensures the unconditional-trust warning is logged
+// once per process rather than per request.
+var untrustedHeaderWarning sync.Once
+
+// ConfigureTrustedProxies installs the proxies whose forwarding headers are
+// honored. Must be called before the HTTP server starts since the list is
+// read without locking.
+func ConfigureTrustedProxies(proxies Allowlist) {
+	trustedProxies = proxies
+}
+
 // GetIP extracts the real client IP from the request.
 // Checks X-Forwarded-For header (when behind proxy) first, then X-Real-IP,
-// then falls back to RemoteAddr.
+// then falls back to the host part of RemoteAddr.
+//
+// With trusted proxies configured, the headers are only honored when the
+// direct peer is a trusted proxy, and X-Forwarded-For is read right to left
+// so a client cannot spoof its IP by prepending entries: the first address
+// that is not itself a trusted proxy is the client.
 func GetIP(r *http.Request) string {
+	return NormalizeIP(clientAddr(r))
+}
+
+// TrustedIP returns the client IP to base access decisions on, such as the
+// bypass allowlist. It is GetIP when trusted proxies are configured, and
+// otherwise the connection peer: without them the forwarding headers come
+// from whoever sent the request, and any client could claim an allowlisted
+// address.
+func TrustedIP(r *http.Request) string {
+	if len(trustedProxies) > 0 {
+		return GetIP(r)
+	}
+	return NormalizeIP(remoteHost(r))
+}
+
+// clientAddr picks the client address from the forwarding headers or
+// RemoteAddr, as described on GetIP.
+func clientAddr(r *http.Request) string {
+	if len(trustedProxies) > 0 {
+		if !trustedProxies.Contains(r.RemoteAddr) {
+			return remoteHost(r)
+		}
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			hops := strings.Split(xff, ",")
+			for i := len(hops) - 1; i >= 0; i-- {
+				hop := strings.TrimSpace(hops[i])
+				if i == 0 || !trustedProxies.Contains(hop) {
+					return hop
+				}
+			}
+		}
+		if xri := r.Header.Get("X-Real-IP"); xri != "" {
+			return xri
+		}
+		return remoteHost(r)
+	}
+
 	// X-Forwarded-For can contain multiple IPs (client, proxy1, proxy2, ...)
 	// We want the first one (client)
 	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		warnUntrustedHeaders()
 		ips := strings.Split(xff, ",")
 		if len(ips) > 0 {
 			return strings.TrimSpace(ips[0])
@@ -274,9 +544,49 @@ func GetIP(r *http.Request) string {
 
 	// X-Real-IP set by some proxies
 	if xri := r.Header.Get("X-Real-IP"); xri != "" {
+		warnUntrustedHeaders()
 		return xri
 	}
 
 	// Direct connection
-	return r.RemoteAddr
+	return remoteHost(r)
+}
+
+// NormalizeIP returns the canonical form of an IP address, so spellings
+// like "0:0:0:0:0:0:0:1", "[::1]" and "::1" (or "::ffff:10.0.0.1" and
+// "10.0.0.1") map to the same rate-limit bucket. A trailing port is
+// dropped. Values that are not IP addresses are returned trimmed but
+// otherwise unchanged.
+func NormalizeIP(ip string) string {
+	ip = strings.TrimSpace(ip)
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	ip = strings.TrimSuffix(strings.TrimPrefix(ip, "["), "]")
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+	return parsed.String()
+}
+
+// remoteHost returns RemoteAddr without the port, so every connection from
+// a client shares one bucket regardless of its ephemeral source port.
+// RemoteAddr values without a port are returned unchanged.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// warnUntrustedHeaders logs once that forwarding headers are trusted from
+// any peer, since that lets clients pick their own rate-limit bucket.
+func warnUntrustedHeaders() {
+	untrustedHeaderWarning.Do(func() {
+		logr.Warn("trusting X-Forwarded-For/X-Real-IP from any client; " +
+			"set trusted_proxies to prevent IP spoofing")
+	})
 }
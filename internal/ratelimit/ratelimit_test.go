@@ -14,11 +14,15 @@
 package ratelimit
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/afreidah/health-check-service/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 // -----------------------------------------------------------------------
@@ -215,6 +219,28 @@ func TestMiddleware_RejectsExceeded(t *testing.T) {
 	}
 }
 
+// TestEndpointMiddleware_CountsRejections verifies each 429 increments the
+// rejection counter for the endpoint. Without it, throttled monitoring tools
+// are invisible to alerting.
+func TestEndpointMiddleware_CountsRejections(t *testing.T) {
+	m := New(0, 0)
+
+	handler := m.EndpointMiddleware("test_endpoint", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	before := testutil.ToFloat64(metrics.RateLimitRejected.WithLabelValues("test_endpoint"))
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.RemoteAddr = "192.168.1.1:12345"
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	after := testutil.ToFloat64(metrics.RateLimitRejected.WithLabelValues("test_endpoint"))
+	if after != before+1 {
+		t.Errorf("Expected rejection counter to increase by 1: before=%f, after=%f", before, after)
+	}
+}
+
 // TestMiddleware_SetsHeaders verifies rate limit information headers are
 // included in responses. These headers allow clients to implement backoff
 // and understand rate limit policies.
@@ -247,14 +273,43 @@ func TestMiddleware_SetsHeaders(t *testing.T) {
 
 // TestGetIP_DirectConnection verifies IP extraction from RemoteAddr when
 // no proxy headers are present. This is the fallback case for direct
-// connections without load balancers.
+// connections without load balancers. The port is stripped so each source
+// port does not get its own bucket.
 func TestGetIP_DirectConnection(t *testing.T) {
-	req := httptest.NewRequest("GET", "/", nil)
-	req.RemoteAddr = "192.168.1.1:12345"
+	tests := []struct {
+		remoteAddr string
+		want       string
+	}{
+		{"192.168.1.1:12345", "192.168.1.1"},
+		{"[2001:db8::1]:443", "2001:db8::1"},
+		{"192.168.1.1", "192.168.1.1"},
+	}
 
-	ip := GetIP(req)
-	if ip != "192.168.1.1:12345" {
-		t.Errorf("Expected 192.168.1.1:12345, got %s", ip)
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tt.remoteAddr
+
+		if ip := GetIP(req); ip != tt.want {
+			t.Errorf("RemoteAddr %s: expected %s, got %s", tt.remoteAddr, tt.want, ip)
+		}
+	}
+}
+
+// TestGetIP_SourcePortsShareBucket verifies connections from different
+// ephemeral ports of one client are limited together.
+func TestGetIP_SourcePortsShareBucket(t *testing.T) {
+	m := New(1, 1)
+
+	first := httptest.NewRequest("GET", "/", nil)
+	first.RemoteAddr = "192.168.1.1:40000"
+	second := httptest.NewRequest("GET", "/", nil)
+	second.RemoteAddr = "192.168.1.1:40001"
+
+	if !m.Allow(GetIP(first)) {
+		t.Fatal("First request should be allowed")
+	}
+	if m.Allow(GetIP(second)) {
+		t.Error("Second request from a new source port should share the exhausted bucket")
 	}
 }
 
@@ -300,6 +355,134 @@ func TestGetIP_Precedence(t *testing.T) {
 	}
 }
 
+// TestNormalizeIP verifies equivalent address spellings collapse to one
+// canonical key.
+func TestNormalizeIP(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"::1", "::1"},
+		{"0:0:0:0:0:0:0:1", "::1"},
+		{"[::1]", "::1"},
+		{"[0:0:0:0:0:0:0:1]:8080", "::1"},
+		{"2001:DB8:0:0:0:0:0:1", "2001:db8::1"},
+		{"::ffff:10.0.0.1", "10.0.0.1"},
+		{" 10.0.0.1:443", "10.0.0.1"},
+		{"proxy.example.com", "proxy.example.com"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeIP(tt.in); got != tt.want {
+			t.Errorf("NormalizeIP(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestAllow_EquivalentIPv6FormsShareBucket verifies a client cannot evade
+// its limit by alternating IPv6 representations.
+func TestAllow_EquivalentIPv6FormsShareBucket(t *testing.T) {
+	m := New(1, 1)
+
+	if !m.Allow("::1") {
+		t.Fatal("First request should be allowed")
+	}
+	for _, form := range []string{"0:0:0:0:0:0:0:1", "[::1]", "0000::0001"} {
+		if m.Allow(form) {
+			t.Errorf("%s should share the exhausted ::1 bucket", form)
+		}
+	}
+	if n := m.Stats()["active_ips"]; n != 1 {
+		t.Errorf("Expected 1 tracked IP, got %v", n)
+	}
+}
+
+// useTrustedProxies installs trusted proxies for the duration of a test.
+func useTrustedProxies(t *testing.T, cidrs ...string) {
+	t.Helper()
+	proxies, err := ParseAllowlist(cidrs)
+	if err != nil {
+		t.Fatalf("ParseAllowlist: %v", err)
+	}
+	ConfigureTrustedProxies(proxies)
+	t.Cleanup(func() { ConfigureTrustedProxies(nil) })
+}
+
+// TestGetIP_UntrustedPeerIgnoresHeaders verifies a client outside the
+// trusted proxy ranges cannot choose its IP via forwarding headers.
+func TestGetIP_UntrustedPeerIgnoresHeaders(t *testing.T) {
+	useTrustedProxies(t, "10.0.0.0/8")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "203.0.113.7:5555"
+	req.Header.Set("X-Forwarded-For", "192.168.1.100")
+	req.Header.Set("X-Real-IP", "192.168.1.200")
+
+	if ip := GetIP(req); ip != "203.0.113.7" {
+		t.Errorf("Expected RemoteAddr for untrusted peer, got %s", ip)
+	}
+}
+
+// TestGetIP_TrustedProxyChain verifies X-Forwarded-For is read right to
+// left past trusted proxies, so an entry prepended by the client is ignored.
+func TestGetIP_TrustedProxyChain(t *testing.T) {
+	useTrustedProxies(t, "10.0.0.0/8", "fd00::/8")
+
+	tests := []struct {
+		name string
+		xff  string
+		want string
+	}{
+		{"single hop", "198.51.100.4", "198.51.100.4"},
+		{"spoofed prefix", "1.2.3.4, 198.51.100.4", "198.51.100.4"},
+		{"inner proxies skipped", "198.51.100.4, 10.0.0.2, fd00::5", "198.51.100.4"},
+		{"all trusted", "10.0.0.3, 10.0.0.2", "10.0.0.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = "10.0.0.1:443"
+			req.Header.Set("X-Forwarded-For", tt.xff)
+
+			if ip := GetIP(req); ip != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, ip)
+			}
+		})
+	}
+}
+
+// TestGetIP_TrustedProxyXRealIP verifies X-Real-IP is honored from a
+// trusted proxy that does not set X-Forwarded-For.
+func TestGetIP_TrustedProxyXRealIP(t *testing.T) {
+	useTrustedProxies(t, "10.0.0.0/8")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.1:443"
+	req.Header.Set("X-Real-IP", "198.51.100.9")
+
+	if ip := GetIP(req); ip != "198.51.100.9" {
+		t.Errorf("Expected X-Real-IP from trusted proxy, got %s", ip)
+	}
+}
+
+// TestTrustedIP verifies forwarding headers only name the client for
+// access decisions when they come from a trusted proxy.
+func TestTrustedIP(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "203.0.113.7:5555"
+	req.Header.Set("X-Forwarded-For", "10.0.0.5")
+
+	if ip := TrustedIP(req); ip != "203.0.113.7" {
+		t.Errorf("Without trusted proxies: expected the peer, got %s", ip)
+	}
+
+	useTrustedProxies(t, "203.0.113.0/24")
+	if ip := TrustedIP(req); ip != "10.0.0.5" {
+		t.Errorf("From a trusted proxy: expected the forwarded client, got %s", ip)
+	}
+}
+
 // -----------------------------------------------------------------------
 // Concurrency Tests
 // -----------------------------------------------------------------------
@@ -409,6 +592,70 @@ func TestCleanup_RemovesStaleEntries(t *testing.T) {
 	}
 }
 
+// TestNewWithOptions_CleanupTuning verifies cleanup settings come from the
+// options, with zero values falling back to the defaults.
+func TestNewWithOptions_CleanupTuning(t *testing.T) {
+	m := NewWithOptions(10, 20, Options{CleanupInterval: time.Second, CleanupIdleAfter: 2 * time.Second})
+	if m.cleanupInterval != time.Second || m.cleanupIdleAfter != 2*time.Second {
+		t.Errorf("Expected 1s/2s cleanup, got %v/%v", m.cleanupInterval, m.cleanupIdleAfter)
+	}
+
+	m = New(10, 20)
+	if m.cleanupInterval != DefaultCleanupInterval || m.cleanupIdleAfter != DefaultCleanupIdleAfter {
+		t.Errorf("Expected default cleanup, got %v/%v", m.cleanupInterval, m.cleanupIdleAfter)
+	}
+}
+
+// TestTrackedIPsGauge verifies the tracked-IPs gauge rises as new IPs are
+// seen and falls after cleanup evicts them.
+func TestTrackedIPsGauge(t *testing.T) {
+	m := NewWithOptions(10, 20, Options{Endpoint: "gauge_test", CleanupIdleAfter: 10 * time.Millisecond})
+	gauge := metrics.RateLimitTrackedIPs.WithLabelValues("gauge_test")
+
+	m.Allow("192.168.1.1")
+	m.Allow("192.168.1.2")
+	if got := testutil.ToFloat64(gauge); got != 2 {
+		t.Errorf("Expected 2 tracked IPs, got %v", got)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	m.cleanup()
+	if got := testutil.ToFloat64(gauge); got != 0 {
+		t.Errorf("Expected 0 tracked IPs after cleanup, got %v", got)
+	}
+}
+
+// TestMaxTrackedIPs_OverflowShared verifies IPs beyond the cap are not
+// tracked and share one overflow bucket, counted in the overflow metric,
+// while already tracked IPs keep their own bucket.
+func TestMaxTrackedIPs_OverflowShared(t *testing.T) {
+	m := NewWithOptions(1, 1, Options{Endpoint: "overflow_test", MaxTrackedIPs: 2})
+	overflow := metrics.RateLimitOverflow.WithLabelValues("overflow_test")
+	before := testutil.ToFloat64(overflow)
+
+	// GetTokens starts tracking an IP without spending its tokens
+	m.GetTokens("192.168.1.1")
+	m.GetTokens("192.168.1.2")
+
+	if !m.Allow("192.168.1.3") {
+		t.Error("First overflow request should be allowed")
+	}
+	if m.Allow("192.168.1.4") {
+		t.Error("Second overflow request should share the exhausted overflow bucket")
+	}
+	if got := m.Stats()["active_ips"]; got != 2 {
+		t.Errorf("Expected 2 tracked IPs at the cap, got %v", got)
+	}
+	if got := testutil.ToFloat64(overflow) - before; got != 2 {
+		t.Errorf("Expected 2 overflow requests counted, got %v", got)
+	}
+
+	// A tracked IP is unaffected by the exhausted overflow bucket
+	if !m.Allow("192.168.1.1") {
+		t.Error("Tracked IP should keep its own bucket")
+	}
+}
+
 // -----------------------------------------------------------------------
 // Stats Tests
 // -----------------------------------------------------------------------
@@ -442,3 +689,157 @@ func TestStats_ReturnsInfo(t *testing.T) {
 		t.Error("Missing 'rate' in stats")
 	}
 }
+
+// -----------------------------------------------------------------------
+// Global Limit Tests
+// -----------------------------------------------------------------------
+
+// TestGlobalLimit_RejectsAcrossDistinctIPs verifies the global bucket caps
+// total traffic even when every IP is within its own limit, which is the
+// botnet case per-IP limiting cannot stop.
+func TestGlobalLimit_RejectsAcrossDistinctIPs(t *testing.T) {
+	m := NewWithGlobal(100, 100, 1, 3)
+
+	for i := 0; i < 3; i++ {
+		if !m.Allow(fmt.Sprintf("10.0.0.%d", i)) {
+			t.Fatalf("Request %d should be allowed within global burst", i)
+		}
+	}
+
+	if m.Allow("10.0.0.99") {
+		t.Error("Fresh IP should be rejected once the global bucket is exhausted")
+	}
+}
+
+// TestGlobalLimit_RejectedIPKeepsNoGlobalTokens verifies requests an IP's
+// own limit rejects hand back their global token, so one client hammering
+// past its limit cannot starve other clients.
+func TestGlobalLimit_RejectedIPKeepsNoGlobalTokens(t *testing.T) {
+	m := NewWithGlobal(0.001, 1, 0.001, 3)
+
+	if !m.Allow("10.0.0.1") {
+		t.Fatal("First request from 10.0.0.1 should be allowed")
+	}
+	for i := 0; i < 10; i++ {
+		if m.Allow("10.0.0.1") {
+			t.Fatalf("Request %d from exhausted 10.0.0.1 should be rejected", i)
+		}
+	}
+
+	for _, ip := range []string{"10.0.0.2", "10.0.0.3"} {
+		if !m.Allow(ip) {
+			t.Errorf("Request from %s should be allowed while global tokens remain", ip)
+		}
+	}
+	if m.Allow("10.0.0.4") {
+		t.Error("Fresh IP should be rejected once the global bucket is exhausted")
+	}
+}
+
+// TestGlobalLimit_DisabledByDefault verifies New and a zero global rate do
+// not add a global bucket.
+func TestGlobalLimit_DisabledByDefault(t *testing.T) {
+	for _, m := range []*Manager{New(100, 100), NewWithGlobal(100, 100, 0, 0)} {
+		for i := 0; i < 50; i++ {
+			if !m.Allow(fmt.Sprintf("10.0.0.%d", i)) {
+				t.Fatalf("Request from IP %d should be allowed without a global limit", i)
+			}
+		}
+		if _, ok := m.Stats()["global_tokens"]; ok {
+			t.Error("Stats should not report global tokens when disabled")
+		}
+	}
+}
+
+// TestGlobalLimit_StatsReportsRemainingTokens verifies Stats exposes the
+// remaining global tokens so operators can see how close traffic is to the
+// cap.
+func TestGlobalLimit_StatsReportsRemainingTokens(t *testing.T) {
+	m := NewWithGlobal(100, 100, 0.001, 5)
+
+	m.Allow("10.0.0.1")
+	m.Allow("10.0.0.2")
+
+	tokens, ok := m.Stats()["global_tokens"].(float64)
+	if !ok {
+		t.Fatal("Missing 'global_tokens' in stats")
+	}
+	if tokens < 2.9 || tokens > 3.1 {
+		t.Errorf("Expected about 3 global tokens remaining, got %v", tokens)
+	}
+}
+
+// -----------------------------------------------------------------------
+// Allowlist Tests
+// -----------------------------------------------------------------------
+
+// TestAllowlist_Contains verifies IPv4 and IPv6 matching for the address
+// forms GetIP returns: bare IPs and RemoteAddr with a port.
+func TestAllowlist_Contains(t *testing.T) {
+	allow, err := ParseAllowlist([]string{"10.0.0.0/8", " 192.168.1.0/24", "fd00::/8"})
+	if err != nil {
+		t.Fatalf("ParseAllowlist: %v", err)
+	}
+
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"10.1.2.3", true},
+		{"10.1.2.3:54321", true},
+		{"192.168.1.200", true},
+		{"192.168.2.1", false},
+		{"fd12::1", true},
+		{"[fd12::1]:8080", true},
+		{"2001:db8::1", false},
+		{"not-an-ip", false},
+	}
+
+	for _, tt := range tests {
+		if got := allow.Contains(tt.ip); got != tt.want {
+			t.Errorf("Contains(%q) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+// TestParseAllowlist_RejectsInvalid verifies a malformed CIDR is an error
+// rather than silently ignored, since a typo would leave a scraper throttled.
+func TestParseAllowlist_RejectsInvalid(t *testing.T) {
+	for _, cidr := range []string{"10.0.0.0", "10.0.0.0/33", "fd00::/129", "subnet"} {
+		if _, err := ParseAllowlist([]string{cidr}); err == nil {
+			t.Errorf("Expected error for %q", cidr)
+		}
+	}
+}
+
+// -----------------------------------------------------------------------
+// Runtime Limit Changes
+// -----------------------------------------------------------------------
+
+// TestSetLimits_UpdatesExistingLimiters verifies that a config reload can
+// raise limits for IPs that already have a bucket. Without updating the
+// per-IP limiters in place, clients seen before the reload would keep the
+// old limit until cleanup evicted them.
+func TestSetLimits_UpdatesExistingLimiters(t *testing.T) {
+	m := New(1, 1)
+	ip := "192.168.1.1"
+
+	if !m.Allow(ip) {
+		t.Fatal("First request should be allowed")
+	}
+	if m.Allow(ip) {
+		t.Fatal("Second request should be rejected at burst 1")
+	}
+
+	m.SetLimits(1000, 10)
+
+	if m.GetRate() != 1000 {
+		t.Errorf("Expected rate 1000, got %v", m.GetRate())
+	}
+
+	// At 1000 req/sec the bucket refills almost immediately
+	time.Sleep(10 * time.Millisecond)
+	if !m.Allow(ip) {
+		t.Error("Existing IP should pick up the new limit")
+	}
+}
@@ -0,0 +1,243 @@
+// -----------------------------------------------------------------------
+// StatsD Metrics Sink
+// -----------------------------------------------------------------------
+//
+// Package statsd mirrors a few key Prometheus metrics to a StatsD or
+// DogStatsD server over UDP, for deployments without a Prometheus server.
+// Values are read from the Prometheus registry at each flush, so /metrics
+// and StatsD always agree; this is an additional sink, not a replacement.
+//
+// Gauges are sent as-is. Counters are sent as the increase since the
+// previous flush, which is what StatsD counters expect.
+//
+// -----------------------------------------------------------------------
+
+package statsd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// DefaultFlushInterval is used when no flush interval is configured.
+const DefaultFlushInterval = 10 * time.Second
+
+// maxPacketSize keeps each datagram within a typical 1500-byte MTU.
+const maxPacketSize = 1432
+
+// Mirrored lists the Prometheus metric families sent to StatsD.
+var Mirrored = []string{
+	"monitored_service_status",
+	"health_check_requests_total",
+	"health_check_cache_staleness_seconds",
+}
+
+var logs = slog.Default().With("component", "statsd")
+
+// -----------------------------------------------------------------------
+// Types
+// -----------------------------------------------------------------------
+
+// Options configures a Sink.
+type Options struct {
+	// Addr is the StatsD server as host:port.
+	Addr string
+
+	// Interval is how often metrics are flushed. Zero selects
+	// DefaultFlushInterval.
+	Interval time.Duration
+
+	// Tags sends labels as DogStatsD tags (|#service:nginx). When false,
+	// label values are appended to the metric name for plain StatsD.
+	Tags bool
+}
+
+// Sink periodically sends the Mirrored metrics to a StatsD server.
+type Sink struct {
+	conn     net.Conn
+	opts     Options
+	gatherer prometheus.Gatherer
+
+	// counters holds the last value sent for each counter series so the
+	// next flush can send the increase
+	counters map[string]float64
+}
+
+// -----------------------------------------------------------------------
+// Construction
+// -----------------------------------------------------------------------
+
+// New creates a Sink reading from gatherer. UDP is connectionless, so an
+// error here means the address could not be resolved rather than that the
+// server is down.
+func New(opts Options, gatherer prometheus.Gatherer) (*Sink, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultFlushInterval
+	}
+
+	conn, err := net.Dial("udp", opts.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("statsd: %w", err)
+	}
+
+	return &Sink{
+		conn:     conn,
+		opts:     opts,
+		gatherer: gatherer,
+		counters: make(map[string]float64),
+	}, nil
+}
+
+// -----------------------------------------------------------------------
+// Flushing
+// -----------------------------------------------------------------------
+
+// Run flushes every interval until ctx is cancelled, then flushes once
+// more so the final values are not lost, and closes the connection.
+func (s *Sink) Run(ctx context.Context) {
+	defer s.conn.Close()
+
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.flushAndLog()
+			return
+		case <-ticker.C:
+			s.flushAndLog()
+		}
+	}
+}
+
+// flushAndLog flushes and logs a failure; a down StatsD server must not
+// affect health checking.
+func (s *Sink) flushAndLog() {
+	if err := s.Flush(); err != nil {
+		logs.Warn("statsd flush failed", "addr", s.opts.Addr, "err", err)
+	}
+}
+
+// Flush sends the current values of the Mirrored metrics.
+func (s *Sink) Flush() error {
+	lines, err := s.lines()
+	if err != nil {
+		return err
+	}
+
+	for _, packet := range packets(lines) {
+		if _, err := s.conn.Write([]byte(packet)); err != nil {
+			return fmt.Errorf("statsd: %w", err)
+		}
+	}
+	return nil
+}
+
+// lines renders the Mirrored metrics as StatsD lines.
+func (s *Sink) lines() ([]string, error) {
+	families, err := s.gatherer.Gather()
+	if err != nil {
+		return nil, fmt.Errorf("statsd: gather metrics: %w", err)
+	}
+
+	var lines []string
+	for _, family := range families {
+		if !slices.Contains(Mirrored, family.GetName()) {
+			continue
+		}
+
+		for _, m := range family.GetMetric() {
+			name, tags := s.series(family.GetName(), m.GetLabel())
+
+			switch family.GetType() {
+			case dto.MetricType_GAUGE:
+				lines = append(lines, line(name, m.GetGauge().GetValue(), "g", tags))
+
+			case dto.MetricType_COUNTER:
+				key := name + tags
+				value := m.GetCounter().GetValue()
+				delta := value - s.counters[key]
+
+				// A reset series (deleted and recreated) restarts from zero
+				if delta < 0 {
+					delta = value
+				}
+				s.counters[key] = value
+				if delta > 0 {
+					lines = append(lines, line(name, delta, "c", tags))
+				}
+			}
+		}
+	}
+	return lines, nil
+}
+
+// series returns the StatsD metric name and tag suffix for a labelled
+// series. Labels are sorted by the registry, so the result is stable.
+func (s *Sink) series(name string, labels []*dto.LabelPair) (string, string) {
+	if len(labels) == 0 {
+		return name, ""
+	}
+
+	if !s.opts.Tags {
+		parts := []string{name}
+		for _, l := range labels {
+			parts = append(parts, sanitize(l.GetValue()))
+		}
+		return strings.Join(parts, "."), ""
+	}
+
+	tags := make([]string, 0, len(labels))
+	for _, l := range labels {
+		tags = append(tags, l.GetName()+":"+sanitize(l.GetValue()))
+	}
+	return name, "|#" + strings.Join(tags, ",")
+}
+
+// -----------------------------------------------------------------------
+// Helpers
+// -----------------------------------------------------------------------
+
+// line formats one StatsD line, e.g. "name:1|g|#service:nginx".
+func line(name string, value float64, kind, tags string) string {
+	return name + ":" + strconv.FormatFloat(value, 'f', -1, 64) + "|" + kind + tags
+}
+
+// sanitize replaces characters with meaning in the StatsD line format.
+func sanitize(s string) string {
+	return strings.NewReplacer(":", "_", "|", "_", ",", "_", "#", "_", "@", "_").Replace(s)
+}
+
+// packets joins lines into newline-separated datagrams no larger than
+// maxPacketSize. Lines are sorted so related series share packets.
+func packets(lines []string) []string {
+	sort.Strings(lines)
+
+	var out []string
+	var b strings.Builder
+	for _, l := range lines {
+		if b.Len() > 0 && b.Len()+1+len(l) > maxPacketSize {
+			out = append(out, b.String())
+			b.Reset()
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(l)
+	}
+	if b.Len() > 0 {
+		out = append(out, b.String())
+	}
+	return out
+}
@@ -0,0 +1,145 @@
+// -----------------------------------------------------------------------
+// StatsD Metrics Sink - Tests
+// -----------------------------------------------------------------------
+//
+// Validates the StatsD line format against a local UDP listener, using a
+// private Prometheus registry so the tests control every value.
+//
+// -----------------------------------------------------------------------
+
+package statsd
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// newTestRegistry returns a registry holding mirrored and unmirrored
+// metrics, along with the counter and gauge to drive.
+func newTestRegistry(t *testing.T) (*prometheus.Registry, *prometheus.CounterVec, *prometheus.GaugeVec) {
+	t.Helper()
+
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "health_check_requests_total",
+		Help: "test",
+	}, []string{"status_code"})
+	status := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "monitored_service_status",
+		Help: "test",
+	}, []string{"service", "state"})
+	other := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "health_checker_healthy",
+		Help: "test",
+	})
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(requests, status, other)
+	return reg, requests, status
+}
+
+// listen starts a UDP listener on a free local port.
+func listen(t *testing.T) *net.UDPConn {
+	t.Helper()
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// receive reads one datagram.
+func receive(t *testing.T, conn *net.UDPConn) string {
+	t.Helper()
+
+	buf := make([]byte, maxPacketSize)
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return string(buf[:n])
+}
+
+// TestFlushSendsTaggedMetrics verifies gauges are sent as-is, counters as
+// the increase since the previous flush, and unmirrored metrics are
+// skipped.
+func TestFlushSendsTaggedMetrics(t *testing.T) {
+	reg, requests, status := newTestRegistry(t)
+	server := listen(t)
+
+	sink, err := New(Options{Addr: server.LocalAddr().String(), Tags: true}, reg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer sink.conn.Close()
+
+	status.WithLabelValues("nginx", "active").Set(1)
+	requests.WithLabelValues("200").Add(5)
+
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	want := "health_check_requests_total:5|c|#status_code:200\n" +
+		"monitored_service_status:1|g|#service:nginx,state:active"
+	if got := receive(t, server); got != want {
+		t.Errorf("First flush:\n got %q\nwant %q", got, want)
+	}
+
+	requests.WithLabelValues("200").Add(2)
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	got := receive(t, server)
+	if !strings.Contains(got, "health_check_requests_total:2|c|#status_code:200") {
+		t.Errorf("Expected counter delta of 2, got %q", got)
+	}
+	if strings.Contains(got, "health_checker_healthy") {
+		t.Errorf("Unmirrored metric sent: %q", got)
+	}
+}
+
+// TestSeriesWithoutTags verifies plain StatsD mode folds label values into
+// the metric name.
+func TestSeriesWithoutTags(t *testing.T) {
+	reg, _, status := newTestRegistry(t)
+	server := listen(t)
+
+	sink, err := New(Options{Addr: server.LocalAddr().String()}, reg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer sink.conn.Close()
+
+	status.WithLabelValues("nginx", "failed").Set(0)
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	if got, want := receive(t, server), "monitored_service_status.nginx.failed:0|g"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
+
+// TestPacketsSplitAtMaxSize verifies no datagram exceeds maxPacketSize.
+func TestPacketsSplitAtMaxSize(t *testing.T) {
+	var lines []string
+	for range 100 {
+		lines = append(lines, strings.Repeat("x", 40)+":1|g")
+	}
+
+	out := packets(lines)
+	if len(out) < 2 {
+		t.Fatalf("Expected several packets, got %d", len(out))
+	}
+	for _, p := range out {
+		if len(p) > maxPacketSize {
+			t.Errorf("Packet of %d bytes exceeds %d", len(p), maxPacketSize)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// -----------------------------------------------------------------------
+// OpenTelemetry Tracing
+// -----------------------------------------------------------------------
+//
+// Package tracing provides optional OpenTelemetry tracing. When
+// OTEL_EXPORTER_OTLP_ENDPOINT is set, Init installs an OTLP/HTTP exporter
+// and a batching tracer provider; the exporter reads the standard OTEL_*
+// environment variables for headers, timeouts and TLS. When the variable is
+// unset, the package-level tracer stays a no-op so instrumented code paths
+// allocate nothing and export nothing.
+//
+// -----------------------------------------------------------------------
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/afreidah/health-check-service/internal/version"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// EndpointEnv is the environment variable that enables tracing.
+const EndpointEnv = "OTEL_EXPORTER_OTLP_ENDPOINT"
+
+const instrumentationName = "github.com/afreidah/health-check-service"
+
+var (
+	tracer     trace.Tracer                  = noop.NewTracerProvider().Tracer(instrumentationName)
+	propagator propagation.TextMapPropagator = propagation.NewCompositeTextMapPropagator()
+)
+
+// -----------------------------------------------------------------------
+// Initialization
+// -----------------------------------------------------------------------
+
+// Init configures the OTLP exporter when EndpointEnv is set and returns a
+// shutdown function that flushes buffered spans. When tracing is disabled,
+// Init returns false and a shutdown function that does nothing.
+func Init(ctx context.Context) (shutdown func(context.Context) error, enabled bool, err error) {
+	noopShutdown := func(context.Context) error { return nil }
+
+	if os.Getenv(EndpointEnv) == "" {
+		return noopShutdown, false, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return noopShutdown, false, fmt.Errorf("create OTLP trace exporter: %w", err)
+	}
+
+	// Attributes listed first act as defaults; OTEL_SERVICE_NAME and
+	// OTEL_RESOURCE_ATTRIBUTES override them.
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			attribute.String("service.name", "health-check-service"),
+			attribute.String("service.version", version.Version),
+		),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return noopShutdown, false, fmt.Errorf("build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+
+	tracer = provider.Tracer(instrumentationName, trace.WithInstrumentationVersion(version.Version))
+	propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+	return provider.Shutdown, true, nil
+}
+
+// -----------------------------------------------------------------------
+// Span Helpers
+// -----------------------------------------------------------------------
+
+// Start creates a span as a child of any span carried by ctx.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, opts...)
+}
+
+// StartRequest creates a server span for r, continuing any trace context
+// propagated by the caller, and returns the request with the span attached.
+func StartRequest(r *http.Request, name string) (*http.Request, trace.Span) {
+	ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+		),
+	)
+	return r.WithContext(ctx), span
+}
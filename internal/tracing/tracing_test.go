@@ -0,0 +1,81 @@
+// -----------------------------------------------------------------------
+// OpenTelemetry Tracing - Tests
+// -----------------------------------------------------------------------
+//
+// Validates that tracing stays a no-op without an OTLP endpoint and that
+// spans are recorded once an endpoint is configured.
+//
+// -----------------------------------------------------------------------
+
+package tracing
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// restoreGlobals resets the package tracer and propagator after a test
+// that calls Init.
+func restoreGlobals(t *testing.T) {
+	t.Helper()
+	prevTracer, prevPropagator := tracer, propagator
+	t.Cleanup(func() { tracer, propagator = prevTracer, prevPropagator })
+}
+
+// TestInit_DisabledWithoutEndpoint verifies spans are non-recording when
+// OTEL_EXPORTER_OTLP_ENDPOINT is unset.
+func TestInit_DisabledWithoutEndpoint(t *testing.T) {
+	restoreGlobals(t)
+	t.Setenv(EndpointEnv, "")
+
+	shutdown, enabled, err := Init(context.Background())
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if enabled {
+		t.Error("Expected tracing to be disabled")
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown: %v", err)
+	}
+
+	_, span := Start(context.Background(), "test")
+	defer span.End()
+	if span.IsRecording() {
+		t.Error("Expected no-op span when tracing is disabled")
+	}
+}
+
+// TestInit_EnabledWithEndpoint verifies request spans are recorded and
+// continue a trace propagated via the traceparent header.
+func TestInit_EnabledWithEndpoint(t *testing.T) {
+	restoreGlobals(t)
+	t.Setenv(EndpointEnv, "http://127.0.0.1:0")
+
+	shutdown, enabled, err := Init(context.Background())
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if !enabled {
+		t.Fatal("Expected tracing to be enabled")
+	}
+
+	req := httptest.NewRequest("GET", "/health", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	_, span := StartRequest(req, "HealthHandler")
+	if !span.IsRecording() {
+		t.Error("Expected recording span when tracing is enabled")
+	}
+	if got := span.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected propagated trace ID, got %s", got)
+	}
+	span.End()
+
+	// The endpoint is unreachable; only shutdown completing matters here.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_ = shutdown(ctx)
+}
@@ -0,0 +1,29 @@
+// -----------------------------------------------------------------------
+// Build Metadata
+// -----------------------------------------------------------------------
+//
+// Package version exposes build metadata injected via linker flags during
+// compilation. Defaults identify a development build when the binary is
+// built without the Makefile.
+//
+// Example:
+//   go build -ldflags "-X github.com/afreidah/health-check-service/internal/version.Version=v1.2.3"
+//
+// -----------------------------------------------------------------------
+
+package version
+
+import "fmt"
+
+// Build-time metadata injected via linker flags during compilation.
+var (
+	Version = "dev"
+	Commit  = "unknown"
+	Date    = "unknown"
+)
+
+// String returns a one-line description of the build suitable for logs and
+// command-line output.
+func String() string {
+	return fmt.Sprintf("%s (commit %s, built %s)", Version, Commit, Date)
+}